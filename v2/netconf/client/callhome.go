@@ -0,0 +1,200 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"time"
+
+	"golang.org/x/crypto/ssh"
+)
+
+// Defines a listener for NETCONF Call Home over SSH, as described by RFC 8071.
+// The device initiates the TCP connection, after which the roles revert to normal: the listener
+// acts as the SSH client and the NETCONF client.
+
+// DefaultCallHomeSSHPort is the IANA assigned port for NETCONF Call Home over SSH.
+const DefaultCallHomeSSHPort = 4334
+
+// CallHomeDevice identifies a device that has called home.
+type CallHomeDevice struct {
+	// Address is the remote address of the device connection.
+	Address net.Addr
+	// HostKey is the host key presented by the device during the SSH handshake.
+	HostKey ssh.PublicKey
+}
+
+// CallHomeCredentials defines the secrets used to authenticate with a specific device.
+type CallHomeCredentials struct {
+	// Password, if defined, is used for password authentication.
+	Password string
+	// Signers, if defined, are used for public key authentication.
+	Signers []ssh.Signer
+}
+
+// CallHomeCredentialsFunc is called during the SSH handshake, once a device has presented its host key.
+// It delivers the credentials to be used for the device, or an error if the device should be rejected.
+type CallHomeCredentialsFunc func(dev *CallHomeDevice) (*CallHomeCredentials, error)
+
+// CallHomeHandler is called, on its own goroutine, for each device that establishes a netconf session.
+// The handler is responsible for closing the session.
+type CallHomeHandler func(dev *CallHomeDevice, s Session)
+
+// CallHomeConfig defines properties that configure call home listener behaviour.
+type CallHomeConfig struct {
+	// SSHConfig defines the ssh client configuration used for the handshake with every device.
+	// The User is used for all devices; the HostKeyCallback, if defined, is used to verify a device before
+	// its credentials are selected. The Timeout, if defined, limits the time allowed for the handshake and
+	// the hello exchange; otherwise, the SetupTimeoutSecs of the netconf configuration is used.
+	SSHConfig *ssh.ClientConfig
+	// Credentials, if defined, selects the credentials for a device based on its host key.
+	// Otherwise, the Auth methods defined by SSHConfig are used for all devices.
+	Credentials CallHomeCredentialsFunc
+	// Config defines the netconf session configuration. If nil, DefaultConfig is used.
+	Config *Config
+}
+
+// CallHomeListener accepts connections from devices that call home.
+type CallHomeListener struct {
+	ctx      context.Context
+	listener net.Listener
+	cfg      *CallHomeConfig
+	handler  CallHomeHandler
+	trace    *ClientTrace
+	wg       sync.WaitGroup
+}
+
+// NewCallHomeListener starts listening for devices calling home on the address, establishing a netconf
+// session with each one according to the configuration, and delivering it to the handler.
+func NewCallHomeListener(ctx context.Context, address string, cfg *CallHomeConfig, handler CallHomeHandler) (l *CallHomeListener, err error) {
+
+	if cfg == nil || cfg.SSHConfig == nil {
+		return nil, errors.New("call home ssh configuration must be defined")
+	}
+	if cfg.SSHConfig.HostKeyCallback == nil && cfg.Credentials == nil {
+		return nil, errors.New("call home requires a host key callback or credentials function to verify devices")
+	}
+
+	l = &CallHomeListener{ctx: ctx, cfg: cfg, handler: handler, trace: ContextClientTrace(ctx)}
+	if l.listener, err = net.Listen("tcp", address); err != nil {
+		return nil, err
+	}
+
+	l.wg.Add(1)
+	go l.acceptConnections()
+	return l, nil
+}
+
+// Port delivers the tcp port number on which the listener is accepting connections.
+func (l *CallHomeListener) Port() int {
+	return l.listener.Addr().(*net.TCPAddr).Port
+}
+
+// Close stops the listener accepting further connections.
+// Sessions already delivered to the handler are unaffected.
+func (l *CallHomeListener) Close() {
+	// nolint: gosec, errcheck
+	l.listener.Close()
+	l.wg.Wait()
+}
+
+func (l *CallHomeListener) acceptConnections() {
+	defer l.wg.Done()
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			return
+		}
+		go l.handleConnection(conn)
+	}
+}
+
+func (l *CallHomeListener) handleConnection(conn net.Conn) {
+
+	dev := &CallHomeDevice{Address: conn.RemoteAddr()}
+	target := dev.Address.String()
+	sshcfg := l.deviceSSHConfig(dev)
+
+	cfg := l.cfg.Config
+	if cfg == nil {
+		cfg = DefaultConfig
+	}
+
+	// The ssh Timeout only applies when dialling, so the connection deadline bounds the handshake and hello
+	// exchange with a device that stalls.
+	_ = conn.SetDeadline(time.Now().Add(setupTimeout(sshcfg, cfg)))
+
+	t, err := newSSHTransport(l.ctx, sshcfg, target, "netconf", func() (*ssh.Client, error) {
+		c, chans, reqs, err := ssh.NewClientConn(conn, target, sshcfg)
+		if err != nil {
+			return nil, err
+		}
+		return ssh.NewClient(c, chans, reqs), nil
+	})
+	if err != nil {
+		l.trace.Error("Call home connection failed", target, err)
+		return
+	}
+
+	s, err := newSessionOverTransport(l.ctx, t, cfg)
+	if err != nil {
+		l.trace.Error("Call home session failed", target, err)
+		return
+	}
+	_ = conn.SetDeadline(time.Time{})
+	l.handler(dev, s)
+}
+
+// setupTimeout delivers the time allowed for the handshake and hello exchange with a device.
+func setupTimeout(sshcfg *ssh.ClientConfig, cfg *Config) time.Duration {
+	if sshcfg.Timeout > 0 {
+		return sshcfg.Timeout
+	}
+	if cfg.SetupTimeoutSecs > 0 {
+		return time.Duration(cfg.SetupTimeoutSecs) * time.Second
+	}
+	return time.Duration(DefaultConfig.SetupTimeoutSecs) * time.Second
+}
+
+// deviceSSHConfig delivers the ssh configuration for a device connection, arranging for the device host key
+// to be recorded, and the device credentials to be selected when the host key is presented.
+func (l *CallHomeListener) deviceSSHConfig(dev *CallHomeDevice) *ssh.ClientConfig {
+
+	var creds *CallHomeCredentials
+
+	sshcfg := *l.cfg.SSHConfig
+	sshcfg.HostKeyCallback = func(hostname string, remote net.Addr, key ssh.PublicKey) (err error) {
+		if l.cfg.SSHConfig.HostKeyCallback != nil {
+			if err = l.cfg.SSHConfig.HostKeyCallback(hostname, remote, key); err != nil {
+				return
+			}
+		}
+		dev.HostKey = key
+		if l.cfg.Credentials != nil {
+			creds, err = l.cfg.Credentials(dev)
+			if err == nil && creds == nil {
+				err = fmt.Errorf("no credentials defined for device %s", ssh.FingerprintSHA256(key))
+			}
+		}
+		return
+	}
+
+	if l.cfg.Credentials != nil {
+		// The host key callback is invoked before authentication, so the device credentials will be
+		// available when the auth methods are called.
+		sshcfg.Auth = []ssh.AuthMethod{
+			ssh.PublicKeysCallback(func() ([]ssh.Signer, error) {
+				return creds.Signers, nil
+			}),
+			ssh.PasswordCallback(func() (string, error) {
+				if creds.Password == "" {
+					return "", errors.New("no password defined for device")
+				}
+				return creds.Password, nil
+			}),
+		}
+	}
+	return &sshcfg
+}
@@ -0,0 +1,173 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+	"github.com/damianoneill/net/v2/netconf/testserver"
+
+	assert "github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestCallHome(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t)
+	defer ts.Close()
+
+	devices := map[string]*CallHomeCredentials{
+		string(ts.HostKey().Marshal()): {Password: testserver.TestPassword},
+	}
+
+	cfg := &CallHomeConfig{
+		SSHConfig: &ssh.ClientConfig{User: testserver.TestUserName},
+		Credentials: func(dev *CallHomeDevice) (*CallHomeCredentials, error) {
+			return devices[string(dev.HostKey.Marshal())], nil
+		},
+	}
+
+	type called struct {
+		dev *CallHomeDevice
+		s   Session
+	}
+	sch := make(chan called)
+	l, err := NewCallHomeListener(context.Background(), "localhost:0", cfg, func(dev *CallHomeDevice, s Session) {
+		sch <- called{dev: dev, s: s}
+	})
+	assert.NoError(t, err, "Not expecting new listener to fail")
+	defer l.Close()
+
+	assert.NoError(t, ts.CallHome(fmt.Sprintf("localhost:%d", l.Port())), "Not expecting call home to fail")
+
+	c := <-sch
+	defer c.s.Close()
+
+	assert.True(t, bytes.Equal(ts.HostKey().Marshal(), c.dev.HostKey.Marshal()), "Expected device host key")
+	assert.NotNil(t, c.dev.Address, "Expected device address")
+
+	reply, err := c.s.Execute(common.Request(`<get><response/></get>`))
+	assert.NoError(t, err, "Not expecting exec to fail")
+	assert.Equal(t, `<data><response/></data>`, reply.Data, "Reply should contain response data")
+}
+
+func TestCallHomeWithSharedCredentials(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t)
+	defer ts.Close()
+
+	cfg := &CallHomeConfig{
+		SSHConfig: &ssh.ClientConfig{
+			User:            testserver.TestUserName,
+			Auth:            []ssh.AuthMethod{ssh.Password(testserver.TestPassword)},
+			HostKeyCallback: ssh.FixedHostKey(ts.HostKey()),
+		},
+		Config: &Config{SetupTimeoutSecs: 1},
+	}
+
+	sch := make(chan Session)
+	l, err := NewCallHomeListener(context.Background(), "localhost:0", cfg, func(dev *CallHomeDevice, s Session) {
+		sch <- s
+	})
+	assert.NoError(t, err, "Not expecting new listener to fail")
+	defer l.Close()
+
+	assert.NoError(t, ts.CallHome(fmt.Sprintf("localhost:%d", l.Port())), "Not expecting call home to fail")
+
+	s := <-sch
+	defer s.Close()
+	assert.Contains(t, s.ServerCapabilities(), common.CapBase10, "Failed to retrieve expected capabilities")
+}
+
+func TestCallHomeUnknownDevice(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t)
+	defer ts.Close()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	var traced []string
+	trace := &ClientTrace{
+		ConnectStart: func(clientConfig *ssh.ClientConfig, target string) {
+			traced = append(traced, "ConnectStart "+target)
+		},
+		ConnectDone: func(clientConfig *ssh.ClientConfig, target string, err error, d time.Duration) {
+			traced = append(traced, fmt.Sprintf("ConnectDone %s %v", target, err != nil))
+		},
+		Error: func(context, target string, err error) {
+			traced = append(traced, context)
+			wg.Done()
+		},
+	}
+
+	cfg := &CallHomeConfig{
+		SSHConfig: &ssh.ClientConfig{User: testserver.TestUserName},
+		Credentials: func(dev *CallHomeDevice) (*CallHomeCredentials, error) {
+			return nil, errors.New("unknown device")
+		},
+	}
+
+	l, err := NewCallHomeListener(WithClientTrace(context.Background(), trace), "localhost:0", cfg, func(dev *CallHomeDevice, s Session) {
+		assert.Fail(t, "Not expecting session to be established")
+	})
+	assert.NoError(t, err, "Not expecting new listener to fail")
+	defer l.Close()
+
+	assert.NoError(t, ts.CallHome(fmt.Sprintf("localhost:%d", l.Port())), "Not expecting call home to fail")
+
+	wg.Wait()
+	assert.Equal(t, 3, len(traced), "Expected trace events")
+	assert.Contains(t, traced[0], "ConnectStart 127.0.0.1:")
+	assert.Contains(t, traced[1], " true")
+	assert.Equal(t, "Call home connection failed", traced[2])
+}
+
+func TestCallHomeHandshakeTimeout(t *testing.T) {
+
+	failed := make(chan error, 1)
+	trace := &ClientTrace{
+		Error: func(context, target string, err error) {
+			failed <- err
+		},
+	}
+
+	cfg := &CallHomeConfig{
+		SSHConfig: &ssh.ClientConfig{User: testserver.TestUserName, HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+			Timeout: time.Millisecond * time.Duration(100)},
+	}
+
+	l, err := NewCallHomeListener(WithClientTrace(context.Background(), trace), "localhost:0", cfg, func(dev *CallHomeDevice, s Session) {
+		assert.Fail(t, "Not expecting session to be established")
+	})
+	assert.NoError(t, err, "Not expecting new listener to fail")
+	defer l.Close()
+
+	// A device that connects, but never starts the handshake, is dropped.
+	conn, err := net.Dial("tcp", fmt.Sprintf("localhost:%d", l.Port()))
+	assert.NoError(t, err, "Not expecting dial to fail")
+	defer conn.Close()
+
+	select {
+	case err = <-failed:
+		assert.Error(t, err, "Expected handshake to fail")
+	case <-time.After(time.Second * time.Duration(5)):
+		assert.Fail(t, "Expected handshake to time out")
+	}
+}
+
+func TestCallHomeInvalidConfig(t *testing.T) {
+
+	handler := func(dev *CallHomeDevice, s Session) {}
+
+	_, err := NewCallHomeListener(context.Background(), "localhost:0", nil, handler)
+	assert.Error(t, err, "Expecting missing configuration to fail")
+
+	_, err = NewCallHomeListener(context.Background(), "localhost:0", &CallHomeConfig{SSHConfig: &ssh.ClientConfig{}}, handler)
+	assert.Error(t, err, "Expecting configuration without host key verification to fail")
+}
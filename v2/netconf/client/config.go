@@ -8,6 +8,10 @@ type Config struct {
 	SetupTimeoutSecs int
 	// Indicates that the client should not advertised chunked encoding capability.
 	DisableChunkedCodec bool
+	// Indicates that the session should be closed if an rpc-reply is received that does not correlate with
+	// an outstanding request, for example one that is duplicated, or has an unknown or missing message-id.
+	// Otherwise, such replies are reported via the ClientTrace ReplyUnmatched hook and discarded.
+	StrictReplyCorrelation bool
 }
 
 var DefaultConfig = &Config{
@@ -5,7 +5,6 @@ import (
 	"encoding/xml"
 	"errors"
 	"fmt"
-	"sync/atomic"
 	"time"
 
 	uuid "github.com/satori/go.uuid"
@@ -24,8 +23,24 @@ import (
 // Session represents a Netconf Session
 type Session interface {
 	// Execute executes an RPC request on the server and returns the reply.
+	// If the reply holds any rpc-error with error severity, the error will be a *common.RPCError, or a
+	// *common.RPCErrors if there is more than one. Warnings are available from the reply, which is returned
+	// even if there is an error.
 	Execute(req common.Request) (*common.RPCReply, error)
 
+	// ExecuteContext executes an RPC request on the server and returns the reply.
+	// If ctx is done before the reply is received, the context error is returned and the reply
+	// will be discarded when it arrives.
+	ExecuteContext(ctx context.Context, req common.Request) (*common.RPCReply, error)
+
+	// ExecuteStream executes an RPC request on the server, passing each top-level element of the <data> content
+	// of the reply to the handler as it is received, rather than holding it in the reply, which will have no Data.
+	// This allows very large replies to be processed in constant memory.
+	// The handler is called by the goroutine that receives all messages from the server, so it must not issue
+	// further requests on the session, and other replies and notifications are delayed until it returns.
+	// If ctx is done before the reply is received, the context error is returned and any remaining data is discarded.
+	ExecuteStream(ctx context.Context, req common.Request, handler DataHandler) (*common.RPCReply, error)
+
 	// ExecuteAsync submits an RPC request for execution on the server, arranging for the
 	// reply to be sent to the supplied channel.
 	ExecuteAsync(req common.Request, rchan chan *common.RPCReply) (err error)
@@ -34,11 +49,27 @@ type Session interface {
 	// be sent to the supplied channel.
 	Subscribe(req common.Request, nchan chan *common.Notification) (reply *common.RPCReply, err error)
 
+	// SubscribeContext issues an RPC request and returns the reply, honouring ctx as for ExecuteContext.
+	// If successful, notifications will be sent to the supplied channel.
+	SubscribeContext(ctx context.Context, req common.Request, nchan chan *common.Notification) (reply *common.RPCReply, err error)
+
+	// SubscribeWithOptions issues an RPC request and returns the reply, honouring ctx as for ExecuteContext.
+	// If successful, notifications will be routed to the supplied channel and delivered according to the options.
+	// A session may hold several subscriptions, each with its own channel.
+	SubscribeWithOptions(ctx context.Context, req common.Request, opts *SubscriptionOptions, nchan chan *common.Notification) (reply *common.RPCReply, err error)
+
+	// Unsubscribe stops notifications being sent to the channel of a subscription, and closes it.
+	// Other subscriptions are unaffected. It does not issue any request to the server.
+	Unsubscribe(nchan chan *common.Notification)
+
+	// NotificationCounters delivers the number of notifications received, delivered and dropped by the session.
+	NotificationCounters() NotificationCounters
+
 	// Close closes the session and releases any associated resources.
 	// The channel will be automatically closed if the underlying network connection is closed, for
 	// example if the remote server discoonects.
-	// When the session is closed, any outstanding execute requests and reads from a notification
-	// channel will return nil.
+	// When the session is closed, any outstanding execute requests and reads from notification
+	// channels will return nil.
 	Close()
 
 	// ID delivers the server-allocated id of the session.
@@ -46,6 +77,9 @@ type Session interface {
 
 	// Capabilities delivers the server-supplied capabilities.
 	ServerCapabilities() []string
+
+	// Capabilities delivers the parsed set of server-supplied capabilities.
+	Capabilities() *common.Capabilities
 }
 
 type sesImpl struct {
@@ -58,19 +92,36 @@ type sesImpl struct {
 	pool []chan *common.RPCReply
 
 	hellochan chan bool
-	responseq []chan *common.RPCReply
-	subchan   chan *common.Notification
+	// Closed when the handling of incoming messages ends, i.e. when the underlying connection has closed.
+	done chan struct{}
+	// Outstanding requests, keyed by message-id.
+	responses map[string]chan *common.RPCReply
+	// Outstanding requests whose reply data is to be streamed, keyed by message-id.
+	streams map[string]*dataStream
+	// The message-ids of recently completed requests, used to identify duplicate replies.
+	completed []string
+	// Notification subscriptions, in the order they were made.
+	subs []*subscription
 
 	hello   *common.HelloMessage
+	caps    *common.Capabilities
 	reqLock sync.Mutex
 	pchLock sync.Mutex
 	rchLock sync.Mutex
+	subLock sync.Mutex
 
-	notificationDropCount uint64
+	notificationCounters NotificationCounters
 
 	target string
 }
 
+// The number of completed request message-ids retained to identify duplicate replies.
+const completedHistorySize = 32
+
+// The time for which the response channel of a cancelled request remains registered, so that a late reply is
+// discarded rather than being reported as unmatched. After this, the request is treated as completed.
+var cancelledReplyGracePeriod = time.Minute
+
 // NewSession creates a new Netconf session, using the supplied Transport.
 func NewSession(ctx context.Context, t Transport, cfg *Config) (Session, error) {
 
@@ -82,7 +133,10 @@ func NewSession(ctx context.Context, t Transport, cfg *Config) (Session, error)
 		enc:    codec.NewEncoder(t),
 		trace:  ContextClientTrace(ctx),
 
-		hellochan: make(chan bool)}
+		responses: make(map[string]chan *common.RPCReply),
+		streams:   make(map[string]*dataStream),
+		hellochan: make(chan bool),
+		done:      make(chan struct{})}
 
 	// Send hello
 	err := si.enc.Encode(&common.HelloMessage{Capabilities: si.clientCapabilities()})
@@ -112,6 +166,14 @@ func (si *sesImpl) clientCapabilities() []string {
 }
 
 func (si *sesImpl) Execute(req common.Request) (reply *common.RPCReply, err error) {
+	return si.ExecuteContext(context.Background(), req)
+}
+
+func (si *sesImpl) ExecuteContext(ctx context.Context, req common.Request) (reply *common.RPCReply, err error) {
+	return si.executeContext(ctx, newMessageID(), req)
+}
+
+func (si *sesImpl) executeContext(ctx context.Context, msgID string, req common.Request) (reply *common.RPCReply, err error) {
 
 	si.trace.ExecuteStart(req, false)
 
@@ -121,16 +183,27 @@ func (si *sesImpl) Execute(req common.Request) (reply *common.RPCReply, err erro
 
 	// Allocate a response channel
 	rchan := si.allocChan()
-	defer si.relChan(rchan)
 
 	// Submit the request
-	err = si.execute(req, rchan)
+	err = si.execute(msgID, req, rchan)
 	if err != nil {
+		si.relChan(rchan)
 		return nil, err
 	}
 
 	// Wait for the response.
-	reply = <-rchan
+	select {
+	case reply = <-rchan:
+		si.relChan(rchan)
+	case <-ctx.Done():
+		// The response channel remains registered against the message-id for a grace period, so that a late
+		// reply is discarded rather than being reported as unmatched.
+		// It is not returned to the pool, as the late reply may still be written to it.
+		time.AfterFunc(cancelledReplyGracePeriod, func() {
+			si.removeRespChan(msgID)
+		})
+		return nil, ctx.Err()
+	}
 
 	err = mapError(reply)
 	return reply, err
@@ -143,33 +216,27 @@ func (si *sesImpl) ExecuteAsync(req common.Request, rchan chan *common.RPCReply)
 		si.trace.ExecuteDone(req, true, nil, err, time.Since(begin))
 	}(time.Now())
 
-	return si.execute(req, rchan)
+	return si.execute(newMessageID(), req, rchan)
 }
 
-func (si *sesImpl) execute(req common.Request, rchan chan *common.RPCReply) (err error) {
+func (si *sesImpl) execute(msgID string, req common.Request, rchan chan *common.RPCReply) (err error) {
 
 	// Build the request to be submitted.
-	msg := &common.RPCMessage{MessageID: uuid.NewV4().String(), Union: common.GetUnion(req)}
+	msg := &common.RPCMessage{MessageID: msgID, Union: common.GetUnion(req)}
 
-	// Lock the request channel, so the request and response channel set up is atomic.
+	// Lock the request channel, so that requests are encoded one at a time.
 	si.reqLock.Lock()
 	defer si.reqLock.Unlock()
 
-	// Add the response channel to the response queue, but take it off if the request was not
+	// Register the response channel against the message id, but remove it if the request was not
 	// submitted successfully.
-	si.pushRespChan(rchan)
+	si.addRespChan(msg.MessageID, rchan)
 	if err = si.enc.Encode(msg); err != nil {
-		si.popRespChan()
+		si.removeRespChan(msg.MessageID)
 	}
 	return
 }
 
-func (si *sesImpl) Subscribe(req common.Request, nchan chan *common.Notification) (reply *common.RPCReply, err error) {
-	// Store the notification channel for the session.
-	si.subchan = nchan
-	return si.Execute(req)
-}
-
 func (si *sesImpl) Close() {
 	err := si.t.Close()
 	if err != nil {
@@ -185,6 +252,10 @@ func (si *sesImpl) ServerCapabilities() []string {
 	return si.hello.Capabilities
 }
 
+func (si *sesImpl) Capabilities() *common.Capabilities {
+	return si.caps
+}
+
 func (si *sesImpl) waitForServerHello() (err error) {
 
 	select {
@@ -242,7 +313,8 @@ func (si *sesImpl) handleHello(token xml.StartElement) (err error) {
 		return
 	}
 
-	if !si.cfg.DisableChunkedCodec && common.PeerSupportsChunkedFraming(si.hello.Capabilities) {
+	si.caps = common.ParseCapabilities(si.hello.Capabilities)
+	if !si.cfg.DisableChunkedCodec && si.caps.Supports(common.CapBase11) {
 		// Update the codec to use chunked framing from now.
 		codec.EnableChunkedFraming(si.dec, si.enc)
 	}
@@ -254,47 +326,33 @@ func (si *sesImpl) handleHello(token xml.StartElement) (err error) {
 
 func (si *sesImpl) handleRPCReply(token xml.StartElement) (err error) {
 	reply := common.RPCReply{}
-	if err = si.decodeElement(&reply, &token); err != nil {
-		return
+	if ds := si.removeDataStream(messageID(token)); ds != nil {
+		err = si.streamRPCReply(&reply, token, ds)
+	} else {
+		err = si.decodeElement(&reply, &token)
 	}
-
-	// Pop the channel off the head of the queue and send the reply to it.
-	respch := si.popRespChan()
-	go func(ch chan *common.RPCReply, r *common.RPCReply) {
-		ch <- r
-	}(respch, &reply)
-	return
-}
-
-func (si *sesImpl) handleNotification(token xml.StartElement) (err error) {
-	result := &common.NotificationMessage{}
-	if err = si.decodeElement(&result, &token); err != nil {
+	if err != nil {
 		return
 	}
 
-	// Send notification to subscription channel, if it's defined and not full.
-	if si.subchan != nil {
-		notification := buildNotification(result)
-
-		si.trace.NotificationReceived(notification)
-
-		select {
-		case si.subchan <- notification:
-		default:
-			atomic.AddUint64(&si.notificationDropCount, 1)
-			si.trace.NotificationDropped(notification)
+	// Find the channel of the request that the reply correlates with, and send the reply to it.
+	respch, duplicate := si.removeRespChan(reply.MessageID)
+	if respch == nil {
+		si.trace.ReplyUnmatched(&reply, duplicate)
+		if si.cfg.StrictReplyCorrelation {
+			err = fmt.Errorf("rpc-reply message-id:%q does not match an outstanding request", reply.MessageID)
+			si.trace.Error("Protocol violation", si.target, err)
+			si.Close()
 		}
+		return
 	}
+	si.bindSubscription(&reply)
+	go func(ch chan *common.RPCReply, r *common.RPCReply) {
+		ch <- r
+	}(respch, &reply)
 	return
 }
 
-func buildNotification(nmsg *common.NotificationMessage) *common.Notification {
-	event := fmt.Sprintf(`<%s xmlns="%s">%s</%s>`,
-		nmsg.Event.XMLName.Local, nmsg.Event.XMLName.Space, nmsg.Event.Event, nmsg.Event.XMLName.Local)
-	notification := &common.Notification{XMLName: nmsg.Event.XMLName, EventTime: nmsg.EventTime, Event: event}
-	return notification
-}
-
 func (si *sesImpl) decodeElement(v interface{}, start *xml.StartElement) (err error) {
 	if err = si.dec.DecodeElement(v, start); err != nil {
 		si.trace.Error(fmt.Sprintf("DecodeElement token:%s", start.Name.Local), si.target, err)
@@ -304,19 +362,21 @@ func (si *sesImpl) decodeElement(v interface{}, start *xml.StartElement) (err er
 
 func (si *sesImpl) closeChannels() {
 	close(si.hellochan)
-	if si.subchan != nil {
-		close(si.subchan)
-	}
+	si.closeSubscriptions()
 	si.closeAllResponseChannels()
+	close(si.done)
+}
+
+func (si *sesImpl) closed() <-chan struct{} {
+	return si.done
 }
 
 func (si *sesImpl) closeAllResponseChannels() {
-	for {
-		if ch := si.popRespChan(); ch != nil {
-			close(ch)
-		} else {
-			return
-		}
+	si.rchLock.Lock()
+	defer si.rchLock.Unlock()
+	for id, ch := range si.responses {
+		delete(si.responses, id)
+		close(ch)
 	}
 }
 
@@ -326,7 +386,8 @@ func (si *sesImpl) allocChan() (ch chan *common.RPCReply) {
 
 	l := len(si.pool)
 	if l == 0 {
-		return make(chan *common.RPCReply)
+		// Buffered, so that delivery of a reply never blocks, even if the requester has given up waiting.
+		return make(chan *common.RPCReply, 1)
 	}
 
 	si.pool, ch = si.pool[:l-1], si.pool[l-1]
@@ -339,34 +400,44 @@ func (si *sesImpl) relChan(ch chan *common.RPCReply) {
 	si.pool = append(si.pool, ch)
 }
 
-func (si *sesImpl) pushRespChan(ch chan *common.RPCReply) {
+func (si *sesImpl) addRespChan(id string, ch chan *common.RPCReply) {
 	si.rchLock.Lock()
 	defer si.rchLock.Unlock()
-	si.responseq = append(si.responseq, ch)
-
+	si.responses[id] = ch
 }
 
-func (si *sesImpl) popRespChan() (ch chan *common.RPCReply) {
+// removeRespChan removes and returns the response channel registered against the message id.
+// If there is none, duplicate indicates whether the id belongs to a recently completed request.
+func (si *sesImpl) removeRespChan(id string) (ch chan *common.RPCReply, duplicate bool) {
 	si.rchLock.Lock()
 	defer si.rchLock.Unlock()
-	if len(si.responseq) > 0 {
-		si.responseq, ch = si.responseq[1:], si.responseq[0]
+	ch, ok := si.responses[id]
+	if !ok {
+		for _, cid := range si.completed {
+			if cid == id {
+				return nil, true
+			}
+		}
+		return nil, false
 	}
-	return
+	delete(si.responses, id)
+	if len(si.completed) == completedHistorySize {
+		si.completed = si.completed[1:]
+	}
+	si.completed = append(si.completed, id)
+	return ch, false
+}
+
+func newMessageID() string {
+	return uuid.NewV4().String()
 }
 
-// Map an RPC reply to an error, if the reply is either null or contains any RPC error.
+// Map an RPC reply to an error, if the reply is either null or contains any RPC error with error severity.
 func mapError(r *common.RPCReply) (err error) {
 	if r == nil {
 		err = io.ErrUnexpectedEOF
-	} else if r.Errors != nil {
-		for i := 0; i < len(r.Errors); i++ {
-			rpcErr := r.Errors[i]
-			if rpcErr.Severity == "error" {
-				err = &rpcErr
-				break
-			}
-		}
+	} else {
+		err = r.Err()
 	}
 	return
 }
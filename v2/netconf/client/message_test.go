@@ -3,9 +3,10 @@ package client
 import (
 	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
+	"io"
 	"sync"
-	"sync/atomic"
 	"testing"
 	"time"
 
@@ -85,6 +86,27 @@ func TestExecuteWithFailingRequest(t *testing.T) {
 	assert.NotNil(t, reply, "Reply should be non-nil")
 }
 
+func TestExecuteWithMultipleErrors(t *testing.T) {
+
+	ncs := newNCClientSession(t, testserver.NewTestNetconfServer(t).
+		WithRequestHandler(testserver.RawReplyRequestHandler(
+			`<rpc-error><error-tag>in-use</error-tag><error-severity>error</error-severity><error-message>first</error-message></rpc-error>`+
+				`<rpc-error><error-tag>data-exists</error-tag><error-severity>error</error-severity><error-message>second</error-message></rpc-error>`+
+				`<rpc-error><error-tag>operation-failed</error-tag><error-severity>warning</error-severity></rpc-error>`)).
+		WithRequestHandler(testserver.RawReplyRequestHandler(
+			`<rpc-error><error-tag>operation-failed</error-tag><error-severity>warning</error-severity><error-message>careful</error-message></rpc-error><ok/>`)))
+	defer ncs.Close()
+
+	reply, err := ncs.Execute(common.Request(`<get><response/></get>`))
+	assert.Equal(t, "netconf rpc [error] 'first'; netconf rpc [error] 'second'", err.Error(), "Expected all errors")
+	assert.True(t, errors.Is(err, common.ErrDataExists), "Expected data-exists error")
+	assert.Len(t, reply.Warnings(), 1, "Expected warning")
+
+	reply, err = ncs.Execute(common.Request(`<get><response/></get>`))
+	assert.NoError(t, err, "Not expecting warning to fail exec")
+	assert.Equal(t, "careful", reply.Warnings()[0].Message, "Expected warning")
+}
+
 func TestExecuteFailure(t *testing.T) {
 
 	ts := testserver.NewTestNetconfServer(t)
@@ -132,6 +154,170 @@ func TestNewSessionWithNoChunkedCodec(t *testing.T) {
 	assert.Equal(t, "<response/>", sh.LastReq().Body, "Expected request body")
 }
 
+func TestExecuteContextTimeout(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t).WithRequestHandler(testserver.DelayedEchoRequestHandler(time.Millisecond * time.Duration(500)))
+
+	var traced error
+	trace := &ClientTrace{
+		ExecuteDone: func(req common.Request, async bool, res *common.RPCReply, err error, d time.Duration) {
+			traced = err
+		},
+	}
+	ncs, err := NewRPCSession(WithClientTrace(context.Background(), trace), sshConfig(), fmt.Sprintf("localhost:%d", ts.Port()))
+	assert.NoError(t, err, "Failed to create session")
+	defer ncs.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*time.Duration(100))
+	defer cancel()
+
+	reply, err := ncs.ExecuteContext(ctx, common.Request(`<get><late/></get>`))
+	assert.Equal(t, context.DeadlineExceeded, err, "Expecting exec to time out")
+	assert.Nil(t, reply, "Reply should be nil")
+	assert.Equal(t, context.DeadlineExceeded, traced, "Expecting timeout to be traced")
+
+	// The late reply must not be delivered to the next request.
+	reply, err = ncs.Execute(common.Request(`<get><prompt/></get>`))
+	assert.NoError(t, err, "Not expecting exec to fail")
+	assert.Equal(t, `<data><prompt/></data>`, reply.Data, "Reply should contain response data")
+}
+
+func TestExecuteContextCancelled(t *testing.T) {
+
+	ncs := newNCClientSession(t, testserver.NewTestNetconfServer(t).WithRequestHandler(testserver.IgnoreRequestHandler))
+	defer ncs.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(time.Millisecond*time.Duration(100), cancel)
+
+	reply, err := ncs.ExecuteContext(ctx, common.Request(`<get><test1/></get>`))
+	assert.Equal(t, context.Canceled, err, "Expecting exec to be cancelled")
+	assert.Nil(t, reply, "Reply should be nil")
+}
+
+func TestExecuteContextCancelledExpiry(t *testing.T) {
+
+	defer func(grace time.Duration) { cancelledReplyGracePeriod = grace }(cancelledReplyGracePeriod)
+	cancelledReplyGracePeriod = time.Millisecond * time.Duration(50)
+
+	ncs := newNCClientSession(t, testserver.NewTestNetconfServer(t).WithRequestHandler(testserver.IgnoreRequestHandler))
+	defer ncs.Close()
+	si := ncs.(*sesImpl)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*time.Duration(50))
+	defer cancel()
+	_, err := ncs.ExecuteContext(ctx, common.Request(`<get><test1/></get>`))
+	assert.Equal(t, context.DeadlineExceeded, err, "Expecting exec to time out")
+
+	registered := func() (responses, completed int) {
+		si.rchLock.Lock()
+		defer si.rchLock.Unlock()
+		return len(si.responses), len(si.completed)
+	}
+	n, _ := registered()
+	assert.Equal(t, 1, n, "Expecting cancelled request to remain registered")
+
+	time.Sleep(time.Millisecond * time.Duration(200))
+	n, completed := registered()
+	assert.Equal(t, 0, n, "Expecting cancelled request registration to expire")
+	assert.Equal(t, 1, completed, "Expecting cancelled request to be treated as completed")
+}
+
+func TestSubscribeContextTimeout(t *testing.T) {
+
+	ncs := newNCClientSession(t, testserver.NewTestNetconfServer(t).WithRequestHandler(testserver.IgnoreRequestHandler))
+	defer ncs.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*time.Duration(100))
+	defer cancel()
+
+	reply, err := ncs.SubscribeContext(ctx, common.Request(`<create-subscription/>`), make(chan *common.Notification))
+	assert.Equal(t, context.DeadlineExceeded, err, "Expecting subscribe to time out")
+	assert.Nil(t, reply, "Reply should be nil")
+}
+
+func TestExecuteWithReorderedReplies(t *testing.T) {
+
+	swap := testserver.SwappingRequestHandler()
+	ncs := newNCClientSession(t, testserver.NewTestNetconfServer(t).WithRequestHandler(swap).WithRequestHandler(swap))
+	defer ncs.Close()
+
+	rch1 := make(chan *common.RPCReply)
+	rch2 := make(chan *common.RPCReply)
+	_ = ncs.ExecuteAsync(common.Request(`<get><test1/></get>`), rch1)
+	_ = ncs.ExecuteAsync(common.Request(`<get><test2/></get>`), rch2)
+
+	reply := <-rch1
+	assert.Equal(t, `<data><test1/></data>`, reply.Data, "Reply should contain response data")
+	reply = <-rch2
+	assert.Equal(t, `<data><test2/></data>`, reply.Data, "Reply should contain response data")
+}
+
+func TestExecuteWithDuplicateReply(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t).WithRequestHandler(testserver.DuplicateReplyRequestHandler)
+
+	unmatched := make(chan bool, 1)
+	trace := &ClientTrace{
+		ReplyUnmatched: func(res *common.RPCReply, duplicate bool) {
+			unmatched <- duplicate
+		},
+	}
+	ncs, err := NewRPCSession(WithClientTrace(context.Background(), trace), sshConfig(), fmt.Sprintf("localhost:%d", ts.Port()))
+	assert.NoError(t, err, "Failed to create session")
+	defer ncs.Close()
+
+	reply, err := ncs.Execute(common.Request(`<get><test1/></get>`))
+	assert.NoError(t, err, "Not expecting exec to fail")
+	assert.Equal(t, `<data><test1/></data>`, reply.Data, "Reply should contain response data")
+	assert.True(t, <-unmatched, "Expecting duplicate reply to be reported")
+
+	reply, err = ncs.Execute(common.Request(`<get><test2/></get>`))
+	assert.NoError(t, err, "Not expecting exec to fail")
+	assert.Equal(t, `<data><test2/></data>`, reply.Data, "Reply should contain response data")
+}
+
+func TestExecuteWithUnmatchedReply(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t).WithRequestHandler(testserver.UnknownMessageIDRequestHandler)
+
+	unmatched := make(chan *common.RPCReply, 1)
+	trace := &ClientTrace{
+		ReplyUnmatched: func(res *common.RPCReply, duplicate bool) {
+			assert.False(t, duplicate, "Not expecting duplicate reply")
+			unmatched <- res
+		},
+	}
+	ncs, err := NewRPCSession(WithClientTrace(context.Background(), trace), sshConfig(), fmt.Sprintf("localhost:%d", ts.Port()))
+	assert.NoError(t, err, "Failed to create session")
+	defer ncs.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*time.Duration(250))
+	defer cancel()
+
+	_, err = ncs.ExecuteContext(ctx, common.Request(`<get><test1/></get>`))
+	assert.Equal(t, context.DeadlineExceeded, err, "Expecting exec to time out")
+	assert.Equal(t, "unknown", (<-unmatched).MessageID, "Expecting unmatched reply to be reported")
+
+	reply, err := ncs.Execute(common.Request(`<get><test2/></get>`))
+	assert.NoError(t, err, "Not expecting exec to fail")
+	assert.Equal(t, `<data><test2/></data>`, reply.Data, "Reply should contain response data")
+}
+
+func TestExecuteWithUnmatchedReplyStrict(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t).WithRequestHandler(testserver.UnknownMessageIDRequestHandler)
+	ncs := newNCClientSessionWithConfig(t, ts, &Config{StrictReplyCorrelation: true})
+	defer ncs.Close()
+
+	reply, err := ncs.Execute(common.Request(`<get><test1/></get>`))
+	assert.Equal(t, io.ErrUnexpectedEOF, err, "Expecting session to be closed")
+	assert.Nil(t, reply, "Reply should be nil")
+
+	_, err = ncs.Execute(common.Request(`<get><test2/></get>`))
+	assert.Error(t, err, "Expecting exec on closed session to fail")
+}
+
 func TestExecuteAsync(t *testing.T) {
 
 	ncs := newNCClientSession(t, testserver.NewTestNetconfServer(t))
@@ -215,13 +401,125 @@ func TestSubscribe(t *testing.T) {
 	sh.SendNotification(notificationEvent())
 	sh.SendNotification(notificationEvent())
 	time.Sleep(time.Millisecond * time.Duration(500))
-	assert.Equal(t, uint64(2), atomic.LoadUint64(&(ncs.(*sesImpl).notificationDropCount)), "Expected notification to have been dropped")
+	assert.Equal(t, uint64(2), ncs.NotificationCounters().Dropped, "Expected notification to have been dropped")
 
 	ts.Close()
 	result = <-nch
 	assert.Nil(t, result, "No more notifications expected")
 }
 
+func TestMultipleSubscriptions(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t)
+	ncs := newNCClientSession(t, ts)
+	defer ncs.Close()
+	sh := ts.SessionHandler(ncs.ID())
+
+	nch := make(chan *common.Notification, 1)
+	vch := make(chan *common.Notification, 1)
+
+	_, err := ncs.Subscribe(common.Request(`<create-subscription xmlns="urn:ietf:params:xml:ns:netconf:notification:1.0"/>`), nch)
+	assert.NoError(t, err, "Not expecting subscribe to fail")
+	_, err = ncs.SubscribeWithOptions(context.Background(),
+		common.Request(`<create-subscription xmlns="urn:ietf:params:xml:ns:netconf:notification:1.0"><stream>VENDOR</stream></create-subscription>`),
+		&SubscriptionOptions{Match: MatchEventNamespace("urn:vendor")}, vch)
+	assert.NoError(t, err, "Not expecting subscribe to fail")
+
+	sh.SendNotification(`<alarm xmlns="urn:vendor"><id>1</id></alarm>`)
+	sh.SendNotification(notificationEvent())
+
+	result := <-vch
+	assert.Equal(t, "alarm", result.XMLName.Local, "Expected vendor event")
+	result = <-nch
+	assert.Equal(t, "netconf-session-start", result.XMLName.Local, "Expected netconf event")
+
+	// Unsubscribe closes only the vendor channel.
+	ncs.Unsubscribe(vch)
+	_, ok := <-vch
+	assert.False(t, ok, "Expected vendor channel to be closed")
+
+	sh.SendNotification(`<alarm xmlns="urn:vendor"><id>2</id></alarm>`)
+	result = <-nch
+	assert.Equal(t, "alarm", result.XMLName.Local, "Expected vendor event on remaining subscription")
+
+	ts.Close()
+	result = <-nch
+	assert.Nil(t, result, "No more notifications expected")
+}
+
+func TestSubscriptionsRoutedByID(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t).
+		WithRequestHandler(testserver.RawReplyRequestHandler(`<id xmlns="urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications">21</id>`)).
+		WithRequestHandler(testserver.RawReplyRequestHandler(`<id xmlns="urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications">22</id>`))
+	ncs := newNCClientSession(t, ts)
+	defer ncs.Close()
+	sh := ts.SessionHandler(ncs.ID())
+
+	ch21 := make(chan *common.Notification, 1)
+	ch22 := make(chan *common.Notification, 1)
+	_, err := ncs.Subscribe(common.Request(`<establish-subscription xmlns="urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications"/>`), ch21)
+	assert.NoError(t, err, "Not expecting subscribe to fail")
+	_, err = ncs.Subscribe(common.Request(`<establish-subscription xmlns="urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications"/>`), ch22)
+	assert.NoError(t, err, "Not expecting subscribe to fail")
+
+	sh.SendNotification(`<push-update xmlns="urn:ietf:params:xml:ns:yang:ietf-yang-push"><id>22</id></push-update>`)
+	sh.SendNotification(`<push-update xmlns="urn:ietf:params:xml:ns:yang:ietf-yang-push"><id>21</id></push-update>`)
+
+	result := <-ch22
+	assert.Equal(t, "22", result.SubscriptionID, "Expected notification for subscription 22")
+	result = <-ch21
+	assert.Equal(t, "21", result.SubscriptionID, "Expected notification for subscription 21")
+
+	// Notifications without a subscription id are not delivered to subscriptions with an id.
+	sh.SendNotification(notificationEvent())
+	time.Sleep(time.Millisecond * time.Duration(250))
+	assert.Equal(t, 0, len(ch21)+len(ch22), "Not expecting notification without subscription id")
+	assert.Equal(t, uint64(1), ncs.NotificationCounters().Dropped, "Expected notification to have been dropped")
+}
+
+func TestSubscriptionsWithAndWithoutID(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t).
+		WithRequestHandler(testserver.RawReplyRequestHandler(`<id xmlns="urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications">21</id>`)).
+		WithRequestHandler(testserver.RawReplyRequestHandler(`<ok/>`))
+	ncs := newNCClientSession(t, ts)
+	defer ncs.Close()
+	sh := ts.SessionHandler(ncs.ID())
+
+	pch := make(chan *common.Notification, 1)
+	nch := make(chan *common.Notification, 1)
+	_, err := ncs.Subscribe(common.Request(`<establish-subscription xmlns="urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications"/>`), pch)
+	assert.NoError(t, err, "Not expecting establish-subscription to fail")
+	_, err = ncs.Subscribe(common.Request(`<create-subscription xmlns="urn:ietf:params:xml:ns:netconf:notification:1.0"/>`), nch)
+	assert.NoError(t, err, "Not expecting create-subscription to fail")
+
+	sh.SendNotification(notificationEvent())
+	sh.SendNotification(`<push-update xmlns="urn:ietf:params:xml:ns:yang:ietf-yang-push"><id>21</id></push-update>`)
+
+	result := <-nch
+	assert.Equal(t, "netconf-session-start", result.XMLName.Local, "Expected event on create-subscription channel")
+	result = <-pch
+	assert.Equal(t, "21", result.SubscriptionID, "Expected push update on establish-subscription channel")
+	assert.Equal(t, 0, len(nch)+len(pch), "Not expecting further notifications")
+}
+
+func TestSubscribeFailure(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t).WithRequestHandler(testserver.FailingRequestHandler)
+	ncs := newNCClientSession(t, ts)
+	defer ncs.Close()
+	sh := ts.SessionHandler(ncs.ID())
+
+	nch := make(chan *common.Notification, 1)
+	_, err := ncs.Subscribe(common.Request(`<create-subscription xmlns="urn:ietf:params:xml:ns:netconf:notification:1.0"/>`), nch)
+	assert.Error(t, err, "Expecting subscribe to fail")
+
+	sh.SendNotification(notificationEvent())
+	time.Sleep(time.Millisecond * time.Duration(250))
+	assert.Equal(t, 0, len(nch), "Not expecting notification for failed subscription")
+}
+
 func TestConcurrentExecute(t *testing.T) {
 
 	ts := testserver.NewTestNetconfServer(t)
@@ -303,6 +601,14 @@ func notificationEvent() string {
 		`</netconf-session-start>`
 }
 
+func sshConfig() *ssh.ClientConfig {
+	return &ssh.ClientConfig{
+		User:            testserver.TestUserName,
+		Auth:            []ssh.AuthMethod{ssh.Password(testserver.TestPassword)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}
+}
+
 func newNCClientSession(t assert.TestingT, ts *testserver.TestNCServer) Session {
 	serverAddress := fmt.Sprintf("localhost:%d", ts.Port())
 	sshConfig := &ssh.ClientConfig{
@@ -0,0 +1,540 @@
+package client
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"math/rand"
+	"sync"
+	"time"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+
+	"github.com/imdario/mergo"
+	"golang.org/x/crypto/ssh"
+)
+
+// Defines a resilient session, that re-establishes the underlying netconf session when the connection to the
+// server is lost, and re-issues the subscription requests that were active at the time.
+
+// ErrNotConnected is returned by a resilient session when a request is made while it is reconnecting.
+var ErrNotConnected = errors.New("netconf session is not connected")
+
+// ConnectionState describes the state of the connection held by a resilient session.
+type ConnectionState int
+
+const (
+	// Connected indicates that a netconf session has been established.
+	Connected ConnectionState = iota
+	// Disconnected indicates that the connection has been lost.
+	Disconnected
+	// Reconnecting indicates that an attempt to re-establish the session is being made.
+	Reconnecting
+	// Closed indicates that the resilient session has been closed, or has abandoned reconnection.
+	Closed
+)
+
+func (cs ConnectionState) String() string {
+	switch cs {
+	case Connected:
+		return "connected"
+	case Disconnected:
+		return "disconnected"
+	case Reconnecting:
+		return "reconnecting"
+	case Closed:
+		return "closed"
+	default:
+		return "unknown"
+	}
+}
+
+// ReconnectConfig defines properties that configure resilient session behaviour.
+type ReconnectConfig struct {
+	// Defines the delay before the first reconnection attempt. The delay is doubled after each failed attempt.
+	InitialBackoff time.Duration
+	// Defines the maximum delay between reconnection attempts.
+	MaxBackoff time.Duration
+	// Defines the number of failed reconnection attempts after which the session is closed.
+	// If zero, there is no limit.
+	MaxAttempts int
+	// Resubscribe, if defined, delivers the request used to re-establish a subscription after reconnection, given
+	// the original request and the event time of the last notification received for the subscription (empty if
+	// none has been received). Otherwise, the original request is re-issued.
+	Resubscribe func(req common.Request, lastEventTime string) common.Request
+}
+
+// DefaultReconnectConfig defines the default resilient session configuration.
+var DefaultReconnectConfig = &ReconnectConfig{
+	InitialBackoff: time.Second,
+	MaxBackoff:     time.Minute,
+}
+
+// SessionDialer establishes a netconf session. The session must have been created by this package.
+type SessionDialer func(ctx context.Context) (Session, error)
+
+// closeNotifier is implemented by sessions that signal when their connection has closed.
+type closeNotifier interface {
+	closed() <-chan struct{}
+}
+
+type resilientSession struct {
+	ctx    context.Context
+	target string
+	dial   SessionDialer
+	rcfg   *ReconnectConfig
+	trace  *ClientTrace
+
+	lock sync.Mutex
+	// The current session, or nil if not connected.
+	cur Session
+	// The most recently established session.
+	last Session
+	// Notification counters accumulated from previous sessions.
+	counters NotificationCounters
+	subs     []*resilientSubscription
+	isClosed bool
+
+	// Closed when the resilient session is closed.
+	done chan struct{}
+}
+
+type resilientSubscription struct {
+	req  common.Request
+	opts SubscriptionOptions
+	// The channel supplied by the subscriber.
+	nchan chan *common.Notification
+
+	lock sync.Mutex
+	// The channel used by the session that the subscription was most recently made on.
+	inner chan *common.Notification
+	ended bool
+
+	lastEventTime string
+	stop          chan struct{}
+	forwarded     sync.WaitGroup
+}
+
+// NewResilientRPCSession connects to the target using the ssh configuration, and establishes a netconf session
+// with the client configuration, that is re-established according to the reconnect configuration when the
+// connection is lost.
+func NewResilientRPCSession(ctx context.Context, sshcfg *ssh.ClientConfig, target string, cfg *Config, rcfg *ReconnectConfig) (Session, error) {
+
+	return NewResilientSession(ctx, target, func(ctx context.Context) (Session, error) {
+		return NewRPCSessionWithConfig(ctx, sshcfg, target, cfg)
+	}, rcfg)
+}
+
+// NewResilientSession establishes a netconf session using the dialer, and uses it to re-establish the session
+// according to the reconnect configuration when the connection is lost.
+// The target is used to identify the session in trace events.
+// Requests made while the session is reconnecting return ErrNotConnected. Subscriptions that were active when the
+// connection was lost are re-issued after reconnection, and their notifications continue to be delivered to the
+// original channel, which is only closed by Unsubscribe, Close, or if reconnection is abandoned.
+// If rcfg is nil, DefaultReconnectConfig is used.
+func NewResilientSession(ctx context.Context, target string, dial SessionDialer, rcfg *ReconnectConfig) (Session, error) {
+
+	if rcfg == nil {
+		rcfg = DefaultReconnectConfig
+	}
+
+	// Use supplied config, but apply any defaults to unspecified values.
+	var resolvedConfig ReconnectConfig = *rcfg
+	_ = mergo.Merge(&resolvedConfig, DefaultReconnectConfig)
+
+	rs := &resilientSession{
+		ctx:    ctx,
+		target: target,
+		dial:   dial,
+		rcfg:   &resolvedConfig,
+		trace:  ContextClientTrace(ctx),
+		done:   make(chan struct{}),
+	}
+
+	s, err := rs.connect()
+	if err != nil {
+		return nil, err
+	}
+	rs.setSession(s)
+	return rs, nil
+}
+
+func (rs *resilientSession) Execute(req common.Request) (*common.RPCReply, error) {
+	return rs.ExecuteContext(context.Background(), req)
+}
+
+func (rs *resilientSession) ExecuteContext(ctx context.Context, req common.Request) (*common.RPCReply, error) {
+	s, err := rs.current()
+	if err != nil {
+		return nil, err
+	}
+	return s.ExecuteContext(ctx, req)
+}
+
+func (rs *resilientSession) ExecuteStream(ctx context.Context, req common.Request, handler DataHandler) (*common.RPCReply, error) {
+	s, err := rs.current()
+	if err != nil {
+		return nil, err
+	}
+	return s.ExecuteStream(ctx, req, handler)
+}
+
+func (rs *resilientSession) ExecuteAsync(req common.Request, rchan chan *common.RPCReply) error {
+	s, err := rs.current()
+	if err != nil {
+		return err
+	}
+	return s.ExecuteAsync(req, rchan)
+}
+
+func (rs *resilientSession) Subscribe(req common.Request, nchan chan *common.Notification) (*common.RPCReply, error) {
+	return rs.SubscribeContext(context.Background(), req, nchan)
+}
+
+func (rs *resilientSession) SubscribeContext(ctx context.Context, req common.Request, nchan chan *common.Notification) (*common.RPCReply, error) {
+	return rs.SubscribeWithOptions(ctx, req, &SubscriptionOptions{}, nchan)
+}
+
+func (rs *resilientSession) SubscribeWithOptions(ctx context.Context, req common.Request, opts *SubscriptionOptions, nchan chan *common.Notification) (*common.RPCReply, error) {
+	s, err := rs.current()
+	if err != nil {
+		return nil, err
+	}
+
+	sub := &resilientSubscription{req: req, opts: *opts, nchan: nchan, stop: make(chan struct{})}
+	reply, err := sub.subscribe(ctx, s, req)
+	if err != nil {
+		return reply, err
+	}
+
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	rs.subs = append(rs.subs, sub)
+	return reply, nil
+}
+
+func (rs *resilientSession) Unsubscribe(nchan chan *common.Notification) {
+	rs.lock.Lock()
+	var sub *resilientSubscription
+	for i, s := range rs.subs {
+		if s.nchan == nchan {
+			sub = s
+			rs.subs = append(rs.subs[:i:i], rs.subs[i+1:]...)
+			break
+		}
+	}
+	cur := rs.cur
+	rs.lock.Unlock()
+
+	if sub != nil {
+		if inner := sub.end(); cur != nil {
+			cur.Unsubscribe(inner)
+		}
+	}
+}
+
+func (rs *resilientSession) NotificationCounters() NotificationCounters {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	counters := rs.counters
+	if rs.cur != nil {
+		counters = addCounters(counters, rs.cur.NotificationCounters())
+	}
+	return counters
+}
+
+func (rs *resilientSession) Close() {
+	rs.lock.Lock()
+	if rs.isClosed {
+		rs.lock.Unlock()
+		return
+	}
+	rs.isClosed = true
+	close(rs.done)
+	cur := rs.cur
+	rs.cur = nil
+	subs := rs.subs
+	rs.subs = nil
+	rs.lock.Unlock()
+
+	if cur != nil {
+		cur.Close()
+	}
+	for _, sub := range subs {
+		sub.end()
+	}
+	rs.trace.ConnectionStateChange(rs.target, Closed, nil)
+}
+
+func (rs *resilientSession) ID() uint64 {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	return rs.last.ID()
+}
+
+func (rs *resilientSession) ServerCapabilities() []string {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	return rs.last.ServerCapabilities()
+}
+
+func (rs *resilientSession) Capabilities() *common.Capabilities {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	return rs.last.Capabilities()
+}
+
+func (rs *resilientSession) current() (Session, error) {
+	rs.lock.Lock()
+	defer rs.lock.Unlock()
+	if rs.cur == nil {
+		return nil, ErrNotConnected
+	}
+	return rs.cur, nil
+}
+
+func (rs *resilientSession) connect() (Session, error) {
+	s, err := rs.dial(rs.ctx)
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := s.(closeNotifier); !ok {
+		s.Close()
+		return nil, errors.New("resilient session requires a session created by the client package")
+	}
+	return s, nil
+}
+
+func (rs *resilientSession) setSession(s Session) {
+	rs.lock.Lock()
+	rs.cur, rs.last = s, s
+	rs.lock.Unlock()
+
+	rs.trace.ConnectionStateChange(rs.target, Connected, nil)
+	go rs.monitor(s)
+}
+
+// monitor waits for the connection of the session to close, and then reconnects.
+func (rs *resilientSession) monitor(s Session) {
+	select {
+	case <-s.(closeNotifier).closed():
+	case <-rs.done:
+		return
+	}
+
+	// Release the resources held by the transport.
+	s.Close()
+
+	rs.lock.Lock()
+	if rs.isClosed {
+		rs.lock.Unlock()
+		return
+	}
+	rs.cur = nil
+	rs.counters = addCounters(rs.counters, s.NotificationCounters())
+	rs.lock.Unlock()
+
+	rs.trace.ConnectionStateChange(rs.target, Disconnected, nil)
+	rs.reconnect()
+}
+
+func (rs *resilientSession) reconnect() {
+
+	backoff := rs.rcfg.InitialBackoff
+	for attempt := 1; ; attempt++ {
+		select {
+		case <-time.After(jitter(backoff)):
+		case <-rs.done:
+			return
+		}
+
+		rs.trace.ConnectionStateChange(rs.target, Reconnecting, nil)
+		s, err := rs.connect()
+		if err == nil {
+			rs.resubscribe(s)
+			rs.lock.Lock()
+			if rs.isClosed {
+				rs.lock.Unlock()
+				s.Close()
+				return
+			}
+			rs.lock.Unlock()
+			rs.setSession(s)
+			return
+		}
+
+		rs.trace.Error("Reconnect failed", rs.target, err)
+		if rs.rcfg.MaxAttempts > 0 && attempt >= rs.rcfg.MaxAttempts {
+			rs.trace.ConnectionStateChange(rs.target, Closed, err)
+			rs.abandon()
+			return
+		}
+
+		if backoff *= 2; backoff > rs.rcfg.MaxBackoff {
+			backoff = rs.rcfg.MaxBackoff
+		}
+	}
+}
+
+// resubscribe re-issues the active subscription requests on the session.
+// A subscription that cannot be re-established remains inactive until the next reconnection.
+func (rs *resilientSession) resubscribe(s Session) {
+	rs.lock.Lock()
+	subs := append([]*resilientSubscription(nil), rs.subs...)
+	rs.lock.Unlock()
+
+	for _, sub := range subs {
+		req := sub.req
+		if rs.rcfg.Resubscribe != nil {
+			req = rs.rcfg.Resubscribe(req, sub.lastEvent())
+		}
+		_, err := sub.subscribe(rs.ctx, s, req)
+		rs.trace.ResubscribeDone(rs.target, req, err)
+	}
+}
+
+// abandon closes the resilient session after reconnection has failed.
+func (rs *resilientSession) abandon() {
+	rs.lock.Lock()
+	if rs.isClosed {
+		rs.lock.Unlock()
+		return
+	}
+	rs.isClosed = true
+	close(rs.done)
+	subs := rs.subs
+	rs.subs = nil
+	rs.lock.Unlock()
+
+	for _, sub := range subs {
+		sub.end()
+	}
+}
+
+// subscribe issues the subscription request on the session, forwarding notifications from the session to the
+// subscriber channel.
+func (sub *resilientSubscription) subscribe(ctx context.Context, s Session, req common.Request) (*common.RPCReply, error) {
+
+	inner := make(chan *common.Notification, cap(sub.nchan))
+	reply, err := s.SubscribeWithOptions(ctx, req, &sub.opts, inner)
+	if err != nil {
+		return reply, err
+	}
+
+	sub.lock.Lock()
+	defer sub.lock.Unlock()
+	if sub.ended {
+		// The subscription ended while the request was being made.
+		s.Unsubscribe(inner)
+		return reply, nil
+	}
+	sub.inner = inner
+	sub.forwarded.Add(1)
+	go sub.forward(inner)
+	return reply, nil
+}
+
+// forward sends notifications from the session channel to the subscriber channel, until the session
+// channel is closed or the subscription ends.
+func (sub *resilientSubscription) forward(inner chan *common.Notification) {
+	defer sub.forwarded.Done()
+	for {
+		var n *common.Notification
+		select {
+		case n = <-inner:
+			if n == nil {
+				return
+			}
+		case <-sub.stop:
+			return
+		}
+
+		sub.lastEventTime = n.EventTime
+		select {
+		case sub.nchan <- n:
+		case <-sub.stop:
+			return
+		}
+	}
+}
+
+// lastEvent delivers the event time of the last notification received, once forwarding from the previous session
+// has finished.
+func (sub *resilientSubscription) lastEvent() string {
+	sub.forwarded.Wait()
+	return sub.lastEventTime
+}
+
+// end stops forwarding notifications and closes the subscriber channel, returning the channel used by the
+// session that the subscription was most recently made on.
+func (sub *resilientSubscription) end() chan *common.Notification {
+	sub.lock.Lock()
+	sub.ended = true
+	inner := sub.inner
+	sub.lock.Unlock()
+
+	close(sub.stop)
+	sub.forwarded.Wait()
+	close(sub.nchan)
+	return inner
+}
+
+func addCounters(a, b NotificationCounters) NotificationCounters {
+	return NotificationCounters{
+		Received:  a.Received + b.Received,
+		Delivered: a.Delivered + b.Delivered,
+		Dropped:   a.Dropped + b.Dropped,
+	}
+}
+
+// jitter delivers a random duration between half and the whole of the backoff.
+func jitter(backoff time.Duration) time.Duration {
+	half := int64(backoff / 2)
+	if half <= 0 {
+		return backoff
+	}
+	return time.Duration(half + rand.Int63n(half)) // nolint: gosec
+}
+
+// createSubscription is used to rewrite an RFC 5277 create-subscription request.
+type createSubscription struct {
+	XMLName   xml.Name            `xml:"urn:ietf:params:xml:ns:netconf:notification:1.0 create-subscription"`
+	Stream    string              `xml:"stream,omitempty"`
+	Filter    *subscriptionFilter `xml:"filter,omitempty"`
+	StartTime string              `xml:"startTime,omitempty"`
+	StopTime  string              `xml:"stopTime,omitempty"`
+}
+
+type subscriptionFilter struct {
+	Attrs []xml.Attr `xml:",any,attr"`
+	Body  string     `xml:",innerxml"`
+}
+
+// ReplayCreateSubscription can be used as the ReconnectConfig Resubscribe function for RFC 5277 create-subscription
+// requests. It sets the startTime of the request to the time of the last event received, so that events raised
+// while the session was disconnected are replayed by a server that supports replay.
+// Note that the last event received before disconnection will be delivered again.
+// Other requests, or those made before any event has been received, are returned unchanged.
+func ReplayCreateSubscription(req common.Request, lastEventTime string) common.Request {
+	if lastEventTime == "" {
+		return req
+	}
+
+	var body []byte
+	switch r := req.(type) {
+	case string:
+		body = []byte(r)
+	default:
+		var err error
+		if body, err = xml.Marshal(req); err != nil {
+			return req
+		}
+	}
+
+	cs := &createSubscription{}
+	if err := xml.Unmarshal(body, cs); err != nil {
+		return req
+	}
+	cs.StartTime = lastEventTime
+	return cs
+}
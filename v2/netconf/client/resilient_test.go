@@ -0,0 +1,170 @@
+package client
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+	"github.com/damianoneill/net/v2/netconf/testserver"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestResilientSessionReconnect(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t)
+	defer ts.Close()
+
+	states := make(chan ConnectionState, 10)
+	resubscribed := make(chan error, 1)
+	trace := &ClientTrace{
+		ConnectionStateChange: func(target string, state ConnectionState, err error) {
+			states <- state
+		},
+		ResubscribeDone: func(target string, req common.Request, err error) {
+			resubscribed <- err
+		},
+	}
+	ctx := WithClientTrace(context.Background(), trace)
+	rcfg := &ReconnectConfig{InitialBackoff: time.Millisecond * time.Duration(50), Resubscribe: ReplayCreateSubscription}
+	rs, err := NewResilientRPCSession(ctx, sshConfig(), fmt.Sprintf("localhost:%d", ts.Port()), DefaultConfig, rcfg)
+	assert.NoError(t, err, "Not expecting new session to fail")
+	assert.Equal(t, Connected, <-states, "Expected connected state")
+
+	nch := make(chan *common.Notification, 10)
+	_, err = rs.Subscribe(common.Request(`<create-subscription xmlns="urn:ietf:params:xml:ns:netconf:notification:1.0"><stream>NETCONF</stream></create-subscription>`), nch)
+	assert.NoError(t, err, "Not expecting subscribe to fail")
+
+	sh := ts.SessionHandler(rs.ID())
+	sh.SendNotification(notificationEvent())
+	event := <-nch
+
+	// Drop the connection, and wait for the session to be re-established.
+	sh.Close()
+	assert.Equal(t, Disconnected, <-states, "Expected disconnected state")
+	assert.Equal(t, Reconnecting, <-states, "Expected reconnecting state")
+	assert.NoError(t, <-resubscribed, "Not expecting resubscribe to fail")
+	assert.Equal(t, Connected, <-states, "Expected connected state")
+
+	sh = ts.SessionHandler(rs.ID())
+	assert.Equal(t, "create-subscription", sh.LastReq().XMLName.Local, "Expected subscription to be re-issued")
+	assert.Equal(t, fmt.Sprintf(`<stream>NETCONF</stream><startTime>%s</startTime>`, event.EventTime), sh.LastReq().Body,
+		"Expected subscription replay from last event")
+
+	sh.SendNotification(notificationEvent())
+	assert.NotNil(t, <-nch, "Expected notification on original channel")
+
+	reply, err := rs.Execute(common.Request(`<get><response/></get>`))
+	assert.NoError(t, err, "Not expecting exec to fail")
+	assert.Equal(t, `<data><response/></data>`, reply.Data, "Reply should contain response data")
+	assert.Equal(t, uint64(2), rs.NotificationCounters().Delivered, "Expected counters to accumulate")
+
+	rs.Close()
+	assert.Equal(t, Closed, <-states, "Expected closed state")
+	_, ok := <-nch
+	assert.False(t, ok, "Expected notification channel to be closed")
+}
+
+func TestResilientSessionDefaultConfig(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t)
+	defer ts.Close()
+
+	rs, err := NewResilientRPCSession(context.Background(), sshConfig(), fmt.Sprintf("localhost:%d", ts.Port()), DefaultConfig, nil)
+	assert.NoError(t, err, "Not expecting new session to fail")
+	defer rs.Close()
+
+	assert.Equal(t, *DefaultReconnectConfig, *rs.(*resilientSession).rcfg, "Expected default reconnect config")
+}
+
+func TestResilientSessionAbandon(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t)
+	defer ts.Close()
+
+	states := make(chan ConnectionState, 10)
+	var closeErr error
+	trace := &ClientTrace{
+		ConnectionStateChange: func(target string, state ConnectionState, err error) {
+			if state == Closed {
+				closeErr = err
+			}
+			states <- state
+		},
+	}
+
+	dials := 0
+	dial := func(ctx context.Context) (Session, error) {
+		if dials++; dials > 1 {
+			return nil, errors.New("unreachable")
+		}
+		return NewRPCSession(ctx, sshConfig(), fmt.Sprintf("localhost:%d", ts.Port()))
+	}
+
+	rcfg := &ReconnectConfig{InitialBackoff: time.Millisecond * time.Duration(10), MaxAttempts: 2}
+	rs, err := NewResilientSession(WithClientTrace(context.Background(), trace), "target", dial, rcfg)
+	assert.NoError(t, err, "Not expecting new session to fail")
+	defer rs.Close()
+	assert.Equal(t, Connected, <-states, "Expected connected state")
+
+	nch := make(chan *common.Notification)
+	_, err = rs.Subscribe(common.Request(`<create-subscription xmlns="urn:ietf:params:xml:ns:netconf:notification:1.0"/>`), nch)
+	assert.NoError(t, err, "Not expecting subscribe to fail")
+
+	ts.SessionHandler(rs.ID()).Close()
+	for _, expected := range []ConnectionState{Disconnected, Reconnecting, Reconnecting, Closed} {
+		assert.Equal(t, expected, <-states, "Expected connection state")
+	}
+	assert.EqualError(t, closeErr, "unreachable", "Expected reconnect error")
+
+	_, ok := <-nch
+	assert.False(t, ok, "Expected notification channel to be closed")
+
+	_, err = rs.Execute(common.Request(`<get><response/></get>`))
+	assert.Equal(t, ErrNotConnected, err, "Expected not connected error")
+}
+
+func TestResilientSessionUnsubscribe(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t)
+	defer ts.Close()
+
+	rs, err := NewResilientRPCSession(context.Background(), sshConfig(), fmt.Sprintf("localhost:%d", ts.Port()), DefaultConfig, &ReconnectConfig{})
+	assert.NoError(t, err, "Not expecting new session to fail")
+	defer rs.Close()
+
+	nch1 := make(chan *common.Notification, 1)
+	nch2 := make(chan *common.Notification, 1)
+	_, err = rs.SubscribeWithOptions(context.Background(), createSubscriptionRequest(), &SubscriptionOptions{Match: MatchEventNamespace("urn:vendor")}, nch1)
+	assert.NoError(t, err, "Not expecting subscribe to fail")
+	_, err = rs.Subscribe(createSubscriptionRequest(), nch2)
+	assert.NoError(t, err, "Not expecting subscribe to fail")
+
+	rs.Unsubscribe(nch1)
+	_, ok := <-nch1
+	assert.False(t, ok, "Expected notification channel to be closed")
+
+	sendAlarms(ts.SessionHandler(rs.ID()), 1)
+	assert.Contains(t, (<-nch2).Event, alarmID(1), "Expected notification on remaining subscription")
+}
+
+func TestReplayCreateSubscription(t *testing.T) {
+
+	req := common.Request(`<create-subscription xmlns="urn:ietf:params:xml:ns:netconf:notification:1.0">` +
+		`<stream>NETCONF</stream><filter type="subtree"><event xmlns="urn:test"/></filter></create-subscription>`)
+
+	assert.Equal(t, req, ReplayCreateSubscription(req, ""), "Expected request to be unchanged")
+
+	other := common.Request(`<establish-subscription xmlns="urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications"/>`)
+	assert.Equal(t, other, ReplayCreateSubscription(other, "2020-01-01T00:00:00Z"), "Expected request to be unchanged")
+
+	replay, err := xml.Marshal(ReplayCreateSubscription(req, "2020-01-01T00:00:00Z"))
+	assert.NoError(t, err, "Not expecting marshal to fail")
+	assert.Equal(t, `<create-subscription xmlns="urn:ietf:params:xml:ns:netconf:notification:1.0">`+
+		`<stream>NETCONF</stream><filter type="subtree"><event xmlns="urn:test"/></filter>`+
+		`<startTime>2020-01-01T00:00:00Z</startTime></create-subscription>`, string(replay), "Expected replay start time")
+}
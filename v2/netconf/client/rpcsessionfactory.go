@@ -2,6 +2,7 @@ package client
 
 import (
 	"context"
+	"crypto/tls"
 
 	"github.com/imdario/mergo"
 	"golang.org/x/crypto/ssh"
@@ -20,15 +21,39 @@ func NewRPCSession(ctx context.Context, sshcfg *ssh.ClientConfig, target string)
 // a netconf session with the client configuration.
 func NewRPCSessionWithConfig(ctx context.Context, sshcfg *ssh.ClientConfig, target string, cfg *Config) (s Session, err error) {
 
-	// Use supplied config, but apply any defaults to unspecified values.
-	var resolvedConfig Config = *cfg
-	_ = mergo.Merge(&resolvedConfig, DefaultConfig)
-
 	var t Transport
 	if t, err = createTransport(ctx, sshcfg, target); err != nil {
 		return
 	}
 
+	return newSessionOverTransport(ctx, t, cfg)
+}
+
+// NewTLSRPCSession connects to the target using the tls configuration, and establishes
+// a netconf session with default configuration.
+func NewTLSRPCSession(ctx context.Context, tlscfg *tls.Config, target string) (s Session, err error) {
+
+	return NewTLSRPCSessionWithConfig(ctx, tlscfg, target, DefaultConfig)
+}
+
+// NewTLSRPCSessionWithConfig connects to the target using the tls configuration, and establishes
+// a netconf session with the client configuration.
+func NewTLSRPCSessionWithConfig(ctx context.Context, tlscfg *tls.Config, target string, cfg *Config) (s Session, err error) {
+
+	var t Transport
+	if t, err = NewTLSTransport(ctx, tlscfg, target); err != nil {
+		return
+	}
+
+	return newSessionOverTransport(ctx, t, cfg)
+}
+
+func newSessionOverTransport(ctx context.Context, t Transport, cfg *Config) (s Session, err error) {
+
+	// Use supplied config, but apply any defaults to unspecified values.
+	var resolvedConfig Config = *cfg
+	_ = mergo.Merge(&resolvedConfig, DefaultConfig)
+
 	if s, err = NewSession(ctx, t, &resolvedConfig); err != nil {
 		t.Close() // nolint: gosec,errcheck
 	}
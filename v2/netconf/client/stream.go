@@ -0,0 +1,188 @@
+package client
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"time"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+)
+
+// Defines the streamed decoding of rpc-reply data, which allows very large replies (such as the complete state of
+// a device) to be processed without holding the whole reply in memory.
+
+// DataHandler is called for each top-level element of the <data> content of a streamed rpc-reply.
+// The token reader delivers the tokens of the element, starting with start and ending with the matching end
+// element, after which it returns io.EOF. The element may be decoded using xml.NewTokenDecoder(tr).
+// Any tokens not consumed by the handler are discarded when it returns.
+// If the handler returns an error, no further elements are delivered, and the error is returned to the requester.
+type DataHandler func(start xml.StartElement, tr xml.TokenReader) error
+
+// dataStream defines a request whose reply data is being streamed.
+type dataStream struct {
+	ctx     context.Context
+	handler DataHandler
+	err     error
+}
+
+func (si *sesImpl) ExecuteStream(ctx context.Context, req common.Request, handler DataHandler) (reply *common.RPCReply, err error) {
+
+	msgID := newMessageID()
+	ds := &dataStream{ctx: ctx, handler: handler}
+	si.addDataStream(msgID, ds)
+
+	reply, err = si.executeContext(ctx, msgID, req)
+	if err != nil && err == ctx.Err() {
+		// The stream remains registered for a grace period, as for the response channel, so that any late reply
+		// is discarded, rather than decoded.
+		time.AfterFunc(cancelledReplyGracePeriod, func() {
+			si.removeDataStream(msgID)
+		})
+		return
+	}
+	si.removeDataStream(msgID)
+
+	if err == nil && ds.err != nil {
+		err = ds.err
+	}
+	return
+}
+
+func (si *sesImpl) addDataStream(id string, ds *dataStream) {
+	si.rchLock.Lock()
+	defer si.rchLock.Unlock()
+	si.streams[id] = ds
+}
+
+func (si *sesImpl) removeDataStream(id string) *dataStream {
+	si.rchLock.Lock()
+	defer si.rchLock.Unlock()
+	ds := si.streams[id]
+	delete(si.streams, id)
+	return ds
+}
+
+// streamRPCReply decodes an rpc-reply, passing each top-level element of its data to the stream handler.
+// Other content of the reply is decoded into reply, with the exception of the data.
+func (si *sesImpl) streamRPCReply(reply *common.RPCReply, start xml.StartElement, ds *dataStream) (err error) {
+
+	reply.XMLName = start.Name
+	reply.MessageID = messageID(start)
+	for {
+		var token xml.Token
+		if token, err = si.dec.Token(); err != nil {
+			si.trace.Error("Stream token:rpc-reply", si.target, err)
+			return
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			switch token.Name.Local {
+			case "rpc-error":
+				rpcErr := common.RPCError{}
+				if err = si.decodeElement(&rpcErr, &token); err != nil {
+					return
+				}
+				reply.Errors = append(reply.Errors, rpcErr)
+			case "ok":
+				reply.Ok = true
+				err = si.dec.Skip()
+			case "data":
+				err = si.streamData(ds)
+			default:
+				err = si.dec.Skip()
+			}
+			if err != nil {
+				si.trace.Error("Stream token:"+token.Name.Local, si.target, err)
+				return
+			}
+		case xml.EndElement:
+			return
+		}
+	}
+}
+
+// streamData passes each top-level element of the data to the stream handler, until the end of the data element.
+// Once the handler has failed, or the requester has given up waiting, the remaining elements are discarded.
+func (si *sesImpl) streamData(ds *dataStream) error {
+	for {
+		token, err := si.dec.Token()
+		if err != nil {
+			return err
+		}
+
+		switch token := token.(type) {
+		case xml.StartElement:
+			er := &elementReader{dec: si.dec.Decoder, start: token}
+			if ds.err == nil && ds.ctx.Err() == nil {
+				ds.err = ds.handler(token, er)
+			}
+			if err = er.drain(); err != nil {
+				return err
+			}
+		case xml.EndElement:
+			return nil
+		}
+	}
+}
+
+// messageID delivers the message-id attribute of the start element.
+func messageID(start xml.StartElement) string {
+	for _, attr := range start.Attr {
+		if attr.Name.Local == "message-id" {
+			return attr.Value
+		}
+	}
+	return ""
+}
+
+// elementReader is an xml.TokenReader that delivers the tokens of a single element from the underlying decoder.
+type elementReader struct {
+	dec     *xml.Decoder
+	start   xml.StartElement
+	started bool
+	depth   int
+	done    bool
+	err     error
+}
+
+func (r *elementReader) Token() (xml.Token, error) {
+	if r.done {
+		if r.err != nil {
+			return nil, r.err
+		}
+		return nil, io.EOF
+	}
+
+	if !r.started {
+		r.started = true
+		r.depth = 1
+		return r.start, nil
+	}
+
+	token, err := r.dec.Token()
+	if err != nil {
+		r.done, r.err = true, err
+		return nil, err
+	}
+
+	switch token.(type) {
+	case xml.StartElement:
+		r.depth++
+	case xml.EndElement:
+		r.depth--
+		r.done = r.depth == 0
+	}
+	return token, nil
+}
+
+// drain discards any tokens of the element not consumed by the handler.
+func (r *elementReader) drain() error {
+	for !r.done {
+		if _, err := r.Token(); err != nil {
+			return err
+		}
+	}
+	return r.err
+}
@@ -0,0 +1,173 @@
+package client
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+	"github.com/damianoneill/net/v2/netconf/testserver"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+type streamedInterface struct {
+	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:yang:ietf-interfaces interface"`
+	Name    string   `xml:"name"`
+	Enabled bool     `xml:"enabled"`
+}
+
+func TestExecuteStream(t *testing.T) {
+
+	var data strings.Builder
+	data.WriteString(`<data>`)
+	for i := 1; i <= 100; i++ {
+		fmt.Fprintf(&data, `<interface xmlns="urn:ietf:params:xml:ns:yang:ietf-interfaces"><name>eth%d</name><enabled>true</enabled></interface>`, i)
+	}
+	data.WriteString(`<system xmlns="urn:ietf:params:xml:ns:yang:ietf-system"><hostname>router</hostname></system></data>`)
+
+	ts := testserver.NewTestNetconfServer(t).WithRequestHandler(testserver.RawReplyRequestHandler(data.String()))
+	ncs := newNCClientSession(t, ts)
+	defer ncs.Close()
+
+	var interfaces []streamedInterface
+	var others []xml.Name
+	reply, err := ncs.ExecuteStream(context.Background(), common.Request(`<get/>`), func(start xml.StartElement, tr xml.TokenReader) error {
+		if start.Name.Local != "interface" {
+			// Leave the tokens of other elements unconsumed.
+			others = append(others, start.Name)
+			return nil
+		}
+		intf := streamedInterface{}
+		err := xml.NewTokenDecoder(tr).Decode(&intf)
+		interfaces = append(interfaces, intf)
+		return err
+	})
+	assert.NoError(t, err, "Not expecting exec to fail")
+	assert.Equal(t, "", reply.Data, "Expected data to be streamed")
+	assert.Len(t, interfaces, 100, "Expected all interfaces")
+	assert.Equal(t, streamedInterface{XMLName: xml.Name{Space: "urn:ietf:params:xml:ns:yang:ietf-interfaces", Local: "interface"}, Name: "eth100", Enabled: true},
+		interfaces[99], "Expected interface to be decoded")
+	assert.Equal(t, []xml.Name{{Space: "urn:ietf:params:xml:ns:yang:ietf-system", Local: "system"}}, others, "Expected system element")
+
+	// Subsequent replies must be unaffected.
+	reply, err = ncs.Execute(common.Request(`<get><response/></get>`))
+	assert.NoError(t, err, "Not expecting exec to fail")
+	assert.Equal(t, `<data><response/></data>`, reply.Data, "Reply should contain response data")
+}
+
+func TestExecuteStreamHandlerError(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t).WithRequestHandler(testserver.RawReplyRequestHandler(`<data><a/><b/><c/></data>`))
+	ncs := newNCClientSession(t, ts)
+	defer ncs.Close()
+
+	var elements []string
+	_, err := ncs.ExecuteStream(context.Background(), common.Request(`<get/>`), func(start xml.StartElement, tr xml.TokenReader) error {
+		elements = append(elements, start.Name.Local)
+		return errors.New("failed")
+	})
+	assert.EqualError(t, err, "failed", "Expecting handler error")
+	assert.Equal(t, []string{"a"}, elements, "Expected no elements after error")
+
+	reply, err := ncs.Execute(common.Request(`<get><response/></get>`))
+	assert.NoError(t, err, "Not expecting exec to fail")
+	assert.Equal(t, `<data><response/></data>`, reply.Data, "Reply should contain response data")
+}
+
+func TestExecuteStreamRPCError(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t).WithRequestHandler(testserver.FailingRequestHandler)
+	ncs := newNCClientSession(t, ts)
+	defer ncs.Close()
+
+	reply, err := ncs.ExecuteStream(context.Background(), common.Request(`<get/>`), func(start xml.StartElement, tr xml.TokenReader) error {
+		return nil
+	})
+	assert.Error(t, err, "Expecting exec to fail")
+	assert.Equal(t, "oops", err.(*common.RPCError).Message, "Expected error message")
+	assert.Len(t, reply.Errors, 1, "Expected reply error")
+}
+
+func TestExecuteStreamContextTimeout(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t).
+		WithRequestHandler(testserver.DelayedEchoRequestHandler(time.Millisecond * time.Duration(200)))
+	ncs := newNCClientSession(t, ts)
+	defer ncs.Close()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	called := false
+	_, err := ncs.ExecuteStream(ctx, common.Request(`<get><response/></get>`), func(start xml.StartElement, tr xml.TokenReader) error {
+		called = true
+		return nil
+	})
+	assert.Equal(t, context.Canceled, err, "Expected context error")
+
+	// The late reply must be discarded, without calling the handler.
+	reply, err := ncs.Execute(common.Request(`<get><next/></get>`))
+	assert.NoError(t, err, "Not expecting exec to fail")
+	assert.Equal(t, `<data><next/></data>`, reply.Data, "Reply should contain response data")
+	assert.False(t, called, "Not expecting handler to be called")
+}
+
+func TestExecuteStreamCancelledExpiry(t *testing.T) {
+
+	defer func(grace time.Duration) { cancelledReplyGracePeriod = grace }(cancelledReplyGracePeriod)
+	cancelledReplyGracePeriod = time.Millisecond * time.Duration(50)
+
+	ncs := newNCClientSession(t, testserver.NewTestNetconfServer(t).WithRequestHandler(testserver.IgnoreRequestHandler))
+	defer ncs.Close()
+	si := ncs.(*sesImpl)
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*time.Duration(50))
+	defer cancel()
+	_, err := ncs.ExecuteStream(ctx, common.Request(`<get><response/></get>`), func(start xml.StartElement, tr xml.TokenReader) error {
+		return nil
+	})
+	assert.Equal(t, context.DeadlineExceeded, err, "Expected context error")
+
+	streams := func() int {
+		si.rchLock.Lock()
+		defer si.rchLock.Unlock()
+		return len(si.streams)
+	}
+	assert.Equal(t, 1, streams(), "Expecting cancelled stream to remain registered")
+
+	time.Sleep(time.Millisecond * time.Duration(200))
+	assert.Equal(t, 0, streams(), "Expecting cancelled stream registration to expire")
+}
+
+func TestElementReader(t *testing.T) {
+
+	d := xml.NewDecoder(strings.NewReader(`<a><b x="1"><c>text</c></b><d/></a>`))
+	_, _ = d.Token()
+	start, _ := d.Token()
+
+	er := &elementReader{dec: d, start: start.(xml.StartElement)}
+	var names []string
+	for {
+		token, err := er.Token()
+		if err != nil {
+			assert.Equal(t, io.EOF, err, "Expected end of element")
+			break
+		}
+		switch token := token.(type) {
+		case xml.StartElement:
+			names = append(names, "+"+token.Name.Local)
+		case xml.EndElement:
+			names = append(names, "-"+token.Name.Local)
+		}
+	}
+	assert.Equal(t, []string{"+b", "+c", "-c", "-b"}, names, "Expected element tokens")
+
+	next, _ := d.Token()
+	assert.Equal(t, "d", next.(xml.StartElement).Name.Local, "Expected decoder to be positioned after element")
+}
@@ -0,0 +1,490 @@
+package client
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+)
+
+// Defines the management of notification subscriptions held by a session.
+// A session may hold several subscriptions, each with its own channel. An incoming notification is routed to:
+// 1. the subscription whose id matches the subscription id carried by the notification, if any, otherwise
+// 2. the first subscription without an id whose Match function selects the notification, if any, otherwise
+// 3. the first subscription without an id that does not define a Match function.
+// A subscription with an id only receives the notifications that carry its id. A subscription whose request has
+// not been answered may yet be given an id by the reply, so it does not receive notifications without an id.
+
+// BackPressurePolicy defines how notifications are handled when a subscription channel is not ready to
+// receive them.
+type BackPressurePolicy int
+
+const (
+	// DropNewest discards a notification if the subscription channel is not ready to receive it.
+	DropNewest BackPressurePolicy = iota
+	// Block waits until the subscription channel is ready to receive the notification. Note that this
+	// blocks the handling of all incoming messages for the session, including rpc replies.
+	Block
+	// DropOldest holds notifications in a ring buffer until the subscription channel is ready to receive them,
+	// discarding the oldest notification if the buffer is full.
+	DropOldest
+	// Spill holds notifications in an unbounded queue until the subscription channel is ready to receive them,
+	// calling the ClientTrace NotificationHighWater hook when the queue length reaches the high water mark.
+	Spill
+)
+
+// Defines default values for subscription options.
+const (
+	DefaultNotificationBufferSize    = 100
+	DefaultNotificationHighWaterMark = 1000
+)
+
+// SubscriptionOptions defines how incoming notifications are routed and delivered to a subscription.
+type SubscriptionOptions struct {
+	// ID identifies the subscription, as reported by notifications that carry a subscription id (RFC 8639).
+	// If empty, the id returned in the reply to the subscription request, if any, will be used.
+	ID string
+	// Match, if defined, selects the notifications that belong to the subscription, for example those
+	// delivered by a specific event stream.
+	Match func(n *common.Notification) bool
+	// Policy defines how notifications are handled when the subscription channel is not ready to receive them.
+	Policy BackPressurePolicy
+	// BufferSize defines the capacity of the ring buffer used by the DropOldest policy.
+	// If zero, DefaultNotificationBufferSize is used.
+	BufferSize int
+	// HighWaterMark defines the queue length that triggers a high water alert for the Spill policy.
+	// If zero, DefaultNotificationHighWaterMark is used.
+	HighWaterMark int
+}
+
+// NotificationCounters reports the number of notifications handled by a session.
+type NotificationCounters struct {
+	// Received is the number of notifications received from the server.
+	Received uint64
+	// Delivered is the number of notifications sent to a subscription channel.
+	Delivered uint64
+	// Dropped is the number of notifications discarded, either because they were not selected by any subscription
+	// or because of the back-pressure policy of the subscription. Notifications still queued when a subscription
+	// ends are also counted as dropped.
+	Dropped uint64
+}
+
+// MatchEventNamespace delivers a Match function that selects notifications whose event element is
+// defined in one of the namespaces.
+func MatchEventNamespace(ns ...string) func(n *common.Notification) bool {
+	return func(n *common.Notification) bool {
+		for _, s := range ns {
+			if n.XMLName.Space == s {
+				return true
+			}
+		}
+		return false
+	}
+}
+
+type subscription struct {
+	si   *sesImpl
+	opts SubscriptionOptions
+	ch   chan *common.Notification
+	// The message-id of the subscription request, until the reply has been received.
+	msgID string
+
+	// Closed when the subscription ends.
+	done chan struct{}
+	// Held while sending directly to the subscription channel, so that it is not closed during the send.
+	sendLock sync.Mutex
+
+	// Queue of notifications, for policies that buffer notifications, and the goroutine that forwards
+	// them to the subscription channel.
+	queue     *notificationQueue
+	queueLock sync.Mutex
+	ready     chan struct{}
+	forwarded chan struct{}
+	highWater bool
+}
+
+// subscriptionReply is used to extract the id from the reply to an establish-subscription request.
+type subscriptionReply struct {
+	ID string `xml:"urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications id"`
+}
+
+// subscriptionEvent is used to extract the subscription id from a notification event.
+type subscriptionEvent struct {
+	ID string `xml:"id"`
+}
+
+func (si *sesImpl) Subscribe(req common.Request, nchan chan *common.Notification) (reply *common.RPCReply, err error) {
+	return si.SubscribeContext(context.Background(), req, nchan)
+}
+
+func (si *sesImpl) SubscribeContext(ctx context.Context, req common.Request, nchan chan *common.Notification) (reply *common.RPCReply, err error) {
+	return si.SubscribeWithOptions(ctx, req, &SubscriptionOptions{}, nchan)
+}
+
+func (si *sesImpl) SubscribeWithOptions(ctx context.Context, req common.Request, opts *SubscriptionOptions, nchan chan *common.Notification) (reply *common.RPCReply, err error) {
+
+	// Register the subscription before the request is sent, so that no notification is missed.
+	sub := si.newSubscription(opts, nchan)
+	si.addSubscription(sub)
+
+	if reply, err = si.executeContext(ctx, sub.msgID, req); err != nil {
+		if si.removeSubscription(sub) {
+			sub.stop(false)
+		}
+	}
+	return
+}
+
+func (si *sesImpl) Unsubscribe(nchan chan *common.Notification) {
+	si.subLock.Lock()
+	var sub *subscription
+	for i, s := range si.subs {
+		if s.ch == nchan {
+			sub = s
+			si.subs = append(si.subs[:i:i], si.subs[i+1:]...)
+			break
+		}
+	}
+	si.subLock.Unlock()
+
+	if sub != nil {
+		sub.stop(true)
+	}
+}
+
+func (si *sesImpl) NotificationCounters() NotificationCounters {
+	return NotificationCounters{
+		Received:  atomic.LoadUint64(&si.notificationCounters.Received),
+		Delivered: atomic.LoadUint64(&si.notificationCounters.Delivered),
+		Dropped:   atomic.LoadUint64(&si.notificationCounters.Dropped),
+	}
+}
+
+func (si *sesImpl) newSubscription(opts *SubscriptionOptions, nchan chan *common.Notification) *subscription {
+	sub := &subscription{si: si, opts: *opts, ch: nchan, msgID: newMessageID(), done: make(chan struct{})}
+
+	switch opts.Policy {
+	case DropOldest:
+		if sub.opts.BufferSize <= 0 {
+			sub.opts.BufferSize = DefaultNotificationBufferSize
+		}
+		sub.queue = newNotificationQueue(sub.opts.BufferSize, false)
+	case Spill:
+		if sub.opts.HighWaterMark <= 0 {
+			sub.opts.HighWaterMark = DefaultNotificationHighWaterMark
+		}
+		sub.queue = newNotificationQueue(sub.opts.HighWaterMark, true)
+	default:
+	}
+
+	if sub.queue != nil {
+		sub.ready = make(chan struct{}, 1)
+		sub.forwarded = make(chan struct{})
+		go sub.forward()
+	}
+	return sub
+}
+
+func (si *sesImpl) addSubscription(sub *subscription) {
+	si.subLock.Lock()
+	defer si.subLock.Unlock()
+	si.subs = append(si.subs, sub)
+}
+
+// removeSubscription removes the subscription, returning false if it had already been removed.
+func (si *sesImpl) removeSubscription(sub *subscription) bool {
+	si.subLock.Lock()
+	defer si.subLock.Unlock()
+	for i, s := range si.subs {
+		if s == sub {
+			si.subs = append(si.subs[:i:i], si.subs[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// bindSubscription completes the set up of the subscription, if any, whose request the reply correlates with,
+// recording the subscription id delivered by the reply.
+// It is called before any subsequent message is handled, so the id is known before the first notification for
+// the subscription is routed.
+func (si *sesImpl) bindSubscription(reply *common.RPCReply) {
+	si.subLock.Lock()
+	defer si.subLock.Unlock()
+	for _, sub := range si.subs {
+		if sub.msgID != reply.MessageID {
+			continue
+		}
+		sub.msgID = ""
+		if sub.opts.ID == "" && mapError(reply) == nil {
+			sr := &subscriptionReply{}
+			if err := xml.Unmarshal([]byte("<reply>"+reply.Data+"</reply>"), sr); err == nil {
+				sub.opts.ID = sr.ID
+			}
+		}
+		return
+	}
+}
+
+func (si *sesImpl) handleNotification(token xml.StartElement) (err error) {
+	result := &common.NotificationMessage{}
+	if err = si.decodeElement(&result, &token); err != nil {
+		return
+	}
+
+	notification := buildNotification(result)
+	atomic.AddUint64(&si.notificationCounters.Received, 1)
+	si.trace.NotificationReceived(notification)
+
+	// Send notification to the subscription that it is routed to, according to the subscription policy.
+	if sub := si.routeNotification(notification); sub != nil {
+		sub.deliver(notification)
+	} else {
+		si.notificationDropped(notification)
+	}
+	return
+}
+
+// routeNotification delivers the subscription that the notification should be sent to, or nil
+// if there is none.
+func (si *sesImpl) routeNotification(n *common.Notification) *subscription {
+	si.subLock.Lock()
+	defer si.subLock.Unlock()
+
+	var matched, fallback *subscription
+	for _, sub := range si.subs {
+		if sub.opts.ID != "" {
+			if sub.opts.ID == n.SubscriptionID {
+				return sub
+			}
+			continue
+		}
+		if sub.msgID != "" {
+			continue
+		}
+		if sub.opts.Match == nil {
+			if fallback == nil {
+				fallback = sub
+			}
+		} else if matched == nil && sub.opts.Match(n) {
+			matched = sub
+		}
+	}
+	if matched != nil {
+		return matched
+	}
+	return fallback
+}
+
+func (si *sesImpl) notificationDelivered() {
+	atomic.AddUint64(&si.notificationCounters.Delivered, 1)
+}
+
+func (si *sesImpl) notificationDropped(n *common.Notification) {
+	atomic.AddUint64(&si.notificationCounters.Dropped, 1)
+	si.trace.NotificationDropped(n)
+}
+
+func (si *sesImpl) closeSubscriptions() {
+	si.subLock.Lock()
+	subs := si.subs
+	si.subs = nil
+	si.subLock.Unlock()
+
+	for _, sub := range subs {
+		sub.stop(true)
+	}
+}
+
+// deliver sends the notification to the subscription channel, or queues it, according to the subscription policy.
+func (sub *subscription) deliver(n *common.Notification) {
+
+	if sub.queue != nil {
+		sub.enqueue(n)
+		return
+	}
+
+	sub.sendLock.Lock()
+	defer sub.sendLock.Unlock()
+
+	select {
+	case <-sub.done:
+		sub.si.notificationDropped(n)
+		return
+	default:
+	}
+
+	if sub.opts.Policy == Block {
+		select {
+		case sub.ch <- n:
+			sub.si.notificationDelivered()
+		case <-sub.done:
+			sub.si.notificationDropped(n)
+		}
+		return
+	}
+
+	select {
+	case sub.ch <- n:
+		sub.si.notificationDelivered()
+	default:
+		sub.si.notificationDropped(n)
+	}
+}
+
+func (sub *subscription) enqueue(n *common.Notification) {
+	sub.queueLock.Lock()
+	select {
+	case <-sub.done:
+		sub.queueLock.Unlock()
+		sub.si.notificationDropped(n)
+		return
+	default:
+	}
+	dropped := sub.queue.push(n)
+	l := sub.queue.len()
+	alert := sub.opts.Policy == Spill && !sub.highWater && l >= sub.opts.HighWaterMark
+	if alert {
+		sub.highWater = true
+	}
+	sub.queueLock.Unlock()
+
+	if dropped != nil {
+		sub.si.notificationDropped(dropped)
+	}
+	if alert {
+		sub.si.trace.NotificationHighWater(n, l)
+	}
+
+	// Wake the forwarder, if it's not already due to run.
+	select {
+	case sub.ready <- struct{}{}:
+	default:
+	}
+}
+
+func (sub *subscription) dequeue() (n *common.Notification) {
+	sub.queueLock.Lock()
+	defer sub.queueLock.Unlock()
+	n = sub.queue.pop()
+	if sub.queue.len() < sub.opts.HighWaterMark {
+		// Re-arm the high water alert.
+		sub.highWater = false
+	}
+	return
+}
+
+// forward sends queued notifications to the subscription channel, until the subscription ends.
+func (sub *subscription) forward() {
+	defer close(sub.forwarded)
+	for {
+		n := sub.dequeue()
+		if n == nil {
+			select {
+			case <-sub.ready:
+				continue
+			case <-sub.done:
+				return
+			}
+		}
+		select {
+		case sub.ch <- n:
+			sub.si.notificationDelivered()
+		case <-sub.done:
+			sub.si.notificationDropped(n)
+			return
+		}
+	}
+}
+
+// stop ends the subscription, discarding any queued notifications, and closing the subscription channel
+// if required.
+func (sub *subscription) stop(closeChannel bool) {
+	close(sub.done)
+
+	if sub.queue != nil {
+		<-sub.forwarded
+		for n := sub.dequeue(); n != nil; n = sub.dequeue() {
+			sub.si.notificationDropped(n)
+		}
+	}
+
+	if closeChannel {
+		// Wait for any send in progress to complete.
+		sub.sendLock.Lock()
+		close(sub.ch)
+		sub.sendLock.Unlock()
+	}
+}
+
+// notificationQueue is a ring buffer of notifications. If it is bounded, pushing a notification when it is full
+// displaces the oldest one; otherwise it grows as required.
+type notificationQueue struct {
+	buf     []*common.Notification
+	head    int
+	count   int
+	growing bool
+}
+
+func newNotificationQueue(size int, growing bool) *notificationQueue {
+	return &notificationQueue{buf: make([]*common.Notification, size), growing: growing}
+}
+
+func (q *notificationQueue) len() int {
+	return q.count
+}
+
+// push adds the notification to the tail of the queue, returning the notification that was discarded to make
+// space for it, if any.
+func (q *notificationQueue) push(n *common.Notification) (dropped *common.Notification) {
+	if q.count == len(q.buf) {
+		if q.growing {
+			buf := make([]*common.Notification, 2*len(q.buf))
+			for i := 0; i < q.count; i++ {
+				buf[i] = q.buf[(q.head+i)%len(q.buf)]
+			}
+			q.buf, q.head = buf, 0
+		} else {
+			dropped = q.pop()
+		}
+	}
+	q.buf[(q.head+q.count)%len(q.buf)] = n
+	q.count++
+	return
+}
+
+// pop removes and returns the notification at the head of the queue, or nil if it is empty.
+func (q *notificationQueue) pop() (n *common.Notification) {
+	if q.count == 0 {
+		return nil
+	}
+	n, q.buf[q.head] = q.buf[q.head], nil
+	q.head = (q.head + 1) % len(q.buf)
+	q.count--
+	return
+}
+
+func buildNotification(nmsg *common.NotificationMessage) *common.Notification {
+	event := fmt.Sprintf(`<%s xmlns="%s">%s</%s>`,
+		nmsg.Event.XMLName.Local, nmsg.Event.XMLName.Space, nmsg.Event.Event, nmsg.Event.XMLName.Local)
+	notification := &common.Notification{XMLName: nmsg.Event.XMLName, EventTime: nmsg.EventTime, Event: event}
+
+	switch nmsg.Event.XMLName.Space {
+	case common.NetmodNotificationNS:
+		switch nmsg.Event.XMLName.Local {
+		case "replayComplete":
+			notification.Type = common.ReplayComplete
+		case "notificationComplete":
+			notification.Type = common.NotificationComplete
+		}
+	case common.SubscribedNotificationsNS, common.YangPushNS:
+		se := &subscriptionEvent{}
+		if err := xml.Unmarshal([]byte(event), se); err == nil {
+			notification.SubscriptionID = se.ID
+		}
+	}
+	return notification
+}
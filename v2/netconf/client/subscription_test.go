@@ -0,0 +1,205 @@
+package client
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+	"github.com/damianoneill/net/v2/netconf/testserver"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestSubscribeBlock(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t)
+	ncs := newNCClientSession(t, ts)
+	defer ncs.Close()
+	sh := ts.SessionHandler(ncs.ID())
+
+	nch := make(chan *common.Notification)
+	_, err := ncs.SubscribeWithOptions(context.Background(), createSubscriptionRequest(), &SubscriptionOptions{Policy: Block}, nch)
+	assert.NoError(t, err, "Not expecting subscribe to fail")
+
+	sendAlarms(sh, 3)
+	time.Sleep(time.Millisecond * time.Duration(250))
+
+	for i := 1; i <= 3; i++ {
+		assert.Contains(t, (<-nch).Event, alarmID(i), "Expected notifications in order")
+	}
+	time.Sleep(time.Millisecond * time.Duration(100))
+	assert.Equal(t, NotificationCounters{Received: 3, Delivered: 3}, ncs.NotificationCounters(), "Expected counters")
+}
+
+func TestSubscribeDropNewest(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t)
+	ncs := newNCClientSession(t, ts)
+	defer ncs.Close()
+	sh := ts.SessionHandler(ncs.ID())
+
+	nch := make(chan *common.Notification, 1)
+	_, err := ncs.Subscribe(createSubscriptionRequest(), nch)
+	assert.NoError(t, err, "Not expecting subscribe to fail")
+
+	sendAlarms(sh, 3)
+	time.Sleep(time.Millisecond * time.Duration(250))
+
+	assert.Contains(t, (<-nch).Event, alarmID(1), "Expected first notification")
+	assert.Equal(t, NotificationCounters{Received: 3, Delivered: 1, Dropped: 2}, ncs.NotificationCounters(), "Expected counters")
+}
+
+func TestSubscribeDropOldest(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t)
+	ncs := newNCClientSession(t, ts)
+	defer ncs.Close()
+	sh := ts.SessionHandler(ncs.ID())
+
+	nch := make(chan *common.Notification)
+	_, err := ncs.SubscribeWithOptions(context.Background(), createSubscriptionRequest(), &SubscriptionOptions{Policy: DropOldest, BufferSize: 2}, nch)
+	assert.NoError(t, err, "Not expecting subscribe to fail")
+
+	sendAlarms(sh, 5)
+	time.Sleep(time.Millisecond * time.Duration(250))
+
+	// The oldest notifications are dropped, so the last two notifications must be delivered.
+	var received []*common.Notification
+	for {
+		select {
+		case n := <-nch:
+			received = append(received, n)
+			continue
+		case <-time.After(time.Millisecond * time.Duration(250)):
+		}
+		break
+	}
+	l := len(received)
+	assert.True(t, l >= 2 && l <= 3, "Expected the buffered notifications")
+	assert.Contains(t, received[l-2].Event, alarmID(4), "Expected newest notifications")
+	assert.Contains(t, received[l-1].Event, alarmID(5), "Expected newest notifications")
+
+	counters := ncs.NotificationCounters()
+	assert.Equal(t, uint64(5), counters.Received, "Expected received count")
+	assert.Equal(t, uint64(l), counters.Delivered, "Expected delivered count")
+	assert.Equal(t, uint64(5-l), counters.Dropped, "Expected dropped count")
+}
+
+func TestSubscribeSpill(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t)
+
+	alerts := make(chan int, 10)
+	trace := &ClientTrace{
+		NotificationHighWater: func(m *common.Notification, queued int) {
+			alerts <- queued
+		},
+	}
+	ncs, err := NewRPCSession(WithClientTrace(context.Background(), trace), sshConfig(), fmt.Sprintf("localhost:%d", ts.Port()))
+	assert.NoError(t, err, "Failed to create session")
+	defer ncs.Close()
+	sh := ts.SessionHandler(ncs.ID())
+
+	nch := make(chan *common.Notification)
+	_, err = ncs.SubscribeWithOptions(context.Background(), createSubscriptionRequest(), &SubscriptionOptions{Policy: Spill, HighWaterMark: 2}, nch)
+	assert.NoError(t, err, "Not expecting subscribe to fail")
+
+	sendAlarms(sh, 5)
+	assert.Equal(t, 2, <-alerts, "Expected high water alert")
+
+	for i := 1; i <= 5; i++ {
+		assert.Contains(t, (<-nch).Event, alarmID(i), "Expected notifications in order")
+	}
+	time.Sleep(time.Millisecond * time.Duration(100))
+	assert.Equal(t, NotificationCounters{Received: 5, Delivered: 5}, ncs.NotificationCounters(), "Expected counters")
+}
+
+func TestUnsubscribeQueuedNotifications(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t)
+	ncs := newNCClientSession(t, ts)
+	defer ncs.Close()
+	sh := ts.SessionHandler(ncs.ID())
+
+	nch := make(chan *common.Notification)
+	_, err := ncs.SubscribeWithOptions(context.Background(), createSubscriptionRequest(), &SubscriptionOptions{Policy: Spill}, nch)
+	assert.NoError(t, err, "Not expecting subscribe to fail")
+
+	sendAlarms(sh, 3)
+	time.Sleep(time.Millisecond * time.Duration(250))
+
+	ncs.Unsubscribe(nch)
+	_, ok := <-nch
+	assert.False(t, ok, "Expected channel to be closed")
+	assert.Equal(t, NotificationCounters{Received: 3, Dropped: 3}, ncs.NotificationCounters(), "Expected counters")
+}
+
+func TestNotificationWithoutSubscription(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t)
+	ncs := newNCClientSession(t, ts)
+	defer ncs.Close()
+	sh := ts.SessionHandler(ncs.ID())
+	sh.WaitStart()
+
+	sendAlarms(sh, 1)
+	time.Sleep(time.Millisecond * time.Duration(250))
+	assert.Equal(t, NotificationCounters{Received: 1, Dropped: 1}, ncs.NotificationCounters(), "Expected counters")
+}
+
+func TestReplayNotifications(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t)
+	ncs := newNCClientSession(t, ts)
+	defer ncs.Close()
+	sh := ts.SessionHandler(ncs.ID())
+
+	nch := make(chan *common.Notification, 3)
+	_, err := ncs.Subscribe(createSubscriptionRequest(), nch)
+	assert.NoError(t, err, "Not expecting subscribe to fail")
+
+	sendAlarms(sh, 1)
+	sh.SendNotification(`<replayComplete xmlns="urn:ietf:params:xml:ns:netmod:notification"/>`)
+	sh.SendNotification(`<notificationComplete xmlns="urn:ietf:params:xml:ns:netmod:notification"/>`)
+
+	assert.Equal(t, common.EventNotification, (<-nch).Type, "Expected event notification")
+	assert.Equal(t, common.ReplayComplete, (<-nch).Type, "Expected replay complete")
+	assert.Equal(t, common.NotificationComplete, (<-nch).Type, "Expected notification complete")
+}
+
+func TestNotificationQueue(t *testing.T) {
+
+	q := newNotificationQueue(2, false)
+	assert.Nil(t, q.push(&common.Notification{EventTime: "1"}), "Not expecting notification to be dropped")
+	assert.Nil(t, q.push(&common.Notification{EventTime: "2"}), "Not expecting notification to be dropped")
+	assert.Equal(t, "1", q.push(&common.Notification{EventTime: "3"}).EventTime, "Expecting oldest notification to be dropped")
+	assert.Equal(t, 2, q.len(), "Expected queue length")
+	assert.Equal(t, "2", q.pop().EventTime, "Expected queue head")
+	assert.Equal(t, "3", q.pop().EventTime, "Expected queue head")
+	assert.Nil(t, q.pop(), "Expected queue to be empty")
+
+	q = newNotificationQueue(2, true)
+	for i := 1; i <= 5; i++ {
+		assert.Nil(t, q.push(&common.Notification{EventTime: fmt.Sprint(i)}), "Not expecting notification to be dropped")
+	}
+	assert.Equal(t, 5, q.len(), "Expected queue length")
+	for i := 1; i <= 5; i++ {
+		assert.Equal(t, fmt.Sprint(i), q.pop().EventTime, "Expected queue head")
+	}
+}
+
+func createSubscriptionRequest() common.Request {
+	return common.Request(`<create-subscription xmlns="urn:ietf:params:xml:ns:netconf:notification:1.0"/>`)
+}
+
+func sendAlarms(sh *testserver.SessionHandler, count int) {
+	for i := 1; i <= count; i++ {
+		sh.SendNotification(fmt.Sprintf(`<alarm xmlns="urn:vendor">%s</alarm>`, alarmID(i)))
+	}
+}
+
+func alarmID(i int) string {
+	return fmt.Sprintf(`<id>%d</id>`, i)
+}
@@ -0,0 +1,49 @@
+package client
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"time"
+)
+
+// Defines a NETCONF transport layered over TLS, as described by RFC 7589.
+
+// DefaultTLSPort is the IANA assigned port for NETCONF over TLS.
+const DefaultTLSPort = 6513
+
+// NewTLSTransport creates a new TLS transport, connecting to the target with the supplied TLS configuration.
+// RFC 7589 requires mutual authentication, so the configuration must define a client certificate.
+// The ConnectStart and ConnectDone trace hooks are called with a nil ssh client configuration.
+func NewTLSTransport(ctx context.Context, tlsConfig *tls.Config, target string) (rt Transport, err error) {
+
+	impl := tImpl{target: target}
+	impl.trace = ContextClientTrace(ctx)
+
+	impl.trace.ConnectStart(nil, target)
+
+	defer func(begin time.Time) {
+		impl.trace.ConnectDone(nil, target, err, time.Since(begin))
+	}(time.Now())
+
+	if tlsConfig == nil || (len(tlsConfig.Certificates) == 0 && tlsConfig.GetClientCertificate == nil) {
+		err = errors.New("tls client certificate must be configured")
+		return
+	}
+
+	// The context bounds both the connection and the TLS handshake.
+	var conn net.Conn
+	if conn, err = (&tls.Dialer{Config: tlsConfig}).DialContext(ctx, "tcp", target); err != nil {
+		return
+	}
+
+	impl.reader = conn
+	impl.writeCloser = conn
+
+	impl.injectTraceReader()
+	impl.injectTraceWriter()
+
+	rt = &impl
+	return
+}
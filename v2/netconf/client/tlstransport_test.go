@@ -0,0 +1,169 @@
+package client
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/damianoneill/net/v2/netconf/testserver"
+	assert "github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestTLSSuccessfulConnection(t *testing.T) {
+
+	creds := testserver.NewTLSCredentials(t)
+	ts := testserver.NewTLSServer(t, creds.ServerConfig)
+	defer ts.Close()
+
+	tr, err := NewTLSTransport(context.Background(), creds.ClientConfig, fmt.Sprintf("localhost:%d", ts.Port()))
+	assert.NoError(t, err, "Not expecting new transport to fail")
+	defer tr.Close()
+}
+
+func TestTLSMissingClientCertificate(t *testing.T) {
+
+	creds := testserver.NewTLSCredentials(t)
+	ts := testserver.NewTLSServer(t, creds.ServerConfig)
+	defer ts.Close()
+
+	tlsConfig := creds.ClientConfig.Clone()
+	tlsConfig.Certificates = nil
+
+	tr, err := NewTLSTransport(context.Background(), tlsConfig, fmt.Sprintf("localhost:%d", ts.Port()))
+	assert.Error(t, err, "Not expecting new transport to succeed")
+	assert.Nil(t, tr, "Transport should not be defined")
+}
+
+func TestTLSUntrustedServer(t *testing.T) {
+
+	creds := testserver.NewTLSCredentials(t)
+	ts := testserver.NewTLSServer(t, creds.ServerConfig)
+	defer ts.Close()
+
+	// Use client credentials from a different certificate authority.
+	tr, err := NewTLSTransport(context.Background(), testserver.NewTLSCredentials(t).ClientConfig, fmt.Sprintf("localhost:%d", ts.Port()))
+	assert.Error(t, err, "Not expecting new transport to succeed")
+	assert.Nil(t, tr, "Transport should not be defined")
+}
+
+func TestTLSHandshakeCancelled(t *testing.T) {
+
+	creds := testserver.NewTLSCredentials(t)
+
+	// A server that accepts the connection, but never completes the handshake.
+	l, err := net.Listen("tcp", "localhost:0")
+	assert.NoError(t, err, "Not expecting listen to fail")
+	defer l.Close()
+	go func() {
+		conn, err := l.Accept()
+		if err == nil {
+			defer conn.Close()
+			time.Sleep(time.Second * time.Duration(5))
+		}
+	}()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(time.Millisecond*time.Duration(100), cancel)
+
+	begin := time.Now()
+	tr, err := NewTLSTransport(ctx, creds.ClientConfig, l.Addr().String())
+	assert.True(t, errors.Is(err, context.Canceled), "Expecting handshake to be cancelled")
+	assert.Nil(t, tr, "Transport should not be defined")
+	assert.True(t, time.Since(begin) < time.Second, "Expecting cancellation to stop the handshake")
+}
+
+func TestTLSWriteRead(t *testing.T) {
+
+	creds := testserver.NewTLSCredentials(t)
+	ts := testserver.NewTLSServer(t, creds.ServerConfig)
+	defer ts.Close()
+
+	tr, err := NewTLSTransport(context.Background(), creds.ClientConfig, fmt.Sprintf("localhost:%d", ts.Port()))
+	assert.NoError(t, err, "Not expecting new transport to fail")
+	defer tr.Close()
+
+	rdr := bufio.NewReader(tr)
+	_, _ = tr.Write([]byte("Message\n"))
+	response, _ := rdr.ReadString('\n')
+	assert.Equal(t, "GOT:Message\n", response, "Failed to get expected response")
+}
+
+func TestTLSTrace(t *testing.T) {
+
+	creds := testserver.NewTLSCredentials(t)
+	ts := testserver.NewTLSServer(t, creds.ServerConfig)
+	defer ts.Close()
+
+	var traces []string
+	trace := &ClientTrace{
+		ConnectStart: func(clientConfig *ssh.ClientConfig, target string) {
+			traces = append(traces, fmt.Sprintf("ConnectStart %s %v", target, clientConfig))
+		},
+		ConnectDone: func(clientConfig *ssh.ClientConfig, target string, err error, d time.Duration) {
+			traces = append(traces, fmt.Sprintf("ConnectDone %s error:%v", target, err))
+			assert.True(t, d > 0, "Duration should be defined")
+		},
+		ConnectionClosed: func(target string, err error) {
+			traces = append(traces, fmt.Sprintf("ConnectionClosed target:%s error:%v", target, err))
+		},
+		ReadStart: func(p []byte) {
+			traces = append(traces, "ReadStart called")
+		},
+		ReadDone: func(p []byte, c int, err error, d time.Duration) {
+			traces = append(traces, fmt.Sprintf("ReadDone %s %d %v", string(p[:c]), c, err))
+		},
+		WriteStart: func(p []byte) {
+			traces = append(traces, fmt.Sprintf("WriteStart %s", p))
+		},
+		WriteDone: func(p []byte, c int, err error, d time.Duration) {
+			traces = append(traces, fmt.Sprintf("WriteDone %s %d %v", string(p[:c]), c, err))
+		},
+	}
+
+	ctx := WithClientTrace(context.Background(), trace)
+	tr, _ := NewTLSTransport(ctx, creds.ClientConfig, fmt.Sprintf("localhost:%d", ts.Port()))
+
+	_, _ = tr.Write([]byte("Message\n"))
+	_, _ = bufio.NewReader(tr).ReadString('\n')
+
+	tr.Close()
+
+	assert.Equal(t, fmt.Sprintf("ConnectStart localhost:%d <nil>", ts.Port()), traces[0])
+	assert.Equal(t, fmt.Sprintf("ConnectDone localhost:%d error:<nil>", ts.Port()), traces[1])
+	assert.Equal(t, "WriteStart Message\n", traces[2])
+	assert.Equal(t, "WriteDone Message\n 8 <nil>", traces[3])
+	assert.Equal(t, "ReadStart called", traces[4])
+	assert.Equal(t, "ReadDone GOT:Message\n 12 <nil>", traces[5])
+	assert.Contains(t, traces[6], "ConnectionClosed target:localhost:", traces[6])
+}
+
+func TestTLSSessionSetupSuccess(t *testing.T) {
+
+	creds := testserver.NewTLSCredentials(t)
+	ts := testserver.NewTestNetconfTLSServer(t, creds.ServerConfig)
+	defer ts.Close()
+
+	s, err := NewTLSRPCSession(context.Background(), creds.ClientConfig, fmt.Sprintf("localhost:%d", ts.Port()))
+	assert.NoError(t, err, "Expecting new session to succeed")
+	defer s.Close()
+
+	reply, err := s.Execute(`<get><response/></get>`)
+	assert.NoError(t, err, "Not expecting exec to fail")
+	assert.Equal(t, `<data><response/></data>`, reply.Data, "Reply should contain response data")
+}
+
+func TestTLSSessionSetupFailure(t *testing.T) {
+
+	creds := testserver.NewTLSCredentials(t)
+	ts := testserver.NewTLSServer(t, creds.ServerConfig)
+	defer ts.Close()
+
+	s, err := NewTLSRPCSessionWithConfig(context.Background(), creds.ClientConfig, fmt.Sprintf("localhost:%d", ts.Port()), &Config{SetupTimeoutSecs: 1})
+	assert.Error(t, err, "Expecting new session to fail - no hello from server")
+	assert.Nil(t, s, "Session should be nil")
+}
@@ -41,6 +41,7 @@ func WithClientTrace(ctx context.Context, trace *ClientTrace) context.Context {
 // ClientTrace defines a structure for handling trace events
 type ClientTrace struct {
 	// ConnectStart is called when starting to connect to a remote server.
+	// clientConfig will be nil if the transport is not SSH.
 	ConnectStart func(clientConfig *ssh.ClientConfig, target string)
 
 	// ConnectDone is called when the transport connection attempt completes, with err indicating
@@ -75,11 +76,27 @@ type ClientTrace struct {
 	// NotificationDropped is called when a notification is dropped because the reader is not ready.
 	NotificationDropped func(m *common.Notification)
 
+	// NotificationHighWater is called when the length of the queue of notifications held for a subscription
+	// reaches the high water mark defined by its options.
+	NotificationHighWater func(m *common.Notification, queued int)
+
 	// ExecuteStart is called before the execution of an rpc request.
 	ExecuteStart func(req common.Request, async bool)
 
 	// ExecuteDone is called after the execution of an rpc request.
 	ExecuteDone func(req common.Request, async bool, res *common.RPCReply, err error, d time.Duration)
+
+	// ConnectionStateChange is called when the connection state of a resilient session changes, with err
+	// indicating the reason, if any.
+	ConnectionStateChange func(target string, state ConnectionState, err error)
+
+	// ResubscribeDone is called after a resilient session has re-issued a subscription request following
+	// reconnection, with err indicating whether it was successful.
+	ResubscribeDone func(target string, req common.Request, err error)
+
+	// ReplyUnmatched is called when an rpc-reply is received whose message-id does not correlate with an
+	// outstanding request, with duplicate indicating that it matches a recently completed request.
+	ReplyUnmatched func(res *common.RPCReply, duplicate bool)
 }
 
 // DefaultLoggingHooks provides a default logging hook to report errors.
@@ -134,12 +151,24 @@ var DiagnosticLoggingHooks = &ClientTrace{
 	NotificationDropped: func(n *common.Notification) {
 		log.Printf("NETCONF-NotificationDropped %s\n", n.XMLName.Local)
 	},
+	NotificationHighWater: func(n *common.Notification, queued int) {
+		log.Printf("NETCONF-NotificationHighWater %s queued:%d\n", n.XMLName.Local, queued)
+	},
 	ExecuteStart: func(req common.Request, async bool) {
 		log.Printf("NETCONF-ExecuteStart async:%v req:%s\n", async, req)
 	},
 	ExecuteDone: func(req common.Request, async bool, res *common.RPCReply, err error, d time.Duration) {
 		log.Printf("NETCONF-ExecuteDone async:%v req:%s err:%v took:%dms\n", async, req, err, d.Milliseconds())
 	},
+	ConnectionStateChange: func(target string, state ConnectionState, err error) {
+		log.Printf("NETCONF-ConnectionStateChange target:%s state:%s err:%v\n", target, state, err)
+	},
+	ResubscribeDone: func(target string, req common.Request, err error) {
+		log.Printf("NETCONF-ResubscribeDone target:%s req:%s err:%v\n", target, req, err)
+	},
+	ReplyUnmatched: func(res *common.RPCReply, duplicate bool) {
+		log.Printf("NETCONF-ReplyUnmatched message-id:%s duplicate:%v\n", res.MessageID, duplicate)
+	},
 }
 
 // NoOpLoggingHooks provides set of hooks that do nothing.
@@ -154,9 +183,13 @@ var NoOpLoggingHooks = &ClientTrace{
 	WriteStart: func(p []byte) {},
 	WriteDone:  func(p []byte, c int, err error, d time.Duration) {},
 
-	Error:                func(context, target string, err error) {},
-	NotificationReceived: func(n *common.Notification) {},
-	NotificationDropped:  func(n *common.Notification) {},
-	ExecuteStart:         func(req common.Request, async bool) {},
-	ExecuteDone:          func(req common.Request, async bool, res *common.RPCReply, err error, d time.Duration) {},
+	Error:                 func(context, target string, err error) {},
+	NotificationReceived:  func(n *common.Notification) {},
+	NotificationDropped:   func(n *common.Notification) {},
+	NotificationHighWater: func(n *common.Notification, queued int) {},
+	ExecuteStart:          func(req common.Request, async bool) {},
+	ExecuteDone:           func(req common.Request, async bool, res *common.RPCReply, err error, d time.Duration) {},
+	ReplyUnmatched:        func(res *common.RPCReply, duplicate bool) {},
+	ConnectionStateChange: func(target string, state ConnectionState, err error) {},
+	ResubscribeDone:       func(target string, req common.Request, err error) {},
 }
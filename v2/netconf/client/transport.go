@@ -29,9 +29,19 @@ type tImpl struct {
 
 // NewSSHTransport creates a new SSH transport, connecting to the target with the supplied client configuration
 // and requesting the specified subsystem.
-// nolint : gosec
 func NewSSHTransport(ctx context.Context, clientConfig *ssh.ClientConfig, target, subsystem string) (rt Transport, err error) {
 
+	return newSSHTransport(ctx, clientConfig, target, subsystem, func() (*ssh.Client, error) {
+		return ssh.Dial("tcp", target, clientConfig)
+	})
+}
+
+// newSSHTransport creates a new SSH transport over the client delivered by connect, and requests the specified
+// subsystem.
+// nolint : gosec
+func newSSHTransport(ctx context.Context, clientConfig *ssh.ClientConfig, target, subsystem string,
+	connect func() (*ssh.Client, error)) (rt Transport, err error) {
+
 	impl := tImpl{target: target}
 	impl.trace = ContextClientTrace(ctx)
 
@@ -53,7 +63,7 @@ func NewSSHTransport(ctx context.Context, clientConfig *ssh.ClientConfig, target
 		}
 	}()
 
-	impl.sshClient, err = ssh.Dial("tcp", target, clientConfig)
+	impl.sshClient, err = connect()
 	if err != nil {
 		return
 	}
@@ -91,7 +101,7 @@ func (t *tImpl) Write(p []byte) (n int, err error) {
 
 // Close closes all session resources in the following order:
 //
-//  1. stdin pipe
+//  1. stdin pipe (or TLS connection)
 //  2. SSH session
 //  3. SSH client
 //
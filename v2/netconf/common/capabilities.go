@@ -0,0 +1,201 @@
+package common
+
+import (
+	"sort"
+	"strings"
+)
+
+// Defines a structured model of the capabilities exchanged in netconf hello messages (RFC 6241 section 8),
+// including the YANG module capabilities of RFC 6020 section 5.6.4.
+
+// Define netconf capability URNs.
+const (
+	CapWritableRunning   = "urn:ietf:params:netconf:capability:writable-running:1.0"
+	CapCandidate         = "urn:ietf:params:netconf:capability:candidate:1.0"
+	CapConfirmedCommit10 = "urn:ietf:params:netconf:capability:confirmed-commit:1.0"
+	CapConfirmedCommit   = "urn:ietf:params:netconf:capability:confirmed-commit:1.1"
+	CapRollbackOnError   = "urn:ietf:params:netconf:capability:rollback-on-error:1.0"
+	CapValidate10        = "urn:ietf:params:netconf:capability:validate:1.0"
+	CapValidate          = "urn:ietf:params:netconf:capability:validate:1.1"
+	CapStartup           = "urn:ietf:params:netconf:capability:startup:1.0"
+	CapURL               = "urn:ietf:params:netconf:capability:url:1.0"
+	CapNotification      = "urn:ietf:params:netconf:capability:notification:1.0"
+	CapInterleave        = "urn:ietf:params:netconf:capability:interleave:1.0"
+	CapWithDefaults      = "urn:ietf:params:netconf:capability:with-defaults:1.0"
+	CapYangLibrary10     = "urn:ietf:params:netconf:capability:yang-library:1.0"
+	CapYangLibrary       = "urn:ietf:params:netconf:capability:yang-library:1.1"
+	CapPartialLock       = "urn:ietf:params:netconf:capability:partial-lock:1.0"
+)
+
+// Capability defines a single capability, identified by its URI, with any parameters that qualify it
+// (for example, the scheme parameter of the :url capability).
+type Capability struct {
+	// URI identifies the capability, and excludes any parameters.
+	URI string
+	// Params holds the parameters of the capability, keyed by name.
+	Params map[string]string
+}
+
+// Param delivers the value of the named parameter, or an empty string if it is not defined (or c is nil).
+func (c *Capability) Param(name string) string {
+	if c == nil {
+		return ""
+	}
+	return c.Params[name]
+}
+
+// ParamList delivers the comma-separated values of the named parameter, or nil if it is not defined (or c is nil).
+func (c *Capability) ParamList(name string) []string {
+	return splitList(c.Param(name))
+}
+
+// Module defines a YANG module advertised as a capability.
+type Module struct {
+	Namespace  string
+	Name       string
+	Revision   string
+	Features   []string
+	Deviations []string
+}
+
+// URI delivers the capability URI that advertises the module.
+func (m *Module) URI() string {
+	var params []string
+	params = append(params, "module="+m.Name)
+	if m.Revision != "" {
+		params = append(params, "revision="+m.Revision)
+	}
+	if len(m.Features) > 0 {
+		params = append(params, "features="+strings.Join(m.Features, ","))
+	}
+	if len(m.Deviations) > 0 {
+		params = append(params, "deviations="+strings.Join(m.Deviations, ","))
+	}
+	return m.Namespace + "?" + strings.Join(params, "&")
+}
+
+// HasFeature returns true if the module is advertised with the feature.
+func (m *Module) HasFeature(feature string) bool {
+	if m == nil {
+		return false
+	}
+	for _, f := range m.Features {
+		if f == feature {
+			return true
+		}
+	}
+	return false
+}
+
+// Capabilities defines the set of capabilities advertised by a netconf peer.
+// A nil set, as delivered before the hello exchange completes, is empty.
+type Capabilities struct {
+	raw     []string
+	caps    map[string]*Capability
+	modules map[string]*Module
+}
+
+// ParseCapabilities delivers the capability set defined by the capability URIs in a hello message.
+func ParseCapabilities(caps []string) *Capabilities {
+	cs := &Capabilities{raw: caps, caps: make(map[string]*Capability), modules: make(map[string]*Module)}
+	for _, uri := range caps {
+		c := parseCapability(strings.TrimSpace(uri))
+		cs.caps[c.URI] = c
+		if name := c.Param("module"); name != "" {
+			cs.modules[name] = &Module{
+				Namespace:  c.URI,
+				Name:       name,
+				Revision:   c.Param("revision"),
+				Features:   c.ParamList("features"),
+				Deviations: c.ParamList("deviations"),
+			}
+		}
+	}
+	return cs
+}
+
+// Supports returns true if any of the capabilities, identified by URI without parameters, is supported.
+func (cs *Capabilities) Supports(uris ...string) bool {
+	if cs == nil {
+		return false
+	}
+	for _, uri := range uris {
+		if _, ok := cs.caps[uri]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// Capability delivers the capability identified by the URI, without parameters, or nil if it is not supported.
+func (cs *Capabilities) Capability(uri string) *Capability {
+	if cs == nil {
+		return nil
+	}
+	return cs.caps[uri]
+}
+
+// BaseVersions delivers the versions of the base protocol that are supported, e.g. "1.0" and "1.1".
+func (cs *Capabilities) BaseVersions() (versions []string) {
+	for _, base := range []string{CapBase10, CapBase11} {
+		if cs.Supports(base) {
+			versions = append(versions, base[strings.LastIndex(base, ":")+1:])
+		}
+	}
+	return
+}
+
+// Module delivers the YANG module with the name, or nil if it is not advertised.
+func (cs *Capabilities) Module(name string) *Module {
+	if cs == nil {
+		return nil
+	}
+	return cs.modules[name]
+}
+
+// Modules delivers all advertised YANG modules, ordered by name.
+func (cs *Capabilities) Modules() []*Module {
+	if cs == nil {
+		return nil
+	}
+	modules := make([]*Module, 0, len(cs.modules))
+	for _, m := range cs.modules {
+		modules = append(modules, m)
+	}
+	sort.Slice(modules, func(i, j int) bool { return modules[i].Name < modules[j].Name })
+	return modules
+}
+
+// Strings delivers the capability URIs from which the set was parsed.
+func (cs *Capabilities) Strings() []string {
+	if cs == nil {
+		return nil
+	}
+	return cs.raw
+}
+
+func parseCapability(uri string) *Capability {
+	c := &Capability{URI: uri, Params: make(map[string]string)}
+	i := strings.Index(uri, "?")
+	if i < 0 {
+		return c
+	}
+
+	c.URI = uri[:i]
+	for _, param := range strings.FieldsFunc(uri[i+1:], func(r rune) bool { return r == '&' || r == ';' }) {
+		kv := strings.SplitN(param, "=", 2)
+		if len(kv) == 2 {
+			c.Params[kv[0]] = kv[1]
+		} else {
+			c.Params[kv[0]] = ""
+		}
+	}
+	return c
+}
+
+func splitList(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(s, ",")
+}
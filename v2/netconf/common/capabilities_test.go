@@ -0,0 +1,86 @@
+package common
+
+import (
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestParseCapabilities(t *testing.T) {
+
+	caps := ParseCapabilities([]string{
+		CapBase10,
+		CapBase11,
+		CapCandidate,
+		CapConfirmedCommit,
+		CapValidate10,
+		"urn:ietf:params:netconf:capability:url:1.0?scheme=http,ftp,file",
+		"urn:ietf:params:netconf:capability:with-defaults:1.0?basic-mode=explicit&also-supported=report-all,trim",
+		"urn:ietf:params:netconf:capability:yang-library:1.1?revision=2019-01-04&content-id=42",
+		"urn:ietf:params:xml:ns:yang:ietf-interfaces?module=ietf-interfaces&revision=2018-02-20&features=arbitrary-names,pre-provisioning&deviations=vendor-deviations",
+		"urn:ietf:params:xml:ns:yang:ietf-ip?module=ietf-ip",
+		" urn:ietf:params:netconf:capability:interleave:1.0 ",
+	})
+
+	assert.True(t, caps.Supports(CapCandidate), "Expected candidate")
+	assert.True(t, caps.Supports(CapInterleave), "Expected interleave, ignoring whitespace")
+	assert.True(t, caps.Supports(CapValidate, CapValidate10), "Expected any version of validate")
+	assert.False(t, caps.Supports(CapValidate), "Not expecting validate 1.1")
+	assert.False(t, caps.Supports(CapStartup, CapNotification), "Not expecting startup or notification")
+	assert.True(t, caps.Supports(CapURL), "Expected url, ignoring parameters")
+	assert.Equal(t, []string{"1.0", "1.1"}, caps.BaseVersions(), "Expected base versions")
+
+	assert.Equal(t, []string{"http", "ftp", "file"}, caps.Capability(CapURL).ParamList("scheme"), "Expected url schemes")
+	wd := caps.Capability(CapWithDefaults)
+	assert.Equal(t, "explicit", wd.Param("basic-mode"), "Expected with-defaults basic mode")
+	assert.Equal(t, []string{"report-all", "trim"}, wd.ParamList("also-supported"), "Expected with-defaults modes")
+	assert.Equal(t, "42", caps.Capability(CapYangLibrary).Param("content-id"), "Expected yang-library content id")
+	assert.Nil(t, caps.Capability(CapStartup), "Not expecting startup")
+
+	intf := caps.Module("ietf-interfaces")
+	assert.Equal(t, &Module{
+		Namespace:  "urn:ietf:params:xml:ns:yang:ietf-interfaces",
+		Name:       "ietf-interfaces",
+		Revision:   "2018-02-20",
+		Features:   []string{"arbitrary-names", "pre-provisioning"},
+		Deviations: []string{"vendor-deviations"},
+	}, intf, "Expected module")
+	assert.True(t, intf.HasFeature("pre-provisioning"), "Expected module feature")
+	assert.False(t, intf.HasFeature("if-mib"), "Not expecting module feature")
+	assert.Equal(t, &Module{Namespace: "urn:ietf:params:xml:ns:yang:ietf-ip", Name: "ietf-ip"}, caps.Module("ietf-ip"), "Expected module")
+	assert.Nil(t, caps.Module("ietf-system"), "Not expecting module")
+
+	modules := caps.Modules()
+	assert.Len(t, modules, 2, "Expected modules")
+	assert.Equal(t, "ietf-interfaces", modules[0].Name, "Expected modules ordered by name")
+}
+
+func TestNilCapabilities(t *testing.T) {
+
+	var caps *Capabilities
+	assert.False(t, caps.Supports(CapCandidate), "Not expecting nil set to support candidate")
+	assert.Nil(t, caps.Capability(CapWithDefaults), "Not expecting capability")
+	assert.Equal(t, "", caps.Capability(CapWithDefaults).Param("basic-mode"), "Not expecting capability parameter")
+	assert.Nil(t, caps.Capability(CapURL).ParamList("scheme"), "Not expecting capability parameter")
+	assert.Nil(t, caps.BaseVersions(), "Not expecting base versions")
+	assert.Nil(t, caps.Module("ietf-interfaces"), "Not expecting module")
+	assert.False(t, caps.Module("ietf-interfaces").HasFeature("if-mib"), "Not expecting module feature")
+	assert.Empty(t, caps.Modules(), "Not expecting modules")
+	assert.Nil(t, caps.Strings(), "Not expecting capability URIs")
+}
+
+func TestModuleURI(t *testing.T) {
+
+	m := &Module{
+		Namespace:  "urn:ietf:params:xml:ns:yang:ietf-interfaces",
+		Name:       "ietf-interfaces",
+		Revision:   "2018-02-20",
+		Features:   []string{"arbitrary-names", "pre-provisioning"},
+		Deviations: []string{"vendor-deviations"},
+	}
+	assert.Equal(t, "urn:ietf:params:xml:ns:yang:ietf-interfaces?module=ietf-interfaces&revision=2018-02-20"+
+		"&features=arbitrary-names,pre-provisioning&deviations=vendor-deviations", m.URI(), "Expected module URI")
+	assert.Equal(t, m, ParseCapabilities([]string{m.URI()}).Module("ietf-interfaces"), "Expected module to round trip")
+
+	assert.Equal(t, "urn:test?module=test", (&Module{Namespace: "urn:test", Name: "test"}).URI(), "Expected module URI")
+}
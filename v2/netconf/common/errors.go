@@ -0,0 +1,128 @@
+package common
+
+import (
+	"errors"
+	"strings"
+)
+
+// Defines the errors reported by rpc-error elements (RFC 6241 Appendix A).
+
+// Define the error severities.
+const (
+	SeverityError   = "error"
+	SeverityWarning = "warning"
+)
+
+// RPCErrorTag identifies the error condition reported by an rpc-error. The tags can be used as sentinel errors,
+// so that errors.Is(err, ErrLockDenied) reports whether err holds an rpc-error with the lock-denied tag.
+type RPCErrorTag string
+
+// Define the error tags.
+const (
+	ErrInUse                 RPCErrorTag = "in-use"
+	ErrInvalidValue          RPCErrorTag = "invalid-value"
+	ErrTooBig                RPCErrorTag = "too-big"
+	ErrMissingAttribute      RPCErrorTag = "missing-attribute"
+	ErrBadAttribute          RPCErrorTag = "bad-attribute"
+	ErrUnknownAttribute      RPCErrorTag = "unknown-attribute"
+	ErrMissingElement        RPCErrorTag = "missing-element"
+	ErrBadElement            RPCErrorTag = "bad-element"
+	ErrUnknownElement        RPCErrorTag = "unknown-element"
+	ErrUnknownNamespace      RPCErrorTag = "unknown-namespace"
+	ErrAccessDenied          RPCErrorTag = "access-denied"
+	ErrLockDenied            RPCErrorTag = "lock-denied"
+	ErrResourceDenied        RPCErrorTag = "resource-denied"
+	ErrRollbackFailed        RPCErrorTag = "rollback-failed"
+	ErrDataExists            RPCErrorTag = "data-exists"
+	ErrDataMissing           RPCErrorTag = "data-missing"
+	ErrOperationNotSupported RPCErrorTag = "operation-not-supported"
+	ErrOperationFailed       RPCErrorTag = "operation-failed"
+	ErrPartialOperation      RPCErrorTag = "partial-operation"
+	ErrMalformedMessage      RPCErrorTag = "malformed-message"
+)
+
+func (t RPCErrorTag) Error() string {
+	return "netconf rpc error " + string(t)
+}
+
+// ErrorInfo holds the content of an error-info element.
+type ErrorInfo struct {
+	BadElement   string `xml:"bad-element,omitempty"`
+	BadAttribute string `xml:"bad-attribute,omitempty"`
+	BadNamespace string `xml:"bad-namespace,omitempty"`
+	// SessionID identifies the session holding a lock, for lock-denied errors.
+	SessionID uint64 `xml:"session-id,omitempty"`
+	// OkElements, ErrElements and NoopElements report the outcome for each element of a partial-operation.
+	OkElements   []string `xml:"ok-element"`
+	ErrElements  []string `xml:"err-element"`
+	NoopElements []string `xml:"noop-element"`
+	// Content holds the raw content of the element, including any that is specific to the server.
+	Content string `xml:",innerxml"`
+}
+
+// Is reports whether the target is the error tag of the rpc-error.
+func (re *RPCError) Is(target error) bool {
+	tag, ok := target.(RPCErrorTag)
+	return ok && string(tag) == re.Tag
+}
+
+// RPCErrors aggregates the errors reported by an rpc-reply that holds more than one rpc-error with error severity.
+type RPCErrors struct {
+	Errors []*RPCError
+}
+
+func (e *RPCErrors) Error() string {
+	msgs := make([]string, len(e.Errors))
+	for i, err := range e.Errors {
+		msgs[i] = err.Error()
+	}
+	return strings.Join(msgs, "; ")
+}
+
+// Is reports whether any of the errors matches the target.
+func (e *RPCErrors) Is(target error) bool {
+	for _, err := range e.Errors {
+		if errors.Is(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// As finds the first of the errors that matches the target.
+func (e *RPCErrors) As(target interface{}) bool {
+	for _, err := range e.Errors {
+		if errors.As(err, target) {
+			return true
+		}
+	}
+	return false
+}
+
+// Err delivers the error reported by the reply, which is nil if the reply holds no rpc-error with error severity,
+// a *RPCError if it holds one, or a *RPCErrors aggregating them if it holds more than one.
+func (r *RPCReply) Err() error {
+	errs := r.errors(SeverityError)
+	switch len(errs) {
+	case 0:
+		return nil
+	case 1:
+		return errs[0]
+	default:
+		return &RPCErrors{Errors: errs}
+	}
+}
+
+// Warnings delivers the rpc-errors with warning severity held by the reply, which may have succeeded.
+func (r *RPCReply) Warnings() []*RPCError {
+	return r.errors(SeverityWarning)
+}
+
+func (r *RPCReply) errors(severity string) (errs []*RPCError) {
+	for i := range r.Errors {
+		if r.Errors[i].Severity == severity {
+			errs = append(errs, &r.Errors[i])
+		}
+	}
+	return
+}
@@ -0,0 +1,84 @@
+package common
+
+import (
+	"encoding/xml"
+	"errors"
+	"testing"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+const partialOperationReply = `<rpc-reply xmlns="urn:ietf:params:xml:ns:netconf:base:1.0" message-id="101">
+<rpc-error>
+  <error-type>application</error-type>
+  <error-tag>data-exists</error-tag>
+  <error-severity>error</error-severity>
+  <error-app-tag>duplicate-entry</error-app-tag>
+  <error-path>/t:top/t:interface[t:name="Ethernet0/0"]</error-path>
+  <error-message xml:lang="en">Interface already exists</error-message>
+  <error-info><bad-element>interface</bad-element><bad-namespace>urn:tns</bad-namespace></error-info>
+</rpc-error>
+<rpc-error>
+  <error-type>application</error-type>
+  <error-tag>partial-operation</error-tag>
+  <error-severity>error</error-severity>
+  <error-info><ok-element>mtu</ok-element><err-element>interface</err-element><noop-element>name</noop-element><noop-element>desc</noop-element></error-info>
+</rpc-error>
+<rpc-error>
+  <error-type>application</error-type>
+  <error-tag>operation-failed</error-tag>
+  <error-severity>warning</error-severity>
+  <error-message>Deprecated node</error-message>
+  <error-info><bad-attribute>speed</bad-attribute><session-id>42</session-id></error-info>
+</rpc-error>
+</rpc-reply>`
+
+func TestRPCErrors(t *testing.T) {
+
+	reply := &RPCReply{}
+	assert.NoError(t, xml.Unmarshal([]byte(partialOperationReply), reply), "Not expecting unmarshal to fail")
+
+	err := reply.Err()
+	var rpcErrs *RPCErrors
+	assert.True(t, errors.As(err, &rpcErrs), "Expected multiple errors")
+	assert.Len(t, rpcErrs.Errors, 2, "Expected errors with error severity")
+	assert.Equal(t, "netconf rpc [error] 'Interface already exists'; netconf rpc [error] ''", err.Error(), "Expected error message")
+
+	assert.True(t, errors.Is(err, ErrDataExists), "Expected data-exists error")
+	assert.True(t, errors.Is(err, ErrPartialOperation), "Expected partial-operation error")
+	assert.False(t, errors.Is(err, ErrOperationFailed), "Not expecting warning to be reported as error")
+	assert.False(t, errors.Is(err, ErrLockDenied), "Not expecting lock-denied error")
+
+	var rpcErr *RPCError
+	assert.True(t, errors.As(err, &rpcErr), "Expected rpc-error")
+	assert.Equal(t, "duplicate-entry", rpcErr.AppTag, "Expected error-app-tag")
+	assert.Equal(t, &ErrorInfo{BadElement: "interface", BadNamespace: "urn:tns",
+		Content: `<bad-element>interface</bad-element><bad-namespace>urn:tns</bad-namespace>`}, rpcErr.ErrorInfo, "Expected error-info")
+
+	partial := rpcErrs.Errors[1].ErrorInfo
+	assert.Equal(t, []string{"mtu"}, partial.OkElements, "Expected ok elements")
+	assert.Equal(t, []string{"interface"}, partial.ErrElements, "Expected err elements")
+	assert.Equal(t, []string{"name", "desc"}, partial.NoopElements, "Expected noop elements")
+
+	warnings := reply.Warnings()
+	assert.Len(t, warnings, 1, "Expected warning")
+	assert.Equal(t, "Deprecated node", warnings[0].Message, "Expected warning message")
+	assert.Equal(t, "speed", warnings[0].ErrorInfo.BadAttribute, "Expected bad attribute")
+	assert.Equal(t, uint64(42), warnings[0].ErrorInfo.SessionID, "Expected session id")
+}
+
+func TestRPCReplyErr(t *testing.T) {
+
+	reply := &RPCReply{Errors: []RPCError{{Severity: SeverityWarning, Tag: "operation-failed"}}}
+	assert.NoError(t, reply.Err(), "Not expecting warnings to be reported as errors")
+	assert.Len(t, reply.Warnings(), 1, "Expected warning")
+
+	reply.Errors = append(reply.Errors, RPCError{Severity: SeverityError, Tag: "lock-denied", Message: "locked"})
+	err := reply.Err()
+	_, ok := err.(*RPCError)
+	assert.True(t, ok, "Expected single rpc-error")
+	assert.True(t, errors.Is(err, ErrLockDenied), "Expected lock-denied error")
+	assert.Equal(t, "netconf rpc error lock-denied", ErrLockDenied.Error(), "Expected sentinel message")
+
+	assert.NoError(t, (&RPCReply{}).Err(), "Not expecting error")
+}
@@ -40,9 +40,13 @@ type RPCError struct {
 	Type     string `xml:"error-type"`
 	Tag      string `xml:"error-tag"`
 	Severity string `xml:"error-severity"`
+	AppTag   string `xml:"error-app-tag,omitempty"`
 	Path     string `xml:"error-path"`
 	Message  string `xml:"error-message"`
-	Info     string `xml:",innerxml"`
+	// ErrorInfo holds the decoded error-info element, if any.
+	ErrorInfo *ErrorInfo `xml:"error-info,omitempty"`
+	// Info holds the raw content of the rpc-error element.
+	Info string `xml:",innerxml"`
 }
 
 // Error generates a string representation of the RPC error
@@ -55,8 +59,28 @@ type Notification struct {
 	XMLName   xml.Name
 	EventTime string
 	Event     string `xml:",innerxml"`
+	// SubscriptionID identifies the subscription that generated the notification, if defined by the
+	// event (as for the subscription state and push notifications of RFC 8639 and RFC 8641).
+	SubscriptionID string `xml:"-"`
+	// Type distinguishes event notifications from those that report the progress of the subscription.
+	Type NotificationType `xml:"-"`
 }
 
+// NotificationType distinguishes ordinary event notifications from the notifications that report the
+// progress of a subscription (RFC 5277).
+type NotificationType int
+
+const (
+	// EventNotification is an ordinary event notification.
+	EventNotification NotificationType = iota
+	// ReplayComplete reports that all the replayed notifications requested by the subscription start time
+	// have been sent.
+	ReplayComplete
+	// NotificationComplete reports that all the notifications requested by the subscription stop time have been
+	// sent, and that the subscription has ended.
+	NotificationComplete
+)
+
 // NotificationMessage defines the notification message sent from the server.
 type NotificationMessage struct {
 	XMLName   xml.Name     //`xml:"notification"`
@@ -106,14 +130,13 @@ const (
 	CapBase10       = "urn:ietf:params:netconf:base:1.0"
 	CapBase11       = "urn:ietf:params:netconf:base:1.1"
 	CapXpath        = "urn:ietf:params:netconf:capability:xpath:1.0"
+
+	NetmodNotificationNS      = "urn:ietf:params:xml:ns:netmod:notification"
+	SubscribedNotificationsNS = "urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications"
+	YangPushNS                = "urn:ietf:params:xml:ns:yang:ietf-yang-push"
 )
 
 // PeerSupportsChunkedFraming returns true if capability list indicates support for chunked framing.
 func PeerSupportsChunkedFraming(caps []string) bool {
-	for _, capability := range caps {
-		if capability == CapBase11 {
-			return true
-		}
-	}
-	return false
+	return ParseCapabilities(caps).Supports(CapBase11)
 }
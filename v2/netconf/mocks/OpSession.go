@@ -3,6 +3,9 @@
 package mocks
 
 import (
+	context "context"
+
+	client "github.com/damianoneill/net/v2/netconf/client"
 	common "github.com/damianoneill/net/v2/netconf/common"
 	mock "github.com/stretchr/testify/mock"
 )
@@ -12,6 +15,22 @@ type OpSession struct {
 	mock.Mock
 }
 
+// Capabilities provides a mock function with given fields:
+func (_m *OpSession) Capabilities() *common.Capabilities {
+	ret := _m.Called()
+
+	var r0 *common.Capabilities
+	if rf, ok := ret.Get(0).(func() *common.Capabilities); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*common.Capabilities)
+		}
+	}
+
+	return r0
+}
+
 // Close provides a mock function with given fields:
 func (_m *OpSession) Close() {
 	_m.Called()
@@ -54,6 +73,52 @@ func (_m *OpSession) ExecuteAsync(req common.Request, rchan chan *common.RPCRepl
 	return r0
 }
 
+// ExecuteContext provides a mock function with given fields: ctx, req
+func (_m *OpSession) ExecuteContext(ctx context.Context, req common.Request) (*common.RPCReply, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *common.RPCReply
+	if rf, ok := ret.Get(0).(func(context.Context, common.Request) *common.RPCReply); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*common.RPCReply)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, common.Request) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExecuteStream provides a mock function with given fields: ctx, req, handler
+func (_m *OpSession) ExecuteStream(ctx context.Context, req common.Request, handler client.DataHandler) (*common.RPCReply, error) {
+	ret := _m.Called(ctx, req, handler)
+
+	var r0 *common.RPCReply
+	if rf, ok := ret.Get(0).(func(context.Context, common.Request, client.DataHandler) *common.RPCReply); ok {
+		r0 = rf(ctx, req, handler)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*common.RPCReply)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, common.Request, client.DataHandler) error); ok {
+		r1 = rf(ctx, req, handler)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // ID provides a mock function with given fields:
 func (_m *OpSession) ID() uint64 {
 	ret := _m.Called()
@@ -68,6 +133,20 @@ func (_m *OpSession) ID() uint64 {
 	return r0
 }
 
+// NotificationCounters provides a mock function with given fields:
+func (_m *OpSession) NotificationCounters() client.NotificationCounters {
+	ret := _m.Called()
+
+	var r0 client.NotificationCounters
+	if rf, ok := ret.Get(0).(func() client.NotificationCounters); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Get(0).(client.NotificationCounters)
+	}
+
+	return r0
+}
+
 // ServerCapabilities provides a mock function with given fields:
 func (_m *OpSession) ServerCapabilities() []string {
 	ret := _m.Called()
@@ -106,3 +185,54 @@ func (_m *OpSession) Subscribe(req common.Request, nchan chan *common.Notificati
 
 	return r0, r1
 }
+
+// SubscribeContext provides a mock function with given fields: ctx, req, nchan
+func (_m *OpSession) SubscribeContext(ctx context.Context, req common.Request, nchan chan *common.Notification) (*common.RPCReply, error) {
+	ret := _m.Called(ctx, req, nchan)
+
+	var r0 *common.RPCReply
+	if rf, ok := ret.Get(0).(func(context.Context, common.Request, chan *common.Notification) *common.RPCReply); ok {
+		r0 = rf(ctx, req, nchan)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*common.RPCReply)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, common.Request, chan *common.Notification) error); ok {
+		r1 = rf(ctx, req, nchan)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SubscribeWithOptions provides a mock function with given fields: ctx, req, opts, nchan
+func (_m *OpSession) SubscribeWithOptions(ctx context.Context, req common.Request, opts *client.SubscriptionOptions, nchan chan *common.Notification) (*common.RPCReply, error) {
+	ret := _m.Called(ctx, req, opts, nchan)
+
+	var r0 *common.RPCReply
+	if rf, ok := ret.Get(0).(func(context.Context, common.Request, *client.SubscriptionOptions, chan *common.Notification) *common.RPCReply); ok {
+		r0 = rf(ctx, req, opts, nchan)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*common.RPCReply)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, common.Request, *client.SubscriptionOptions, chan *common.Notification) error); ok {
+		r1 = rf(ctx, req, opts, nchan)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Unsubscribe provides a mock function with given fields: nchan
+func (_m *OpSession) Unsubscribe(nchan chan *common.Notification) {
+	_m.Called(nchan)
+}
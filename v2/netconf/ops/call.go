@@ -0,0 +1,198 @@
+package ops
+
+import (
+	"context"
+	"encoding/xml"
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+)
+
+// Defines a generic call for requests that are not modelled by the other operations, e.g. custom or vendor rpcs,
+// and the request for a YANG 1.1 action (RFC 7950 7.15).
+
+func (s *sImpl) Call(ctx context.Context, req common.Request, resp interface{}) error {
+	reply, err := s.Session.ExecuteContext(ctx, req)
+	if err != nil {
+		return err
+	}
+	return decodeCallReply(reply.Data, resp)
+}
+
+func decodeCallReply(data string, resp interface{}) error {
+	if resp == nil {
+		return nil
+	}
+	body, err := callReplyBody(data)
+	if err != nil {
+		return err
+	}
+	if target, ok := resp.(*string); ok {
+		*target = body
+		return nil
+	}
+	if body == "" {
+		return nil
+	}
+	if hasXMLName(resp) {
+		return xml.Unmarshal([]byte(body), resp)
+	}
+	return xml.Unmarshal([]byte("<reply>"+body+"</reply>"), resp)
+}
+
+// callReplyBody delivers the body of the reply, which is the content of the <data> element if that is the only
+// element of the reply, or the elements of the reply, excluding <ok/> and any rpc-errors.
+func callReplyBody(data string) (string, error) {
+	d := xml.NewDecoder(strings.NewReader(data))
+	var elements []string
+	var names []xml.Name
+	depth := 0
+	var start int64
+	for {
+		offset := d.InputOffset()
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			if depth == 0 {
+				start = offset
+				names = append(names, t.Name)
+			}
+			depth++
+		case xml.EndElement:
+			depth--
+			if depth == 0 {
+				if n := names[len(names)-1]; isNetconfElement(n, "ok") || isNetconfElement(n, "rpc-error") {
+					names = names[:len(names)-1]
+					continue
+				}
+				elements = append(elements, data[start:d.InputOffset()])
+			}
+		}
+	}
+	if len(elements) == 1 && isNetconfElement(names[0], "data") {
+		dataElem := &Data{}
+		if err := xml.Unmarshal([]byte(elements[0]), dataElem); err != nil {
+			return "", err
+		}
+		return dataElem.Content, nil
+	}
+	return strings.Join(elements, ""), nil
+}
+
+// isNetconfElement reports whether name is the named element in the base namespace, which the reply data
+// may not declare.
+func isNetconfElement(name xml.Name, local string) bool {
+	return name.Local == local && (name.Space == "" || name.Space == common.NetconfNS)
+}
+
+func hasXMLName(v interface{}) bool {
+	t := reflect.TypeOf(v)
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if t.Kind() != reflect.Struct {
+		return false
+	}
+	_, ok := t.FieldByName("XMLName")
+	return ok
+}
+
+// ActionReq is the request for a YANG 1.1 action, which is invoked on the data node identified by its ancestors.
+type ActionReq struct {
+	ancestors []*ConfigNode
+	body      interface{}
+}
+
+// Action delivers the request for the action defined by body, which may be an xml string or a struct with xml tags,
+// invoked on the data node identified by the ancestors, outermost first.
+// The ancestors should define their namespaces, and list entries their keys, e.g.
+//
+//	Action(`<reset><reset-at>2014-07-29T13:42:00Z</reset-at></reset>`,
+//	    ListEntry("server", Leaf("name", "apache-1")).NS("urn:example:server-farm"))
+func Action(body interface{}, ancestors ...*ConfigNode) *ActionReq {
+	return &ActionReq{ancestors: ancestors, body: body}
+}
+
+// MarshalXML encodes the action element, which holds the ancestors and the body.
+func (a *ActionReq) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	action := xml.StartElement{Name: xml.Name{Local: "action"}, Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: YangNS}}}
+	if err := e.EncodeToken(action); err != nil {
+		return err
+	}
+	ends := []xml.EndElement{action.End()}
+	ns := YangNS
+	for _, n := range a.ancestors {
+		start, childNS, err := n.startElement(ns, false)
+		if err != nil {
+			return err
+		}
+		if err = e.EncodeToken(start); err != nil {
+			return err
+		}
+		if err = n.marshalChildren(e, childNS); err != nil {
+			return err
+		}
+		ends = append(ends, start.End())
+		ns = childNS
+	}
+	var err error
+	switch body := a.body.(type) {
+	case string:
+		err = encodeRawXML(e, body)
+	case nil:
+	default:
+		err = e.Encode(body)
+	}
+	if err != nil {
+		return err
+	}
+	for i := len(ends) - 1; i >= 0; i-- {
+		if err := e.EncodeToken(ends[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encodeRawXML encodes the xml verbatim, keeping its prefixes and namespace declarations.
+func encodeRawXML(e *xml.Encoder, s string) error {
+	d := xml.NewDecoder(strings.NewReader(s))
+	prefixed := func(n xml.Name) xml.Name {
+		if n.Space == "" {
+			return n
+		}
+		return xml.Name{Local: n.Space + ":" + n.Local}
+	}
+	for {
+		tok, err := d.RawToken()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			start := xml.StartElement{Name: prefixed(t.Name)}
+			for _, attr := range t.Attr {
+				start.Attr = append(start.Attr, xml.Attr{Name: prefixed(attr.Name), Value: attr.Value})
+			}
+			tok = start
+		case xml.EndElement:
+			tok = xml.EndElement{Name: prefixed(t.Name)}
+		case xml.ProcInst:
+			continue
+		}
+		if err = e.EncodeToken(xml.CopyToken(tok)); err != nil {
+			return err
+		}
+	}
+}
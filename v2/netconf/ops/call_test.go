@@ -0,0 +1,119 @@
+package ops
+
+import (
+	"context"
+	"encoding/xml"
+	"testing"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+	"github.com/damianoneill/net/v2/netconf/testserver"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+type vendorReq struct {
+	XMLName xml.Name `xml:"urn:vendor clear-counters"`
+	Port    string   `xml:"port"`
+}
+
+type vendorResult struct {
+	XMLName xml.Name `xml:"urn:vendor result"`
+	Code    int      `xml:"code"`
+}
+
+type vendorOutput struct {
+	Status string `xml:"urn:vendor status"`
+	Count  int    `xml:"urn:vendor count"`
+}
+
+func TestCall(t *testing.T) {
+
+	ctx := context.Background()
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("ExecuteContext", ctx, `<ping xmlns="urn:vendor"/>`).Return(&common.RPCReply{Data: `<ok/>`}, nil)
+	mcli.On("ExecuteContext", ctx, &vendorReq{Port: "ge-0/0/0"}).Return(&common.RPCReply{Data: `
+  <result xmlns="urn:vendor"><code>3</code></result>`}, nil)
+	mcli.On("ExecuteContext", ctx, &vendorReq{Port: "all"}).Return(&common.RPCReply{
+		Data: `<rpc-error><error-tag>operation-failed</error-tag><error-severity>warning</error-severity></rpc-error>` +
+			`<status xmlns="urn:vendor">done</status><count xmlns="urn:vendor">42</count>`}, nil)
+	mcli.On("ExecuteContext", ctx, `<get-result xmlns="urn:vendor"/>`).Return(&common.RPCReply{Data: `<data><result xmlns="urn:vendor"><code>7</code></result></data>`}, nil)
+
+	result := &vendorResult{Code: -1}
+	assert.NoError(t, ncs.Call(ctx, `<ping xmlns="urn:vendor"/>`, result), "Not expecting call to fail")
+	assert.Equal(t, -1, result.Code, "Not expecting ok reply to be decoded")
+	assert.NoError(t, ncs.Call(ctx, `<ping xmlns="urn:vendor"/>`, nil), "Not expecting call to fail")
+
+	assert.NoError(t, ncs.Call(ctx, &vendorReq{Port: "ge-0/0/0"}, result), "Not expecting call to fail")
+	assert.Equal(t, 3, result.Code, "Expected bare reply to be decoded")
+
+	output := &vendorOutput{}
+	assert.NoError(t, ncs.Call(ctx, &vendorReq{Port: "all"}, output), "Not expecting call to fail")
+	assert.Equal(t, &vendorOutput{Status: "done", Count: 42}, output, "Expected output parameters to be decoded")
+
+	assert.NoError(t, ncs.Call(ctx, `<get-result xmlns="urn:vendor"/>`, result), "Not expecting call to fail")
+	assert.Equal(t, 7, result.Code, "Expected data reply to be decoded")
+
+	var body string
+	assert.NoError(t, ncs.Call(ctx, `<get-result xmlns="urn:vendor"/>`, &body), "Not expecting call to fail")
+	assert.Equal(t, `<result xmlns="urn:vendor"><code>7</code></result>`, body, "Expected data content")
+	assert.NoError(t, ncs.Call(ctx, &vendorReq{Port: "all"}, &body), "Not expecting call to fail")
+	assert.Equal(t, `<status xmlns="urn:vendor">done</status><count xmlns="urn:vendor">42</count>`, body, "Expected reply elements")
+
+	mcli.AssertExpectations(t)
+}
+
+func TestCallFailure(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t).
+		WithRequestHandler(testserver.FailingRequestHandler).
+		WithRequestHandler(testserver.RawReplyRequestHandler(`<result xmlns="urn:other"><code>1</code></result>`))
+	ncs := newTransactionSession(t, ts)
+	defer ncs.Close()
+
+	result := &vendorResult{}
+	err := ncs.Call(context.Background(), &vendorReq{}, result)
+	assert.EqualError(t, err, "netconf rpc [error] 'oops'", "Expected rpc-error")
+
+	err = ncs.Call(context.Background(), &vendorReq{}, result)
+	assert.Error(t, err, "Expected unexpected root element to fail")
+	assert.Equal(t, "clear-counters", ts.SessionHandler(ncs.ID()).LastReq().XMLName.Local, "Expected vendor request")
+}
+
+type resetAction struct {
+	XMLName xml.Name `xml:"urn:example:server-farm reset"`
+	ResetAt string   `xml:"reset-at"`
+}
+
+func TestAction(t *testing.T) {
+
+	req, err := xml.Marshal(Action(&resetAction{ResetAt: "2014-07-29T13:42:00Z"},
+		ListEntry("server", Leaf("name", "apache-1")).NS("urn:example:server-farm")))
+	assert.NoError(t, err, "Not expecting marshal to fail")
+	assert.Equal(t, `<action xmlns="urn:ietf:params:xml:ns:yang:1">`+
+		`<server xmlns="urn:example:server-farm"><name>apache-1</name>`+
+		`<reset xmlns="urn:example:server-farm"><reset-at>2014-07-29T13:42:00Z</reset-at></reset>`+
+		`</server></action>`, string(req), "Expected action request")
+
+	req, err = xml.Marshal(Action(`<sf:reset xmlns:sf="urn:example:server-farm"><sf:reset-at>now &amp; then</sf:reset-at></sf:reset>`,
+		Container("farm").NS("urn:example:server-farm"), ListEntry("server", Leaf("name", "a"), Leaf("rack", "1"))))
+	assert.NoError(t, err, "Not expecting marshal to fail")
+	assert.Equal(t, `<action xmlns="urn:ietf:params:xml:ns:yang:1">`+
+		`<farm xmlns="urn:example:server-farm"><server><name>a</name><rack>1</rack>`+
+		`<sf:reset xmlns:sf="urn:example:server-farm"><sf:reset-at>now &amp; then</sf:reset-at></sf:reset>`+
+		`</server></farm></action>`, string(req), "Expected action request")
+
+	_, err = xml.Marshal(Action(`<reset>`, Container("farm")))
+	assert.Error(t, err, "Expected invalid action body")
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	action := Action(&resetAction{}, ListEntry("server", Leaf("name", "apache-1")).NS("urn:example:server-farm"))
+	mcli.On("ExecuteContext", context.Background(), action).Return(&common.RPCReply{Data: `<reset-finished-at xmlns="urn:example:server-farm">2014-07-29T13:42:12Z</reset-finished-at>`}, nil)
+
+	var output struct {
+		FinishedAt string `xml:"reset-finished-at"`
+	}
+	assert.NoError(t, ncs.Call(context.Background(), action, &output), "Not expecting action to fail")
+	assert.Equal(t, "2014-07-29T13:42:12Z", output.FinishedAt, "Expected action output")
+
+	mcli.AssertExpectations(t)
+}
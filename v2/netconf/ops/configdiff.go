@@ -0,0 +1,325 @@
+package ops
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// Defines a namespace-aware comparison of two configurations, e.g. the running configuration retrieved by
+// GetConfigSubtree and the intended configuration, which delivers a report of the changes and the configuration
+// for an edit-config request that applies them.
+
+// ListKeys maps the names of list elements to the names of their key leaves, so that list entries can be matched
+// by key. A name mapped to no keys identifies a leaf-list, whose entries are matched by value (see DiffConfig).
+// A name without a namespace matches elements with that local name in any namespace.
+type ListKeys map[xml.Name][]string
+
+func (k ListKeys) lookup(name xml.Name) ([]string, bool) {
+	if keys, ok := k[name]; ok {
+		return keys, true
+	}
+	keys, ok := k[xml.Name{Local: name.Local}]
+	return keys, ok
+}
+
+// ConfigChange describes a difference between two configurations.
+type ConfigChange struct {
+	// Operation is one of ChangeCreate, ChangeDelete or ChangeReplace.
+	Operation string
+	// Path identifies the data node, with list entries qualified by their keys.
+	Path string
+	// Old and New hold the values of a leaf, if applicable.
+	Old string
+	New string
+}
+
+func (c ConfigChange) String() string {
+	switch {
+	case c.Operation == ChangeCreate && c.New != "":
+		return fmt.Sprintf("+ %s = %s", c.Path, c.New)
+	case c.Operation == ChangeCreate:
+		return "+ " + c.Path
+	case c.Operation == ChangeDelete && c.Old != "":
+		return fmt.Sprintf("- %s = %s", c.Path, c.Old)
+	case c.Operation == ChangeDelete:
+		return "- " + c.Path
+	case c.Old != "" || c.New != "":
+		return fmt.Sprintf("~ %s: %s -> %s", c.Path, c.Old, c.New)
+	}
+	return "~ " + c.Path
+}
+
+// ConfigDiff holds the differences between two configurations.
+type ConfigDiff struct {
+	// Changes lists the differences.
+	Changes []ConfigChange
+	// Edit is the configuration that applies the changes, using create, delete and replace operations,
+	// e.g. EditConfig(RunningCfg, Cfg(diff.Edit)).
+	Edit ConfigTree
+}
+
+// Empty reports whether the configurations are equivalent.
+func (d *ConfigDiff) Empty() bool {
+	return len(d.Changes) == 0
+}
+
+// String delivers a report of the changes, one per line.
+func (d *ConfigDiff) String() string {
+	var b strings.Builder
+	for _, c := range d.Changes {
+		b.WriteString(c.String())
+		b.WriteString("\n")
+	}
+	return b.String()
+}
+
+// DiffConfig compares the current configuration with the intended configuration, both of which are xml documents
+// holding the top-level configuration elements (as returned by GetConfigSubtree), and delivers the changes
+// needed to turn current into intended.
+// Elements are matched by namespace and name, list entries by their keys, as defined by keys, and leaf-list
+// entries by value. The order of list entries is not compared. An error is returned if a list has several
+// entries, but its keys are not defined; a list with a single entry in each configuration is compared as a
+// container, so lists should also be defined by keys.
+// A leaf-list that is not defined by keys is only recognised when it has several entries; one with a single entry
+// in each configuration is compared as a leaf, and a change of its value replaces the leaf, which would add the new
+// entry to the leaf-list without deleting the old one. Leaf-lists should therefore be defined by keys, e.g. using
+// ListKeysFromYang.
+func DiffConfig(current, intended string, keys ListKeys) (*ConfigDiff, error) {
+	cur, err := parseConfigXML(current)
+	if err != nil {
+		return nil, fmt.Errorf("current config: %w", err)
+	}
+	in, err := parseConfigXML(intended)
+	if err != nil {
+		return nil, fmt.Errorf("intended config: %w", err)
+	}
+	d := &ConfigDiff{}
+	differ := &configDiffer{keys: keys, diff: d}
+	if d.Edit, err = differ.diffChildren(cur, in, ""); err != nil {
+		return nil, err
+	}
+	return d, nil
+}
+
+// configElement is a generic representation of a configuration element.
+type configElement struct {
+	name     xml.Name
+	text     string
+	children []*configElement
+}
+
+func (e *configElement) isLeaf() bool {
+	return len(e.children) == 0
+}
+
+func (e *configElement) child(local string) *configElement {
+	for _, c := range e.children {
+		if c.name.Local == local {
+			return c
+		}
+	}
+	return nil
+}
+
+func parseConfigXML(s string) ([]*configElement, error) {
+	d := xml.NewDecoder(bytes.NewReader([]byte(s)))
+	root := &configElement{}
+	stack := []*configElement{root}
+	var text strings.Builder
+	for {
+		tok, err := d.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, err
+		}
+		top := stack[len(stack)-1]
+		switch t := tok.(type) {
+		case xml.StartElement:
+			e := &configElement{name: t.Name}
+			top.children = append(top.children, e)
+			stack = append(stack, e)
+			text.Reset()
+		case xml.CharData:
+			text.Write(t)
+		case xml.EndElement:
+			if top.isLeaf() {
+				top.text = strings.TrimSpace(text.String())
+			}
+			stack = stack[:len(stack)-1]
+			text.Reset()
+		}
+	}
+	return root.children, nil
+}
+
+type configDiffer struct {
+	keys ListKeys
+	diff *ConfigDiff
+}
+
+func (cd *configDiffer) record(operation, path, old, new string) {
+	cd.diff.Changes = append(cd.diff.Changes, ConfigChange{Operation: operation, Path: path, Old: old, New: new})
+}
+
+// leafLists delivers the names of the leaf elements that are leaf-list entries, either as defined by the keys,
+// or because they are repeated.
+func (cd *configDiffer) leafLists(siblings ...[]*configElement) map[xml.Name]bool {
+	leafLists := map[xml.Name]bool{}
+	for _, elements := range siblings {
+		count := map[xml.Name]int{}
+		for _, e := range elements {
+			if !e.isLeaf() {
+				continue
+			}
+			count[e.name]++
+			if keys, ok := cd.keys.lookup(e.name); (ok && len(keys) == 0) || count[e.name] > 1 {
+				leafLists[e.name] = true
+			}
+		}
+	}
+	return leafLists
+}
+
+// identity delivers the path step of e, which identifies it amongst its siblings.
+func (cd *configDiffer) identity(e *configElement, leafLists map[xml.Name]bool) string {
+	step := e.name.Local
+	if keys, ok := cd.keys.lookup(e.name); ok && len(keys) > 0 && !e.isLeaf() {
+		for _, k := range keys {
+			var value string
+			if kc := e.child(k); kc != nil {
+				value = kc.text
+			}
+			step += fmt.Sprintf("[%s='%s']", k, value)
+		}
+	} else if leafLists[e.name] {
+		step += fmt.Sprintf("[.='%s']", e.text)
+	}
+	return step
+}
+
+// checkListKeys returns an error if any of the sibling elements are repeated list entries whose keys are not
+// defined, as they cannot be matched.
+func (cd *configDiffer) checkListKeys(path string, siblings ...[]*configElement) error {
+	for _, elements := range siblings {
+		count := map[xml.Name]int{}
+		for _, e := range elements {
+			if e.isLeaf() {
+				continue
+			}
+			count[e.name]++
+			if keys, _ := cd.keys.lookup(e.name); count[e.name] > 1 && len(keys) == 0 {
+				return fmt.Errorf("config diff: list %s/%s has several entries, but its keys are not defined", path, e.name.Local)
+			}
+		}
+	}
+	return nil
+}
+
+func (cd *configDiffer) diffChildren(current, intended []*configElement, path string) ([]*ConfigNode, error) {
+	if err := cd.checkListKeys(path, current, intended); err != nil {
+		return nil, err
+	}
+	leafLists := cd.leafLists(current, intended)
+	key := func(e *configElement) string {
+		return e.name.Space + " " + cd.identity(e, leafLists)
+	}
+	unmatched := map[string][]*configElement{}
+	for _, c := range current {
+		unmatched[key(c)] = append(unmatched[key(c)], c)
+	}
+
+	var edits []*ConfigNode
+	for _, in := range intended {
+		k := key(in)
+		p := path + "/" + cd.identity(in, leafLists)
+		if len(unmatched[k]) == 0 {
+			cd.record(ChangeCreate, p, "", in.text)
+			edits = append(edits, toConfigNode(in).Op(CreateOp))
+			continue
+		}
+		cur := unmatched[k][0]
+		unmatched[k] = unmatched[k][1:]
+		edit, err := cd.diffElement(cur, in, p)
+		if err != nil {
+			return nil, err
+		}
+		if edit != nil {
+			edits = append(edits, edit)
+		}
+	}
+	for _, cur := range current {
+		k := key(cur)
+		if len(unmatched[k]) == 0 || unmatched[k][0] != cur {
+			continue
+		}
+		unmatched[k] = unmatched[k][1:]
+		cd.record(ChangeDelete, path+"/"+cd.identity(cur, leafLists), cur.text, "")
+		edits = append(edits, cd.deletion(cur, leafLists[cur.name]))
+	}
+	return edits, nil
+}
+
+// diffElement delivers the edit that turns the current element into the intended element, if required.
+func (cd *configDiffer) diffElement(cur, in *configElement, path string) (*ConfigNode, error) {
+	switch {
+	case cur.isLeaf() && in.isLeaf():
+		if cur.text == in.text {
+			return nil, nil
+		}
+		cd.record(ChangeReplace, path, cur.text, in.text)
+		return toConfigNode(in).Op(ReplaceOp), nil
+	case cur.isLeaf() || in.isLeaf():
+		cd.record(ChangeReplace, path, "", "")
+		return toConfigNode(in).Op(ReplaceOp), nil
+	}
+
+	edits, err := cd.diffChildren(cur.children, in.children, path)
+	if err != nil || len(edits) == 0 {
+		return nil, err
+	}
+	edit := Container(in.name.Local).NS(in.name.Space)
+	if keys, ok := cd.keys.lookup(in.name); ok && len(keys) > 0 {
+		edit = ListEntry(in.name.Local, keyLeaves(in, keys)...).NS(in.name.Space)
+	}
+	return edit.Add(edits...), nil
+}
+
+// deletion delivers the edit that deletes the element, which identifies list and leaf-list entries.
+func (cd *configDiffer) deletion(e *configElement, leafList bool) *ConfigNode {
+	if keys, ok := cd.keys.lookup(e.name); ok && len(keys) > 0 && !e.isLeaf() {
+		return ListEntry(e.name.Local, keyLeaves(e, keys)...).NS(e.name.Space).Op(DeleteOp)
+	}
+	switch {
+	case leafList:
+		return LeafList(e.name.Local, e.text).NS(e.name.Space).Op(DeleteOp)
+	case e.isLeaf():
+		return Leaf(e.name.Local, "").NS(e.name.Space).Op(DeleteOp)
+	}
+	return Container(e.name.Local).NS(e.name.Space).Op(DeleteOp)
+}
+
+func keyLeaves(e *configElement, keys []string) []*ConfigNode {
+	var leaves []*ConfigNode
+	for _, k := range keys {
+		if kc := e.child(k); kc != nil {
+			leaves = append(leaves, toConfigNode(kc))
+		}
+	}
+	return leaves
+}
+
+func toConfigNode(e *configElement) *ConfigNode {
+	if e.isLeaf() {
+		return Leaf(e.name.Local, e.text).NS(e.name.Space)
+	}
+	n := Container(e.name.Local).NS(e.name.Space)
+	for _, c := range e.children {
+		n.Add(toConfigNode(c))
+	}
+	return n
+}
@@ -0,0 +1,206 @@
+package ops
+
+import (
+	"context"
+	"encoding/xml"
+	"testing"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+const runningInterfaces = `
+<interfaces xmlns="urn:ietf:params:xml:ns:yang:ietf-interfaces">
+  <interface>
+    <name>eth0</name>
+    <description>uplink</description>
+    <mtu>1500</mtu>
+    <ipv4 xmlns="urn:ietf:params:xml:ns:yang:ietf-ip">
+      <address><ip>192.0.2.1</ip><prefix-length>24</prefix-length></address>
+    </ipv4>
+  </interface>
+  <interface>
+    <name>eth1</name>
+  </interface>
+</interfaces>
+<system xmlns="urn:sys">
+  <dns>192.0.2.53</dns>
+  <dns>192.0.2.54</dns>
+  <ntp><server>old</server></ntp>
+</system>`
+
+const intendedInterfaces = `
+<system xmlns="urn:sys">
+  <dns>192.0.2.54</dns>
+  <dns>192.0.2.55</dns>
+  <ntp>on</ntp>
+  <hostname>router</hostname>
+</system>
+<interfaces xmlns="urn:ietf:params:xml:ns:yang:ietf-interfaces">
+  <interface>
+    <name>eth2</name>
+    <mtu>9000</mtu>
+  </interface>
+  <interface>
+    <name>eth0</name>
+    <description>uplink</description>
+    <mtu>9000</mtu>
+    <ipv4 xmlns="urn:ietf:params:xml:ns:yang:ietf-ip">
+      <address><ip>192.0.2.1</ip><prefix-length>24</prefix-length></address>
+    </ipv4>
+  </interface>
+</interfaces>`
+
+var interfaceKeys = ListKeys{{Space: ifNS, Local: "interface"}: {"name"}, {Space: ipNS, Local: "address"}: {"ip"}}
+
+func TestDiffConfig(t *testing.T) {
+
+	diff, err := DiffConfig(runningInterfaces, intendedInterfaces, interfaceKeys)
+	assert.NoError(t, err, "Not expecting diff to fail")
+	assert.False(t, diff.Empty(), "Expected differences")
+	assert.Equal(t, `+ /system/dns[.='192.0.2.55'] = 192.0.2.55
+~ /system/ntp
++ /system/hostname = router
+- /system/dns[.='192.0.2.53'] = 192.0.2.53
++ /interfaces/interface[name='eth2']
+~ /interfaces/interface[name='eth0']/mtu: 1500 -> 9000
+- /interfaces/interface[name='eth1']
+`, diff.String(), "Expected change report")
+	assert.Equal(t, ConfigChange{Operation: ChangeReplace, Path: "/interfaces/interface[name='eth0']/mtu", Old: "1500", New: "9000"},
+		diff.Changes[5], "Expected change")
+
+	req, err := xml.Marshal(createEditConfigRequest(RunningCfg, Cfg(diff.Edit)))
+	assert.NoError(t, err, "Not expecting marshal to fail")
+	assert.Equal(t, `<edit-config><target><running/></target><config>`+
+		`<system xmlns="urn:sys" xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0">`+
+		`<dns nc:operation="create">192.0.2.55</dns><ntp nc:operation="replace">on</ntp><hostname nc:operation="create">router</hostname>`+
+		`<dns nc:operation="delete">192.0.2.53</dns></system>`+
+		`<interfaces xmlns="urn:ietf:params:xml:ns:yang:ietf-interfaces" xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0">`+
+		`<interface nc:operation="create"><name>eth2</name><mtu>9000</mtu></interface>`+
+		`<interface><name>eth0</name><mtu nc:operation="replace">9000</mtu></interface>`+
+		`<interface nc:operation="delete"><name>eth1</name></interface>`+
+		`</interfaces></config></edit-config>`, string(req), "Expected edit-config request")
+}
+
+func TestDiffConfigNamespaces(t *testing.T) {
+
+	diff, err := DiffConfig(`<top xmlns="urn:a"><leaf>1</leaf></top><top xmlns="urn:b"><leaf>1</leaf></top>`,
+		`<a:top xmlns:a="urn:a"><a:leaf>1</a:leaf></a:top><top xmlns="urn:c"><leaf>1</leaf></top>`, nil)
+	assert.NoError(t, err, "Not expecting diff to fail")
+	assert.Equal(t, "+ /top\n- /top\n", diff.String(), "Expected elements to be matched by namespace")
+
+	req, _ := xml.Marshal(diff.Edit)
+	assert.Equal(t, `<top xmlns="urn:c" xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0" nc:operation="create"><leaf>1</leaf></top>`+
+		`<top xmlns="urn:b" xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0" nc:operation="delete"></top>`, string(req), "Expected edit")
+
+	diff, err = DiffConfig(runningInterfaces, runningInterfaces, interfaceKeys)
+	assert.NoError(t, err, "Not expecting diff to fail")
+	assert.True(t, diff.Empty(), "Expected no differences")
+	assert.Empty(t, diff.Edit, "Expected no edit")
+
+	_, err = DiffConfig(`<top>`, `<top/>`, nil)
+	assert.Error(t, err, "Expected invalid current config")
+}
+
+const interfacesModule = `
+module ietf-interfaces {
+  yang-version 1.1;
+  namespace "urn:ietf:params:xml:ns:yang:"
+          + 'ietf-interfaces';
+  prefix if;
+
+  /* Interfaces
+   * and their addresses. */
+  grouping addresses {
+    list address {
+      key "ip";
+      leaf ip { type string; }
+    }
+  }
+
+  container interfaces {
+    list interface {
+      key "name"; // the name
+      leaf name { type string; }
+      leaf-list alias { type string; }
+      uses addresses;
+    }
+    list statistics {
+      config false;
+    }
+  }
+}`
+
+func TestListKeysFromYang(t *testing.T) {
+
+	keys, err := ListKeysFromYang(interfacesModule, `submodule sub { belongs-to main { prefix m; } list entry { key "a b"; } }`)
+	assert.NoError(t, err, "Not expecting parse to fail")
+	assert.Equal(t, ListKeys{
+		{Space: ifNS, Local: "interface"}: {"name"},
+		{Space: ifNS, Local: "alias"}:     {},
+		{Local: "address"}:                {"ip"},
+		{Local: "entry"}:                  {"a", "b"},
+	}, keys, "Expected list keys")
+
+	_, err = ListKeysFromYang(`module m { container c { `)
+	assert.EqualError(t, err, "yang: unterminated block container at line 1", "Expected invalid module")
+	_, err = ListKeysFromYang(`module m { description "text; }`)
+	assert.EqualError(t, err, "yang: unterminated string at line 1", "Expected invalid module")
+}
+
+func TestDiffConfigEdit(t *testing.T) {
+
+	keys, _ := ListKeysFromYang(interfacesModule)
+	diff, err := DiffConfig(`<interfaces xmlns="urn:ietf:params:xml:ns:yang:ietf-interfaces"><interface><name>eth0</name><alias>a</alias></interface></interfaces>`,
+		`<interfaces xmlns="urn:ietf:params:xml:ns:yang:ietf-interfaces"><interface><name>eth0</name><alias>b</alias></interface></interfaces>`, keys)
+	assert.NoError(t, err, "Not expecting diff to fail")
+	assert.Equal(t, "+ /interfaces/interface[name='eth0']/alias[.='b'] = b\n- /interfaces/interface[name='eth0']/alias[.='a'] = a\n",
+		diff.String(), "Expected leaf-list entries to be matched by value")
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("ExecuteContext", context.Background(), createEditConfigRequest(RunningCfg, Cfg(diff.Edit))).Return(&common.RPCReply{}, nil)
+
+	err = ncs.EditConfigCfg(RunningCfg, diff.Edit)
+	assert.NoError(t, err, "Not expecting edit to fail")
+
+	mcli.AssertExpectations(t)
+}
+
+func TestDiffConfigSingleLeafListEntry(t *testing.T) {
+
+	current := `<system xmlns="urn:sys"><search>a.example</search></system>`
+	intended := `<system xmlns="urn:sys"><search>b.example</search></system>`
+
+	// Without the leaf-list being defined, a single entry cannot be distinguished from a leaf.
+	diff, err := DiffConfig(current, intended, nil)
+	assert.NoError(t, err, "Not expecting diff to fail")
+	assert.Equal(t, "~ /system/search: a.example -> b.example\n", diff.String(), "Expected leaf change")
+
+	diff, err = DiffConfig(current, intended, ListKeys{{Local: "search"}: {}})
+	assert.NoError(t, err, "Not expecting diff to fail")
+	assert.Equal(t, "+ /system/search[.='b.example'] = b.example\n- /system/search[.='a.example'] = a.example\n",
+		diff.String(), "Expected leaf-list entries to be matched by value")
+
+	req, err := xml.Marshal(diff.Edit)
+	assert.NoError(t, err, "Not expecting marshal to fail")
+	assert.Equal(t, `<system xmlns="urn:sys" xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0">`+
+		`<search nc:operation="create">b.example</search><search nc:operation="delete">a.example</search></system>`,
+		string(req), "Expected old entry to be deleted and new entry created")
+}
+
+func TestDiffConfigUnkeyedList(t *testing.T) {
+
+	current := `<interfaces xmlns="urn:ietf:params:xml:ns:yang:ietf-interfaces">` +
+		`<interface><name>eth0</name></interface><interface><name>eth1</name></interface></interfaces>`
+	intended := `<interfaces xmlns="urn:ietf:params:xml:ns:yang:ietf-interfaces">` +
+		`<interface><name>eth1</name></interface><interface><name>eth0</name></interface></interfaces>`
+
+	_, err := DiffConfig(current, intended, nil)
+	assert.EqualError(t, err, "config diff: list /interfaces/interface has several entries, but its keys are not defined",
+		"Expected list entries without keys to be rejected")
+
+	diff, err := DiffConfig(current, intended, interfaceKeys)
+	assert.NoError(t, err, "Not expecting diff to fail")
+	assert.True(t, diff.Empty(), "Expected reordered list entries to match by key")
+}
@@ -0,0 +1,258 @@
+package ops
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+)
+
+// Defines a builder for the configuration carried by an edit-config request, which models the YANG data nodes
+// (containers, list entries, leaves and leaf-lists) and allows an operation (RFC 6241 7.2) and, for ordered-by-user
+// lists and leaf-lists, an insert position (RFC 7950 7.8.6 & 7.7.9) to be set on each node.
+
+// YangNS is the namespace of the YANG insert, key and value attributes.
+const YangNS = "urn:ietf:params:xml:ns:yang:1"
+
+// Insert positions of an entry in an ordered-by-user list or leaf-list.
+const (
+	InsertFirst  = "first"
+	InsertLast   = "last"
+	InsertBefore = "before"
+	InsertAfter  = "after"
+)
+
+type configNodeKind int
+
+const (
+	containerNode configNodeKind = iota
+	listNode
+	leafNode
+	leafListNode
+)
+
+// ConfigNode is a data node in a configuration tree.
+type ConfigNode struct {
+	kind      configNodeKind
+	name      string
+	namespace string
+	values    []string
+	operation string
+	insert    string
+	point     string
+	pointKeys []*ConfigNode
+	keys      []*ConfigNode
+	children  []*ConfigNode
+}
+
+// ConfigTree is a configuration, defined by its top-level data nodes, that can be used as the content of the
+// <config> element of an edit-config request, e.g. EditConfig(RunningCfg, Cfg(tree)).
+type ConfigTree []*ConfigNode
+
+// CfgTree defines the configuration of an edit-config request by its top-level data nodes.
+func CfgTree(nodes ...*ConfigNode) ConfigOption {
+	return func(req *EditConfigReq) {
+		req.Config = &Config{Union: common.GetUnion(ConfigTree(nodes))}
+	}
+}
+
+// Container delivers a container node with the children.
+func Container(name string, children ...*ConfigNode) *ConfigNode {
+	return &ConfigNode{kind: containerNode, name: name, children: children}
+}
+
+// ListEntry delivers an entry of a list, identified by the key leaves, which are encoded ahead of any children.
+func ListEntry(name string, keys ...*ConfigNode) *ConfigNode {
+	return &ConfigNode{kind: listNode, name: name, keys: keys}
+}
+
+// Leaf delivers a leaf node with the value.
+func Leaf(name, value string) *ConfigNode {
+	return &ConfigNode{kind: leafNode, name: name, values: []string{value}}
+}
+
+// LeafList delivers a leaf-list node, encoded as an element for each of the values.
+func LeafList(name string, values ...string) *ConfigNode {
+	return &ConfigNode{kind: leafListNode, name: name, values: values}
+}
+
+// NS sets the namespace of the node, which is inherited by its descendants.
+func (n *ConfigNode) NS(namespace string) *ConfigNode {
+	n.namespace = namespace
+	return n
+}
+
+// Op sets the operation to be applied to the node (e.g. CreateOp, DeleteOp, RemoveOp, ReplaceOp or MergeOp).
+func (n *ConfigNode) Op(operation string) *ConfigNode {
+	n.operation = operation
+	return n
+}
+
+// Insert sets the position of a list or leaf-list entry in an ordered-by-user list (e.g. InsertFirst).
+// For InsertBefore and InsertAfter, point is the value of the existing leaf-list entry; the existing entry
+// of a list is identified using InsertKey.
+func (n *ConfigNode) Insert(position, point string) *ConfigNode {
+	n.insert = position
+	n.point = point
+	return n
+}
+
+// InsertKey sets the position of a list entry relative to the existing entry identified by its key leaves,
+// e.g. InsertKey(InsertAfter, Leaf("name", "eth0")). The key predicate is built from the leaves, using a prefix
+// declared for the namespace of the list.
+func (n *ConfigNode) InsertKey(position string, keys ...*ConfigNode) *ConfigNode {
+	n.insert = position
+	n.pointKeys = keys
+	return n
+}
+
+// Add appends the children to the node.
+func (n *ConfigNode) Add(children ...*ConfigNode) *ConfigNode {
+	n.children = append(n.children, children...)
+	return n
+}
+
+// MarshalXML encodes the node and its descendants, ignoring start.
+func (n *ConfigNode) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	return n.marshal(e, "", true)
+}
+
+// MarshalXML encodes the nodes of the tree, ignoring start.
+func (t ConfigTree) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	for _, n := range t {
+		if err := n.marshal(e, "", true); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *ConfigNode) marshal(e *xml.Encoder, parentNS string, top bool) error {
+	start, ns, err := n.startElement(parentNS, top)
+	if err != nil {
+		return err
+	}
+	switch n.kind {
+	case leafNode, leafListNode:
+		for _, v := range n.values {
+			if err := e.EncodeToken(start); err != nil {
+				return err
+			}
+			if err := e.EncodeToken(xml.CharData(v)); err != nil {
+				return err
+			}
+			if err := e.EncodeToken(start.End()); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	if err := n.marshalChildren(e, ns); err != nil {
+		return err
+	}
+	return e.EncodeToken(start.End())
+}
+
+func (n *ConfigNode) marshalChildren(e *xml.Encoder, ns string) error {
+	for _, c := range append(append([]*ConfigNode{}, n.keys...), n.children...) {
+		if err := c.marshal(e, ns, false); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// startElement delivers the start element of the node, and the namespace of its children.
+func (n *ConfigNode) startElement(parentNS string, top bool) (xml.StartElement, string, error) {
+	var attrs []xml.Attr
+	ns := parentNS
+	if n.namespace != "" && n.namespace != parentNS {
+		ns = n.namespace
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: ns})
+	}
+	if top {
+		// Declare the attribute prefixes once, on the top-level node.
+		usesOp, usesInsert := n.usesAttributes()
+		if usesOp {
+			attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "xmlns:nc"}, Value: common.NetconfNS})
+		}
+		if usesInsert {
+			attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "xmlns:yang"}, Value: YangNS})
+		}
+	}
+	if n.operation != "" {
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "nc:operation"}, Value: n.operation})
+	}
+	if n.insert != "" {
+		switch n.kind {
+		case listNode:
+			if n.point != "" {
+				return xml.StartElement{}, "", fmt.Errorf("config node %s: the insert point of a list entry is set by InsertKey", n.name)
+			}
+			attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "yang:insert"}, Value: n.insert})
+			if len(n.pointKeys) > 0 {
+				predicate, err := n.keyPredicate(ns)
+				if err != nil {
+					return xml.StartElement{}, "", err
+				}
+				if ns != "" {
+					attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "xmlns:" + keyPrefix}, Value: ns})
+				}
+				attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "yang:key"}, Value: predicate})
+			}
+		case leafListNode:
+			attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "yang:insert"}, Value: n.insert})
+			if n.point != "" {
+				attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "yang:value"}, Value: n.point})
+			}
+		default:
+			return xml.StartElement{}, "", fmt.Errorf("config node %s: insert only applies to list and leaf-list entries", n.name)
+		}
+	}
+
+	return xml.StartElement{Name: xml.Name{Local: n.name}, Attr: attrs}, ns, nil
+}
+
+// keyPrefix is the prefix declared for the namespace of a list, on an entry whose insert point is identified by
+// its key leaves.
+const keyPrefix = "key"
+
+// keyPredicate delivers the predicate that identifies the list entry at the insert point, e.g. [key:name='eth0'],
+// whose prefix, if the list has a namespace, is keyPrefix.
+func (n *ConfigNode) keyPredicate(ns string) (string, error) {
+	var b strings.Builder
+	for _, k := range n.pointKeys {
+		if k.kind != leafNode {
+			return "", fmt.Errorf("config node %s: the insert point must be identified by key leaves", n.name)
+		}
+		value := k.values[0]
+		quote := "'"
+		if strings.Contains(value, quote) {
+			quote = `"`
+			if strings.Contains(value, quote) {
+				return "", fmt.Errorf("config node %s: key %s cannot be quoted", n.name, k.name)
+			}
+		}
+		b.WriteString("[")
+		if ns != "" {
+			b.WriteString(keyPrefix + ":")
+		}
+		b.WriteString(k.name + "=" + quote + value + quote + "]")
+	}
+	return b.String(), nil
+}
+
+// usesAttributes reports whether the node, or any of its descendants, has an operation or an insert position.
+func (n *ConfigNode) usesAttributes() (usesOp, usesInsert bool) {
+	usesOp, usesInsert = n.operation != "", n.insert != ""
+	for _, c := range append(append([]*ConfigNode{}, n.keys...), n.children...) {
+		op, insert := c.usesAttributes()
+		usesOp, usesInsert = usesOp || op, usesInsert || insert
+	}
+	return
+}
@@ -0,0 +1,96 @@
+package ops
+
+import (
+	"context"
+	"encoding/xml"
+	"testing"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+const (
+	ifNS = "urn:ietf:params:xml:ns:yang:ietf-interfaces"
+	ipNS = "urn:ietf:params:xml:ns:yang:ietf-ip"
+)
+
+func TestConfigTree(t *testing.T) {
+
+	tree := CfgTree(
+		Container("interfaces",
+			ListEntry("interface", Leaf("name", "eth0")).Add(
+				Leaf("description", "uplink <a&b>"),
+				Leaf("mtu", "").Op(DeleteOp),
+				Container("ipv4").NS(ipNS).Add(
+					ListEntry("address", Leaf("ip", "192.0.2.1")).Op(CreateOp).Add(Leaf("prefix-length", "24")),
+				),
+			),
+			ListEntry("interface", Leaf("name", "eth1")).Op(RemoveOp),
+		).NS(ifNS),
+		Container("system").NS("urn:sys").Op(ReplaceOp).Add(LeafList("dns", "192.0.2.53", "192.0.2.54")),
+	)
+
+	req, err := xml.Marshal(createEditConfigRequest(RunningCfg, tree))
+	assert.NoError(t, err, "Not expecting marshal to fail")
+	assert.Equal(t, `<edit-config><target><running/></target><config>`+
+		`<interfaces xmlns="urn:ietf:params:xml:ns:yang:ietf-interfaces" xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0">`+
+		`<interface><name>eth0</name><description>uplink &lt;a&amp;b&gt;</description><mtu nc:operation="delete"></mtu>`+
+		`<ipv4 xmlns="urn:ietf:params:xml:ns:yang:ietf-ip"><address nc:operation="create"><ip>192.0.2.1</ip><prefix-length>24</prefix-length></address></ipv4>`+
+		`</interface>`+
+		`<interface nc:operation="remove"><name>eth1</name></interface>`+
+		`</interfaces>`+
+		`<system xmlns="urn:sys" xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0" nc:operation="replace"><dns>192.0.2.53</dns><dns>192.0.2.54</dns></system>`+
+		`</config></edit-config>`, string(req), "Expected edit-config request")
+}
+
+func TestConfigTreeInsert(t *testing.T) {
+
+	tree := CfgTree(
+		Container("system").NS("urn:sys").Add(
+			ListEntry("user", Leaf("name", "bob")).InsertKey(InsertAfter, Leaf("name", "alice")),
+			ListEntry("user", Leaf("name", "carol")).Insert(InsertFirst, ""),
+			LeafList("search", "example.org").Insert(InsertBefore, "example.com").Op(CreateOp),
+		),
+	)
+
+	req, err := xml.Marshal(createEditConfigRequest(CandidateCfg, tree))
+	assert.NoError(t, err, "Not expecting marshal to fail")
+	assert.Equal(t, `<edit-config><target><candidate/></target><config>`+
+		`<system xmlns="urn:sys" xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0" xmlns:yang="urn:ietf:params:xml:ns:yang:1">`+
+		`<user yang:insert="after" xmlns:key="urn:sys" yang:key="[key:name=&#39;alice&#39;]"><name>bob</name></user>`+
+		`<user yang:insert="first"><name>carol</name></user>`+
+		`<search nc:operation="create" yang:insert="before" yang:value="example.com">example.org</search>`+
+		`</system></config></edit-config>`, string(req), "Expected edit-config request")
+
+	req, err = xml.Marshal(ListEntry("route", Leaf("prefix", "0.0.0.0/0")).
+		InsertKey(InsertBefore, Leaf("prefix", "10.0.0.0/8"), Leaf("descr", "bob's")))
+	assert.NoError(t, err, "Not expecting marshal to fail")
+	assert.Equal(t, `<route xmlns:yang="urn:ietf:params:xml:ns:yang:1" yang:insert="before" `+
+		`yang:key="[prefix=&#39;10.0.0.0/8&#39;][descr=&#34;bob&#39;s&#34;]"><prefix>0.0.0.0/0</prefix></route>`,
+		string(req), "Expected unqualified key predicate")
+
+	_, err = xml.Marshal(createEditConfigRequest(CandidateCfg, CfgTree(Container("system").Insert(InsertFirst, ""))))
+	assert.EqualError(t, err, "config node system: insert only applies to list and leaf-list entries", "Expected invalid insert")
+
+	_, err = xml.Marshal(ListEntry("user", Leaf("name", "bob")).Insert(InsertAfter, `[name='alice']`))
+	assert.EqualError(t, err, "config node user: the insert point of a list entry is set by InsertKey", "Expected invalid insert point")
+}
+
+func TestEditConfigTree(t *testing.T) {
+
+	tree := Container("top").NS("urn:tns").Add(Leaf("leaf", "value").Op(MergeOp))
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("ExecuteContext", context.Background(), createEditConfigRequest(CandidateCfg, Cfg(tree))).Return(&common.RPCReply{}, nil)
+
+	err := ncs.EditConfigCfg(CandidateCfg, tree)
+	assert.NoError(t, err, "Not expecting call to fail")
+
+	req, _ := xml.Marshal(createEditConfigRequest(CandidateCfg, Cfg(tree)))
+	assert.Equal(t, `<edit-config><target><candidate/></target><config>`+
+		`<top xmlns="urn:tns" xmlns:nc="urn:ietf:params:xml:ns:netconf:base:1.0"><leaf nc:operation="merge">value</leaf></top>`+
+		`</config></edit-config>`, string(req), "Expected edit-config request")
+
+	mcli.AssertExpectations(t)
+}
@@ -3,6 +3,9 @@
 package mocks
 
 import (
+	context "context"
+
+	client "github.com/damianoneill/net/v2/netconf/client"
 	common "github.com/damianoneill/net/v2/netconf/common"
 	mock "github.com/stretchr/testify/mock"
 
@@ -14,18 +17,887 @@ type OpSession struct {
 	mock.Mock
 }
 
+// Call provides a mock function with given fields: ctx, req, resp
+func (_m *OpSession) Call(ctx context.Context, req common.Request, resp interface{}) error {
+	ret := _m.Called(ctx, req, resp)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, common.Request, interface{}) error); ok {
+		r0 = rf(ctx, req, resp)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CancelCommit provides a mock function with given fields: persistID
+func (_m *OpSession) CancelCommit(persistID string) error {
+	ret := _m.Called(persistID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(persistID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CancelCommitContext provides a mock function with given fields: ctx, persistID
+func (_m *OpSession) CancelCommitContext(ctx context.Context, persistID string) error {
+	ret := _m.Called(ctx, persistID)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, persistID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Capabilities provides a mock function with given fields:
+func (_m *OpSession) Capabilities() *common.Capabilities {
+	ret := _m.Called()
+
+	var r0 *common.Capabilities
+	if rf, ok := ret.Get(0).(func() *common.Capabilities); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*common.Capabilities)
+		}
+	}
+
+	return r0
+}
+
 // Close provides a mock function with given fields:
 func (_m *OpSession) Close() {
 	_m.Called()
 }
 
-// CloseSession provides a mock function with given fields:
-func (_m *OpSession) CloseSession() error {
-	ret := _m.Called()
+// CloseSession provides a mock function with given fields:
+func (_m *OpSession) CloseSession() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CloseSessionContext provides a mock function with given fields: ctx
+func (_m *OpSession) CloseSessionContext(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Commit provides a mock function with given fields: options
+func (_m *OpSession) Commit(options ...ops.CommitOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(...ops.CommitOption) error); ok {
+		r0 = rf(options...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CommitContext provides a mock function with given fields: ctx, options
+func (_m *OpSession) CommitContext(ctx context.Context, options ...ops.CommitOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, ...ops.CommitOption) error); ok {
+		r0 = rf(ctx, options...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CopyConfig provides a mock function with given fields: source, target, options
+func (_m *OpSession) CopyConfig(source ops.CfgDsOpt, target ops.CfgDsOpt, options ...ops.RetrievalOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, source, target)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(ops.CfgDsOpt, ops.CfgDsOpt, ...ops.RetrievalOption) error); ok {
+		r0 = rf(source, target, options...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CopyConfigContext provides a mock function with given fields: ctx, source, target, options
+func (_m *OpSession) CopyConfigContext(ctx context.Context, source ops.CfgDsOpt, target ops.CfgDsOpt, options ...ops.RetrievalOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, source, target)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, ops.CfgDsOpt, ops.CfgDsOpt, ...ops.RetrievalOption) error); ok {
+		r0 = rf(ctx, source, target, options...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateSubscription provides a mock function with given fields: nchan, options
+func (_m *OpSession) CreateSubscription(nchan chan *common.Notification, options ...ops.SubscriptionOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, nchan)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(chan *common.Notification, ...ops.SubscriptionOption) error); ok {
+		r0 = rf(nchan, options...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// CreateSubscriptionContext provides a mock function with given fields: ctx, nchan, options
+func (_m *OpSession) CreateSubscriptionContext(ctx context.Context, nchan chan *common.Notification, options ...ops.SubscriptionOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, nchan)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, chan *common.Notification, ...ops.SubscriptionOption) error); ok {
+		r0 = rf(ctx, nchan, options...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteConfig provides a mock function with given fields: target
+func (_m *OpSession) DeleteConfig(target ops.CfgDsOpt) error {
+	ret := _m.Called(target)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(ops.CfgDsOpt) error); ok {
+		r0 = rf(target)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteConfigContext provides a mock function with given fields: ctx, target
+func (_m *OpSession) DeleteConfigContext(ctx context.Context, target ops.CfgDsOpt) error {
+	ret := _m.Called(ctx, target)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, ops.CfgDsOpt) error); ok {
+		r0 = rf(ctx, target)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteSubscription provides a mock function with given fields: id
+func (_m *OpSession) DeleteSubscription(id string) error {
+	ret := _m.Called(id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DeleteSubscriptionContext provides a mock function with given fields: ctx, id
+func (_m *OpSession) DeleteSubscriptionContext(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Discard provides a mock function with given fields:
+func (_m *OpSession) Discard() error {
+	ret := _m.Called()
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func() error); ok {
+		r0 = rf()
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// DiscardContext provides a mock function with given fields: ctx
+func (_m *OpSession) DiscardContext(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EditConfig provides a mock function with given fields: target, config, options
+func (_m *OpSession) EditConfig(target string, config ops.ConfigOption, options ...ops.EditOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, target, config)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, ops.ConfigOption, ...ops.EditOption) error); ok {
+		r0 = rf(target, config, options...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EditConfigCfg provides a mock function with given fields: target, config, options
+func (_m *OpSession) EditConfigCfg(target string, config interface{}, options ...ops.EditOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, target, config)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, interface{}, ...ops.EditOption) error); ok {
+		r0 = rf(target, config, options...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EditConfigCfgContext provides a mock function with given fields: ctx, target, config, options
+func (_m *OpSession) EditConfigCfgContext(ctx context.Context, target string, config interface{}, options ...ops.EditOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, target, config)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, interface{}, ...ops.EditOption) error); ok {
+		r0 = rf(ctx, target, config, options...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EditConfigContext provides a mock function with given fields: ctx, target, config, options
+func (_m *OpSession) EditConfigContext(ctx context.Context, target string, config ops.ConfigOption, options ...ops.EditOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, target, config)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, ops.ConfigOption, ...ops.EditOption) error); ok {
+		r0 = rf(ctx, target, config, options...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EditData provides a mock function with given fields: datastore, config, options
+func (_m *OpSession) EditData(datastore string, config ops.ConfigOption, options ...ops.EditOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, datastore, config)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, ops.ConfigOption, ...ops.EditOption) error); ok {
+		r0 = rf(datastore, config, options...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EditDataContext provides a mock function with given fields: ctx, datastore, config, options
+func (_m *OpSession) EditDataContext(ctx context.Context, datastore string, config ops.ConfigOption, options ...ops.EditOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, datastore, config)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, ops.ConfigOption, ...ops.EditOption) error); ok {
+		r0 = rf(ctx, datastore, config, options...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// EstablishSubscription provides a mock function with given fields: nchan, options
+func (_m *OpSession) EstablishSubscription(nchan chan *common.Notification, options ...ops.PushOption) (string, error) {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, nchan)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(chan *common.Notification, ...ops.PushOption) string); ok {
+		r0 = rf(nchan, options...)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(chan *common.Notification, ...ops.PushOption) error); ok {
+		r1 = rf(nchan, options...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// EstablishSubscriptionContext provides a mock function with given fields: ctx, nchan, options
+func (_m *OpSession) EstablishSubscriptionContext(ctx context.Context, nchan chan *common.Notification, options ...ops.PushOption) (string, error) {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, nchan)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, chan *common.Notification, ...ops.PushOption) string); ok {
+		r0 = rf(ctx, nchan, options...)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, chan *common.Notification, ...ops.PushOption) error); ok {
+		r1 = rf(ctx, nchan, options...)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Execute provides a mock function with given fields: req
+func (_m *OpSession) Execute(req common.Request) (*common.RPCReply, error) {
+	ret := _m.Called(req)
+
+	var r0 *common.RPCReply
+	if rf, ok := ret.Get(0).(func(common.Request) *common.RPCReply); ok {
+		r0 = rf(req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*common.RPCReply)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(common.Request) error); ok {
+		r1 = rf(req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExecuteAsync provides a mock function with given fields: req, rchan
+func (_m *OpSession) ExecuteAsync(req common.Request, rchan chan *common.RPCReply) error {
+	ret := _m.Called(req, rchan)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(common.Request, chan *common.RPCReply) error); ok {
+		r0 = rf(req, rchan)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ExecuteContext provides a mock function with given fields: ctx, req
+func (_m *OpSession) ExecuteContext(ctx context.Context, req common.Request) (*common.RPCReply, error) {
+	ret := _m.Called(ctx, req)
+
+	var r0 *common.RPCReply
+	if rf, ok := ret.Get(0).(func(context.Context, common.Request) *common.RPCReply); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*common.RPCReply)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, common.Request) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// ExecuteStream provides a mock function with given fields: ctx, req, handler
+func (_m *OpSession) ExecuteStream(ctx context.Context, req common.Request, handler client.DataHandler) (*common.RPCReply, error) {
+	ret := _m.Called(ctx, req, handler)
+
+	var r0 *common.RPCReply
+	if rf, ok := ret.Get(0).(func(context.Context, common.Request, client.DataHandler) *common.RPCReply); ok {
+		r0 = rf(ctx, req, handler)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*common.RPCReply)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, common.Request, client.DataHandler) error); ok {
+		r1 = rf(ctx, req, handler)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetConfigSubtree provides a mock function with given fields: filter, source, result, options
+func (_m *OpSession) GetConfigSubtree(filter interface{}, source string, result interface{}, options ...ops.RetrievalOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, filter, source, result)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(interface{}, string, interface{}, ...ops.RetrievalOption) error); ok {
+		r0 = rf(filter, source, result, options...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetConfigSubtreeContext provides a mock function with given fields: ctx, filter, source, result, options
+func (_m *OpSession) GetConfigSubtreeContext(ctx context.Context, filter interface{}, source string, result interface{}, options ...ops.RetrievalOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, filter, source, result)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}, string, interface{}, ...ops.RetrievalOption) error); ok {
+		r0 = rf(ctx, filter, source, result, options...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetConfigSubtreeStream provides a mock function with given fields: ctx, filter, source, handler, options
+func (_m *OpSession) GetConfigSubtreeStream(ctx context.Context, filter interface{}, source string, handler client.DataHandler, options ...ops.RetrievalOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, filter, source, handler)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}, string, client.DataHandler, ...ops.RetrievalOption) error); ok {
+		r0 = rf(ctx, filter, source, handler, options...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetConfigXpath provides a mock function with given fields: xpath, nslist, source, result, options
+func (_m *OpSession) GetConfigXpath(xpath string, nslist []ops.Namespace, source string, result interface{}, options ...ops.RetrievalOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, xpath, nslist, source, result)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, []ops.Namespace, string, interface{}, ...ops.RetrievalOption) error); ok {
+		r0 = rf(xpath, nslist, source, result, options...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetConfigXpathContext provides a mock function with given fields: ctx, xpath, nslist, source, result, options
+func (_m *OpSession) GetConfigXpathContext(ctx context.Context, xpath string, nslist []ops.Namespace, source string, result interface{}, options ...ops.RetrievalOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, xpath, nslist, source, result)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, []ops.Namespace, string, interface{}, ...ops.RetrievalOption) error); ok {
+		r0 = rf(ctx, xpath, nslist, source, result, options...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetConfigXpathFilter provides a mock function with given fields: ctx, filter, source, result, options
+func (_m *OpSession) GetConfigXpathFilter(ctx context.Context, filter *ops.XPathFilter, source string, result interface{}, options ...ops.RetrievalOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, filter, source, result)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, *ops.XPathFilter, string, interface{}, ...ops.RetrievalOption) error); ok {
+		r0 = rf(ctx, filter, source, result, options...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetData provides a mock function with given fields: datastore, result, options
+func (_m *OpSession) GetData(datastore string, result interface{}, options ...ops.GetDataOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, datastore, result)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string, interface{}, ...ops.GetDataOption) error); ok {
+		r0 = rf(datastore, result, options...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetDataContext provides a mock function with given fields: ctx, datastore, result, options
+func (_m *OpSession) GetDataContext(ctx context.Context, datastore string, result interface{}, options ...ops.GetDataOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, datastore, result)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, interface{}, ...ops.GetDataOption) error); ok {
+		r0 = rf(ctx, datastore, result, options...)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// GetSchema provides a mock function with given fields: id, version, fmt
+func (_m *OpSession) GetSchema(id string, version string, fmt string) (string, error) {
+	ret := _m.Called(id, version, fmt)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(string, string, string) string); ok {
+		r0 = rf(id, version, fmt)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
+		r1 = rf(id, version, fmt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSchemaContext provides a mock function with given fields: ctx, id, version, fmt
+func (_m *OpSession) GetSchemaContext(ctx context.Context, id string, version string, fmt string) (string, error) {
+	ret := _m.Called(ctx, id, version, fmt)
+
+	var r0 string
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string) string); ok {
+		r0 = rf(ctx, id, version, fmt)
+	} else {
+		r0 = ret.Get(0).(string)
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string) error); ok {
+		r1 = rf(ctx, id, version, fmt)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSchemas provides a mock function with given fields:
+func (_m *OpSession) GetSchemas() ([]ops.Schema, error) {
+	ret := _m.Called()
+
+	var r0 []ops.Schema
+	if rf, ok := ret.Get(0).(func() []ops.Schema); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]ops.Schema)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSchemasContext provides a mock function with given fields: ctx
+func (_m *OpSession) GetSchemasContext(ctx context.Context) ([]ops.Schema, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []ops.Schema
+	if rf, ok := ret.Get(0).(func(context.Context) []ops.Schema); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]ops.Schema)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStreams provides a mock function with given fields:
+func (_m *OpSession) GetStreams() ([]ops.Stream, error) {
+	ret := _m.Called()
+
+	var r0 []ops.Stream
+	if rf, ok := ret.Get(0).(func() []ops.Stream); ok {
+		r0 = rf()
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]ops.Stream)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func() error); ok {
+		r1 = rf()
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetStreamsContext provides a mock function with given fields: ctx
+func (_m *OpSession) GetStreamsContext(ctx context.Context) ([]ops.Stream, error) {
+	ret := _m.Called(ctx)
+
+	var r0 []ops.Stream
+	if rf, ok := ret.Get(0).(func(context.Context) []ops.Stream); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]ops.Stream)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// GetSubtree provides a mock function with given fields: filter, result, options
+func (_m *OpSession) GetSubtree(filter interface{}, result interface{}, options ...ops.RetrievalOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, filter, result)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func() error); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(interface{}, interface{}, ...ops.RetrievalOption) error); ok {
+		r0 = rf(filter, result, options...)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -33,13 +905,20 @@ func (_m *OpSession) CloseSession() error {
 	return r0
 }
 
-// CopyConfig provides a mock function with given fields: source, target
-func (_m *OpSession) CopyConfig(source ops.CfgDsOpt, target ops.CfgDsOpt) error {
-	ret := _m.Called(source, target)
+// GetSubtreeContext provides a mock function with given fields: ctx, filter, result, options
+func (_m *OpSession) GetSubtreeContext(ctx context.Context, filter interface{}, result interface{}, options ...ops.RetrievalOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, filter, result)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(ops.CfgDsOpt, ops.CfgDsOpt) error); ok {
-		r0 = rf(source, target)
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}, interface{}, ...ops.RetrievalOption) error); ok {
+		r0 = rf(ctx, filter, result, options...)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -47,13 +926,20 @@ func (_m *OpSession) CopyConfig(source ops.CfgDsOpt, target ops.CfgDsOpt) error
 	return r0
 }
 
-// DeleteConfig provides a mock function with given fields: target
-func (_m *OpSession) DeleteConfig(target ops.CfgDsOpt) error {
-	ret := _m.Called(target)
+// GetSubtreeStream provides a mock function with given fields: ctx, filter, handler, options
+func (_m *OpSession) GetSubtreeStream(ctx context.Context, filter interface{}, handler client.DataHandler, options ...ops.RetrievalOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, filter, handler)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(ops.CfgDsOpt) error); ok {
-		r0 = rf(target)
+	if rf, ok := ret.Get(0).(func(context.Context, interface{}, client.DataHandler, ...ops.RetrievalOption) error); ok {
+		r0 = rf(ctx, filter, handler, options...)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -61,13 +947,20 @@ func (_m *OpSession) DeleteConfig(target ops.CfgDsOpt) error {
 	return r0
 }
 
-// Discard provides a mock function with given fields:
-func (_m *OpSession) Discard() error {
-	ret := _m.Called()
+// GetXpath provides a mock function with given fields: xpath, nslist, result, options
+func (_m *OpSession) GetXpath(xpath string, nslist []ops.Namespace, result interface{}, options ...ops.RetrievalOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, xpath, nslist, result)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func() error); ok {
-		r0 = rf()
+	if rf, ok := ret.Get(0).(func(string, []ops.Namespace, interface{}, ...ops.RetrievalOption) error); ok {
+		r0 = rf(xpath, nslist, result, options...)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -75,20 +968,20 @@ func (_m *OpSession) Discard() error {
 	return r0
 }
 
-// EditConfig provides a mock function with given fields: target, config, options
-func (_m *OpSession) EditConfig(target string, config ops.ConfigOption, options ...ops.EditOption) error {
+// GetXpathContext provides a mock function with given fields: ctx, xpath, nslist, result, options
+func (_m *OpSession) GetXpathContext(ctx context.Context, xpath string, nslist []ops.Namespace, result interface{}, options ...ops.RetrievalOption) error {
 	_va := make([]interface{}, len(options))
 	for _i := range options {
 		_va[_i] = options[_i]
 	}
 	var _ca []interface{}
-	_ca = append(_ca, target, config)
+	_ca = append(_ca, ctx, xpath, nslist, result)
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, ops.ConfigOption, ...ops.EditOption) error); ok {
-		r0 = rf(target, config, options...)
+	if rf, ok := ret.Get(0).(func(context.Context, string, []ops.Namespace, interface{}, ...ops.RetrievalOption) error); ok {
+		r0 = rf(ctx, xpath, nslist, result, options...)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -96,20 +989,20 @@ func (_m *OpSession) EditConfig(target string, config ops.ConfigOption, options
 	return r0
 }
 
-// EditConfigCfg provides a mock function with given fields: target, config, options
-func (_m *OpSession) EditConfigCfg(target string, config interface{}, options ...ops.EditOption) error {
+// GetXpathFilter provides a mock function with given fields: ctx, filter, result, options
+func (_m *OpSession) GetXpathFilter(ctx context.Context, filter *ops.XPathFilter, result interface{}, options ...ops.RetrievalOption) error {
 	_va := make([]interface{}, len(options))
 	for _i := range options {
 		_va[_i] = options[_i]
 	}
 	var _ca []interface{}
-	_ca = append(_ca, target, config)
+	_ca = append(_ca, ctx, filter, result)
 	_ca = append(_ca, _va...)
 	ret := _m.Called(_ca...)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, interface{}, ...ops.EditOption) error); ok {
-		r0 = rf(target, config, options...)
+	if rf, ok := ret.Get(0).(func(context.Context, *ops.XPathFilter, interface{}, ...ops.RetrievalOption) error); ok {
+		r0 = rf(ctx, filter, result, options...)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -117,36 +1010,27 @@ func (_m *OpSession) EditConfigCfg(target string, config interface{}, options ..
 	return r0
 }
 
-// Execute provides a mock function with given fields: req
-func (_m *OpSession) Execute(req common.Request) (*common.RPCReply, error) {
-	ret := _m.Called(req)
-
-	var r0 *common.RPCReply
-	if rf, ok := ret.Get(0).(func(common.Request) *common.RPCReply); ok {
-		r0 = rf(req)
-	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).(*common.RPCReply)
-		}
-	}
+// ID provides a mock function with given fields:
+func (_m *OpSession) ID() uint64 {
+	ret := _m.Called()
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(common.Request) error); ok {
-		r1 = rf(req)
+	var r0 uint64
+	if rf, ok := ret.Get(0).(func() uint64); ok {
+		r0 = rf()
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Get(0).(uint64)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// ExecuteAsync provides a mock function with given fields: req, rchan
-func (_m *OpSession) ExecuteAsync(req common.Request, rchan chan *common.RPCReply) error {
-	ret := _m.Called(req, rchan)
+// KillSession provides a mock function with given fields: id
+func (_m *OpSession) KillSession(id uint64) error {
+	ret := _m.Called(id)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(common.Request, chan *common.RPCReply) error); ok {
-		r0 = rf(req, rchan)
+	if rf, ok := ret.Get(0).(func(uint64) error); ok {
+		r0 = rf(id)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -154,13 +1038,13 @@ func (_m *OpSession) ExecuteAsync(req common.Request, rchan chan *common.RPCRepl
 	return r0
 }
 
-// GetConfigSubtree provides a mock function with given fields: filter, source, result
-func (_m *OpSession) GetConfigSubtree(filter interface{}, source string, result interface{}) error {
-	ret := _m.Called(filter, source, result)
+// KillSessionContext provides a mock function with given fields: ctx, id
+func (_m *OpSession) KillSessionContext(ctx context.Context, id uint64) error {
+	ret := _m.Called(ctx, id)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(interface{}, string, interface{}) error); ok {
-		r0 = rf(filter, source, result)
+	if rf, ok := ret.Get(0).(func(context.Context, uint64) error); ok {
+		r0 = rf(ctx, id)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -168,13 +1052,13 @@ func (_m *OpSession) GetConfigSubtree(filter interface{}, source string, result
 	return r0
 }
 
-// GetConfigXpath provides a mock function with given fields: xpath, nslist, source, result
-func (_m *OpSession) GetConfigXpath(xpath string, nslist []ops.Namespace, source string, result interface{}) error {
-	ret := _m.Called(xpath, nslist, source, result)
+// KillSubscription provides a mock function with given fields: id
+func (_m *OpSession) KillSubscription(id string) error {
+	ret := _m.Called(id)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, []ops.Namespace, string, interface{}) error); ok {
-		r0 = rf(xpath, nslist, source, result)
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(id)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -182,57 +1066,62 @@ func (_m *OpSession) GetConfigXpath(xpath string, nslist []ops.Namespace, source
 	return r0
 }
 
-// GetSchema provides a mock function with given fields: id, version, fmt
-func (_m *OpSession) GetSchema(id string, version string, fmt string) (string, error) {
-	ret := _m.Called(id, version, fmt)
-
-	var r0 string
-	if rf, ok := ret.Get(0).(func(string, string, string) string); ok {
-		r0 = rf(id, version, fmt)
-	} else {
-		r0 = ret.Get(0).(string)
-	}
+// KillSubscriptionContext provides a mock function with given fields: ctx, id
+func (_m *OpSession) KillSubscriptionContext(ctx context.Context, id string) error {
+	ret := _m.Called(ctx, id)
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func(string, string, string) error); ok {
-		r1 = rf(id, version, fmt)
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, id)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// GetSchemas provides a mock function with given fields:
-func (_m *OpSession) GetSchemas() ([]ops.Schema, error) {
-	ret := _m.Called()
+// Lock provides a mock function with given fields: target
+func (_m *OpSession) Lock(target string) error {
+	ret := _m.Called(target)
 
-	var r0 []ops.Schema
-	if rf, ok := ret.Get(0).(func() []ops.Schema); ok {
-		r0 = rf()
+	var r0 error
+	if rf, ok := ret.Get(0).(func(string) error); ok {
+		r0 = rf(target)
 	} else {
-		if ret.Get(0) != nil {
-			r0 = ret.Get(0).([]ops.Schema)
-		}
+		r0 = ret.Error(0)
 	}
 
-	var r1 error
-	if rf, ok := ret.Get(1).(func() error); ok {
-		r1 = rf()
+	return r0
+}
+
+// LockContext provides a mock function with given fields: ctx, target
+func (_m *OpSession) LockContext(ctx context.Context, target string) error {
+	ret := _m.Called(ctx, target)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, target)
 	} else {
-		r1 = ret.Error(1)
+		r0 = ret.Error(0)
 	}
 
-	return r0, r1
+	return r0
 }
 
-// GetSubtree provides a mock function with given fields: filter, result
-func (_m *OpSession) GetSubtree(filter interface{}, result interface{}) error {
-	ret := _m.Called(filter, result)
+// ModifySubscription provides a mock function with given fields: id, options
+func (_m *OpSession) ModifySubscription(id string, options ...ops.PushOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, id)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(interface{}, interface{}) error); ok {
-		r0 = rf(filter, result)
+	if rf, ok := ret.Get(0).(func(string, ...ops.PushOption) error); ok {
+		r0 = rf(id, options...)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -240,13 +1129,20 @@ func (_m *OpSession) GetSubtree(filter interface{}, result interface{}) error {
 	return r0
 }
 
-// GetXpath provides a mock function with given fields: xpath, nslist, result
-func (_m *OpSession) GetXpath(xpath string, nslist []ops.Namespace, result interface{}) error {
-	ret := _m.Called(xpath, nslist, result)
+// ModifySubscriptionContext provides a mock function with given fields: ctx, id, options
+func (_m *OpSession) ModifySubscriptionContext(ctx context.Context, id string, options ...ops.PushOption) error {
+	_va := make([]interface{}, len(options))
+	for _i := range options {
+		_va[_i] = options[_i]
+	}
+	var _ca []interface{}
+	_ca = append(_ca, ctx, id)
+	_ca = append(_ca, _va...)
+	ret := _m.Called(_ca...)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string, []ops.Namespace, interface{}) error); ok {
-		r0 = rf(xpath, nslist, result)
+	if rf, ok := ret.Get(0).(func(context.Context, string, ...ops.PushOption) error); ok {
+		r0 = rf(ctx, id, options...)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -254,27 +1150,87 @@ func (_m *OpSession) GetXpath(xpath string, nslist []ops.Namespace, result inter
 	return r0
 }
 
-// ID provides a mock function with given fields:
-func (_m *OpSession) ID() uint64 {
+// NotificationCounters provides a mock function with given fields:
+func (_m *OpSession) NotificationCounters() client.NotificationCounters {
 	ret := _m.Called()
 
-	var r0 uint64
-	if rf, ok := ret.Get(0).(func() uint64); ok {
+	var r0 client.NotificationCounters
+	if rf, ok := ret.Get(0).(func() client.NotificationCounters); ok {
 		r0 = rf()
 	} else {
-		r0 = ret.Get(0).(uint64)
+		r0 = ret.Get(0).(client.NotificationCounters)
 	}
 
 	return r0
 }
 
-// KillSession provides a mock function with given fields: id
-func (_m *OpSession) KillSession(id uint64) error {
-	ret := _m.Called(id)
+// PartialLock provides a mock function with given fields: selectXPaths, nslist
+func (_m *OpSession) PartialLock(selectXPaths []string, nslist []ops.Namespace) (uint32, []string, error) {
+	ret := _m.Called(selectXPaths, nslist)
+
+	var r0 uint32
+	if rf, ok := ret.Get(0).(func([]string, []ops.Namespace) uint32); ok {
+		r0 = rf(selectXPaths, nslist)
+	} else {
+		r0 = ret.Get(0).(uint32)
+	}
+
+	var r1 []string
+	if rf, ok := ret.Get(1).(func([]string, []ops.Namespace) []string); ok {
+		r1 = rf(selectXPaths, nslist)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]string)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func([]string, []ops.Namespace) error); ok {
+		r2 = rf(selectXPaths, nslist)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// PartialLockContext provides a mock function with given fields: ctx, selectXPaths, nslist
+func (_m *OpSession) PartialLockContext(ctx context.Context, selectXPaths []string, nslist []ops.Namespace) (uint32, []string, error) {
+	ret := _m.Called(ctx, selectXPaths, nslist)
+
+	var r0 uint32
+	if rf, ok := ret.Get(0).(func(context.Context, []string, []ops.Namespace) uint32); ok {
+		r0 = rf(ctx, selectXPaths, nslist)
+	} else {
+		r0 = ret.Get(0).(uint32)
+	}
+
+	var r1 []string
+	if rf, ok := ret.Get(1).(func(context.Context, []string, []ops.Namespace) []string); ok {
+		r1 = rf(ctx, selectXPaths, nslist)
+	} else {
+		if ret.Get(1) != nil {
+			r1 = ret.Get(1).([]string)
+		}
+	}
+
+	var r2 error
+	if rf, ok := ret.Get(2).(func(context.Context, []string, []ops.Namespace) error); ok {
+		r2 = rf(ctx, selectXPaths, nslist)
+	} else {
+		r2 = ret.Error(2)
+	}
+
+	return r0, r1, r2
+}
+
+// PartialUnlock provides a mock function with given fields: lockID
+func (_m *OpSession) PartialUnlock(lockID uint32) error {
+	ret := _m.Called(lockID)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(uint64) error); ok {
-		r0 = rf(id)
+	if rf, ok := ret.Get(0).(func(uint32) error); ok {
+		r0 = rf(lockID)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -282,13 +1238,13 @@ func (_m *OpSession) KillSession(id uint64) error {
 	return r0
 }
 
-// Lock provides a mock function with given fields: target
-func (_m *OpSession) Lock(target string) error {
-	ret := _m.Called(target)
+// PartialUnlockContext provides a mock function with given fields: ctx, lockID
+func (_m *OpSession) PartialUnlockContext(ctx context.Context, lockID uint32) error {
+	ret := _m.Called(ctx, lockID)
 
 	var r0 error
-	if rf, ok := ret.Get(0).(func(string) error); ok {
-		r0 = rf(target)
+	if rf, ok := ret.Get(0).(func(context.Context, uint32) error); ok {
+		r0 = rf(ctx, lockID)
 	} else {
 		r0 = ret.Error(0)
 	}
@@ -335,6 +1291,52 @@ func (_m *OpSession) Subscribe(req common.Request, nchan chan *common.Notificati
 	return r0, r1
 }
 
+// SubscribeContext provides a mock function with given fields: ctx, req, nchan
+func (_m *OpSession) SubscribeContext(ctx context.Context, req common.Request, nchan chan *common.Notification) (*common.RPCReply, error) {
+	ret := _m.Called(ctx, req, nchan)
+
+	var r0 *common.RPCReply
+	if rf, ok := ret.Get(0).(func(context.Context, common.Request, chan *common.Notification) *common.RPCReply); ok {
+		r0 = rf(ctx, req, nchan)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*common.RPCReply)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, common.Request, chan *common.Notification) error); ok {
+		r1 = rf(ctx, req, nchan)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// SubscribeWithOptions provides a mock function with given fields: ctx, req, opts, nchan
+func (_m *OpSession) SubscribeWithOptions(ctx context.Context, req common.Request, opts *client.SubscriptionOptions, nchan chan *common.Notification) (*common.RPCReply, error) {
+	ret := _m.Called(ctx, req, opts, nchan)
+
+	var r0 *common.RPCReply
+	if rf, ok := ret.Get(0).(func(context.Context, common.Request, *client.SubscriptionOptions, chan *common.Notification) *common.RPCReply); ok {
+		r0 = rf(ctx, req, opts, nchan)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(*common.RPCReply)
+		}
+	}
+
+	var r1 error
+	if rf, ok := ret.Get(1).(func(context.Context, common.Request, *client.SubscriptionOptions, chan *common.Notification) error); ok {
+		r1 = rf(ctx, req, opts, nchan)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
 // Unlock provides a mock function with given fields: target
 func (_m *OpSession) Unlock(target string) error {
 	ret := _m.Called(target)
@@ -348,3 +1350,50 @@ func (_m *OpSession) Unlock(target string) error {
 
 	return r0
 }
+
+// UnlockContext provides a mock function with given fields: ctx, target
+func (_m *OpSession) UnlockContext(ctx context.Context, target string) error {
+	ret := _m.Called(ctx, target)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, target)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Unsubscribe provides a mock function with given fields: nchan
+func (_m *OpSession) Unsubscribe(nchan chan *common.Notification) {
+	_m.Called(nchan)
+}
+
+// Validate provides a mock function with given fields: source
+func (_m *OpSession) Validate(source ops.CfgDsOpt) error {
+	ret := _m.Called(source)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(ops.CfgDsOpt) error); ok {
+		r0 = rf(source)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// ValidateContext provides a mock function with given fields: ctx, source
+func (_m *OpSession) ValidateContext(ctx context.Context, source ops.CfgDsOpt) error {
+	ret := _m.Called(ctx, source)
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, ops.CfgDsOpt) error); ok {
+		r0 = rf(ctx, source)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
@@ -20,6 +20,11 @@ const (
 	ReplaceOp = "replace"
 	NoneOp    = "none"
 
+	// Edit Config Node Operations, in addition to MergeOp and ReplaceOp
+	CreateOp = "create"
+	DeleteOp = "delete"
+	RemoveOp = "remove"
+
 	// Edit Config Test Options
 	TestThenSetOpt = "test-then-set"
 	SetOpt         = "set"
@@ -32,12 +37,45 @@ type Data struct {
 	Content string      `xml:",innerxml"`
 }
 
+// schemaData holds the schema returned by get-schema, which is text for a yang schema, or xml for yin.
+type schemaData struct {
+	XMLName  xml.Name `xml:"data"`
+	Text     string   `xml:",chardata"`
+	Content  string   `xml:",innerxml"`
+	Elements []struct {
+		XMLName xml.Name
+	} `xml:",any"`
+}
+
 type Schema struct {
 	Identifier string `xml:"identifier"`
 	Version    string `xml:"version"`
 	Format     string `xml:"format"`
 	Namespace  string `xml:"namespace"`
-	Location   string `xml:"location"`
+	// Location holds the first of the Locations.
+	Location string `xml:"location"`
+	// Locations lists where the schema can be retrieved from: "NETCONF" if it is available through get-schema,
+	// or a URI.
+	Locations []string `xml:"-"`
+}
+
+// UnmarshalXML decodes a schema, including all of its locations.
+func (s *Schema) UnmarshalXML(d *xml.Decoder, start xml.StartElement) error {
+	// The schema alias does not define UnmarshalXML, and its location is hidden by the shallower Locations field.
+	type schema Schema
+	v := struct {
+		schema
+		Locations []string `xml:"location"`
+	}{}
+	if err := d.DecodeElement(&v, &start); err != nil {
+		return err
+	}
+	*s = Schema(v.schema)
+	s.Locations = v.Locations
+	if len(v.Locations) > 0 {
+		s.Location = v.Locations[0]
+	}
+	return nil
 }
 
 type NetconfState struct {
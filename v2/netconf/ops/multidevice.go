@@ -0,0 +1,229 @@
+package ops
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+
+	"github.com/imdario/mergo"
+	uuid "github.com/satori/go.uuid"
+)
+
+// Defines a two-phase configuration transaction across multiple devices, so that changes are applied to
+// every device, or none.
+//
+// Each phase is run on all devices concurrently, and must succeed on every device before the next phase starts:
+// - lock the candidate datastore and apply the edits,
+// - validate the candidate datastore (if the :validate capability is supported),
+// - issue a persistent confirmed commit, with a persist-id shared by all devices,
+// - verify the changes (if configured), and
+// - confirm the commit.
+// If any device fails, the confirmed commit is cancelled on every device where it was issued, and the changes are
+// discarded, before the datastores are unlocked. Note that if confirmation fails on a device after it has succeeded
+// on others, the confirmed changes cannot be cancelled.
+// Every device must support the :candidate and :confirmed-commit:1.1 capabilities.
+
+// DeviceChange defines the edits to be applied to a device by a multi-device transaction.
+type DeviceChange struct {
+	// Device identifies the device in progress reports and results, and must be unique within the transaction.
+	Device  string
+	Session OpSession
+	Edits   []Edit
+}
+
+// MultiDeviceConfig defines properties that configure multi-device transaction behaviour.
+type MultiDeviceConfig struct {
+	// ConfirmTimeout, Verify and CleanupTimeout are applied to each device as for a single device transaction.
+	TransactionConfig
+	// PersistID defines the persist-id of the confirmed commit. If empty, a unique id is generated.
+	PersistID string
+	// Progress, if defined, is called as each step is taken on each device, with err reporting whether it
+	// was successful. It may be called concurrently for different devices.
+	Progress func(device string, step TransactionStep, err error)
+}
+
+// DefaultMultiDeviceConfig defines the default multi-device transaction configuration.
+var DefaultMultiDeviceConfig = &MultiDeviceConfig{
+	TransactionConfig: TransactionConfig{
+		ConfirmTimeout: 600,
+		CleanupTimeout: DefaultTransactionConfig.CleanupTimeout,
+	},
+}
+
+// DeviceError reports the failure of a multi-device transaction on a device.
+type DeviceError struct {
+	Device string
+	Err    *TransactionError
+}
+
+func (e *DeviceError) Error() string {
+	return fmt.Sprintf("device %s: %v", e.Device, e.Err)
+}
+
+// Unwrap delivers the transaction error of the device.
+func (e *DeviceError) Unwrap() error {
+	return e.Err
+}
+
+// MultiDeviceResult reports the outcome of a multi-device transaction.
+type MultiDeviceResult struct {
+	// PersistID is the persist-id of the confirmed commit.
+	PersistID string
+	// Devices holds the result of the transaction on each device.
+	Devices map[string]*TransactionResult
+}
+
+// RunMultiDeviceTransaction applies the changes to all devices as a single unit, as described above.
+// It returns a result describing the steps taken on each device, and an error (of type *DeviceError) identifying
+// the first device that failed, if any. If a device is identified by more than one change, an error is returned
+// before any device is touched.
+func RunMultiDeviceTransaction(ctx context.Context, cfg *MultiDeviceConfig, changes ...DeviceChange) (*MultiDeviceResult, error) {
+
+	devices := make(map[string]bool)
+	for _, change := range changes {
+		if devices[change.Device] {
+			return nil, fmt.Errorf("multi-device transaction: device %s is defined more than once", change.Device)
+		}
+		devices[change.Device] = true
+	}
+
+	// Use supplied config, but apply any defaults to unspecified values.
+	var resolvedConfig MultiDeviceConfig = *cfg
+	_ = mergo.Merge(&resolvedConfig, DefaultMultiDeviceConfig)
+	if resolvedConfig.PersistID == "" {
+		resolvedConfig.PersistID = uuid.NewV4().String()
+	}
+
+	md := &multiDeviceTransaction{
+		cfg:    &resolvedConfig,
+		result: &MultiDeviceResult{PersistID: resolvedConfig.PersistID, Devices: make(map[string]*TransactionResult)},
+	}
+	for _, change := range changes {
+		md.add(ctx, change)
+	}
+
+	md.run()
+
+	for i, t := range md.transactions {
+		if t.result.Err != nil {
+			return md.result, &DeviceError{Device: changes[i].Device, Err: t.result.Err}
+		}
+	}
+	return md.result, nil
+}
+
+type multiDeviceTransaction struct {
+	cfg          *MultiDeviceConfig
+	result       *MultiDeviceResult
+	transactions []*transaction
+	edits        [][]Edit
+}
+
+func (md *multiDeviceTransaction) add(ctx context.Context, change DeviceChange) {
+	t := &transaction{
+		ctx:    ctx,
+		s:      change.Session,
+		cfg:    &md.cfg.TransactionConfig,
+		caps:   change.Session.Capabilities(),
+		result: &TransactionResult{},
+	}
+	if md.cfg.Progress != nil {
+		device := change.Device
+		t.progress = func(step TransactionStep, err error) {
+			md.cfg.Progress(device, step, err)
+		}
+	}
+	md.transactions = append(md.transactions, t)
+	md.edits = append(md.edits, change.Edits)
+	md.result.Devices[change.Device] = t.result
+}
+
+func (md *multiDeviceTransaction) run() {
+
+	persistID := md.cfg.PersistID
+	phases := []func(i int, t *transaction) bool{
+		func(i int, t *transaction) bool {
+			return t.editCandidate(md.edits[i])
+		},
+		func(i int, t *transaction) bool {
+			return t.validateCandidate()
+		},
+		func(i int, t *transaction) bool {
+			if !t.do(StepCommit, func(ctx context.Context) error {
+				return t.s.CommitContext(ctx, ConfirmTimeout(t.cfg.ConfirmTimeout), Persist(persistID))
+			}) {
+				return false
+			}
+			t.deferStep(StepCancel, true, func(ctx context.Context) error { return t.s.CancelCommitContext(ctx, persistID) })
+			return true
+		},
+		func(i int, t *transaction) bool {
+			return t.verify()
+		},
+		func(i int, t *transaction) bool {
+			if !t.do(StepConfirm, func(ctx context.Context) error { return t.s.CommitContext(ctx, PersistID(persistID)) }) {
+				return false
+			}
+			// The confirmed changes can no longer be cancelled.
+			t.dropDeferred(StepCancel)
+			return true
+		},
+	}
+
+	failed := !md.checkCapabilities()
+	for _, phase := range phases {
+		if failed {
+			break
+		}
+		failed = !md.runPhase(phase)
+	}
+
+	var wg sync.WaitGroup
+	for _, t := range md.transactions {
+		wg.Add(1)
+		go func(t *transaction) {
+			defer wg.Done()
+			t.cleanup(failed)
+		}(t)
+	}
+	wg.Wait()
+}
+
+// checkCapabilities verifies that every device supports the capabilities required by the transaction.
+func (md *multiDeviceTransaction) checkCapabilities() bool {
+	ok := true
+	for _, t := range md.transactions {
+		switch {
+		case !t.caps.Supports(common.CapCandidate):
+			t.fail(StepEdit, fmt.Errorf("%w: requires capability %s", ErrNotSupported, common.CapCandidate))
+			ok = false
+		case !t.caps.Supports(common.CapConfirmedCommit):
+			t.fail(StepCommit, fmt.Errorf("%w: requires capability %s", ErrNotSupported, common.CapConfirmedCommit))
+			ok = false
+		}
+	}
+	return ok
+}
+
+// runPhase runs the phase on all devices concurrently, and returns true if it succeeded on every device.
+func (md *multiDeviceTransaction) runPhase(phase func(i int, t *transaction) bool) bool {
+	results := make([]bool, len(md.transactions))
+	var wg sync.WaitGroup
+	for i, t := range md.transactions {
+		wg.Add(1)
+		go func(i int, t *transaction) {
+			defer wg.Done()
+			results[i] = phase(i, t)
+		}(i, t)
+	}
+	wg.Wait()
+
+	for _, ok := range results {
+		if !ok {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,143 @@
+package ops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+	"github.com/damianoneill/net/v2/netconf/testserver"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestMultiDeviceTransaction(t *testing.T) {
+
+	servers, changes := newMultiDeviceChanges(t, 3)
+	defer closeMultiDeviceChanges(servers, changes)
+
+	var lock sync.Mutex
+	progress := make(map[string][]TransactionStep)
+	cfg := &MultiDeviceConfig{
+		PersistID: "change-1",
+		Progress: func(device string, step TransactionStep, err error) {
+			lock.Lock()
+			defer lock.Unlock()
+			assert.NoError(t, err, "Not expecting step to fail")
+			progress[device] = append(progress[device], step)
+		},
+	}
+	result, err := RunMultiDeviceTransaction(context.Background(), cfg, changes...)
+	assert.NoError(t, err, "Not expecting transaction to fail")
+	assert.Equal(t, "change-1", result.PersistID, "Expected persist id")
+
+	for i, change := range changes {
+		assert.Equal(t, []TransactionStep{StepLock, StepEdit, StepValidate, StepCommit, StepConfirm}, result.Devices[change.Device].Completed, "Expected completed steps")
+		assert.Equal(t, []TransactionStep{StepLock, StepEdit, StepValidate, StepCommit, StepConfirm, StepUnlock}, progress[change.Device], "Expected progress")
+
+		sh := servers[i].SessionHandler(change.Session.ID())
+		assert.Equal(t, []string{"lock", "edit-config", "validate", "commit", "commit", "unlock"}, sh.ReqNames(), "Expected requests")
+		assert.Equal(t, `<confirmed/><confirm-timeout>600</confirm-timeout><persist>change-1</persist>`, sh.Reqs[3].Body, "Expected confirmed commit")
+		assert.Equal(t, `<persist-id>change-1</persist-id>`, sh.Reqs[4].Body, "Expected confirming commit")
+	}
+}
+
+func TestMultiDeviceTransactionValidateFailure(t *testing.T) {
+
+	servers, changes := newMultiDeviceChanges(t, 3, testserver.EchoRequestHandler, testserver.EchoRequestHandler, testserver.FailingRequestHandler)
+	defer closeMultiDeviceChanges(servers, changes)
+
+	result, err := RunMultiDeviceTransaction(context.Background(), &MultiDeviceConfig{}, changes...)
+	assert.Error(t, err, "Expecting transaction to fail")
+
+	var devErr *DeviceError
+	assert.True(t, errors.As(err, &devErr), "Expected device error")
+	assert.Equal(t, "device-2", devErr.Device, "Expected failing device")
+	assert.Equal(t, StepValidate, devErr.Err.Step, "Expected failing step")
+	var rpcErr *common.RPCError
+	assert.True(t, errors.As(err, &rpcErr), "Expected rpc-error")
+	assert.Equal(t, StepValidate, result.Devices["device-2"].FailedStep(), "Expected failing step")
+
+	for i, change := range changes {
+		assert.Equal(t, []string{"lock", "edit-config", "validate", "discard-changes", "unlock"},
+			servers[i].SessionHandler(change.Session.ID()).ReqNames(), "Expected changes to be discarded on every device")
+	}
+}
+
+func TestMultiDeviceTransactionCommitFailure(t *testing.T) {
+
+	servers, changes := newMultiDeviceChanges(t, 3,
+		testserver.EchoRequestHandler, testserver.EchoRequestHandler, testserver.EchoRequestHandler, testserver.FailingRequestHandler)
+	defer closeMultiDeviceChanges(servers, changes)
+
+	result, err := RunMultiDeviceTransaction(context.Background(), &MultiDeviceConfig{}, changes...)
+	assert.Error(t, err, "Expecting transaction to fail")
+	assert.Equal(t, StepCommit, result.Devices["device-2"].FailedStep(), "Expected failing step")
+
+	for i, change := range changes {
+		sh := servers[i].SessionHandler(change.Session.ID())
+		if i == 1 {
+			assert.Equal(t, []string{"lock", "edit-config", "validate", "commit", "discard-changes", "unlock"}, sh.ReqNames(), "Expected changes to be discarded")
+			continue
+		}
+		assert.Equal(t, []string{"lock", "edit-config", "validate", "commit", "cancel-commit", "discard-changes", "unlock"}, sh.ReqNames(), "Expected commit to be cancelled")
+		assert.Equal(t, fmt.Sprintf(`<persist-id>%s</persist-id>`, result.PersistID), sh.Reqs[4].Body, "Expected persistent commit to be cancelled")
+	}
+}
+
+func TestMultiDeviceTransactionNotSupported(t *testing.T) {
+
+	servers, changes := newMultiDeviceChanges(t, 2)
+	ts := testserver.NewTestNetconfServer(t).WithCapabilities([]string{common.CapBase10, common.CapBase11, common.CapCandidate})
+	servers = append(servers, ts)
+	changes = append(changes, DeviceChange{Device: "device-3", Session: newTransactionSession(t, ts), Edits: transactionEdits[:1]})
+	defer closeMultiDeviceChanges(servers, changes)
+
+	result, err := RunMultiDeviceTransaction(context.Background(), &MultiDeviceConfig{}, changes...)
+	assert.True(t, errors.Is(err, ErrNotSupported), "Expecting transaction to be unsupported")
+	assert.Equal(t, StepCommit, result.Devices["device-3"].FailedStep(), "Expected failing step")
+
+	for i, change := range changes {
+		assert.Equal(t, 0, servers[i].SessionHandler(change.Session.ID()).ReqCount(), "Not expecting requests")
+	}
+}
+
+func TestMultiDeviceTransactionDuplicateDevice(t *testing.T) {
+
+	servers, changes := newMultiDeviceChanges(t, 2)
+	defer closeMultiDeviceChanges(servers, changes)
+	changes[1].Device = changes[0].Device
+
+	result, err := RunMultiDeviceTransaction(context.Background(), &MultiDeviceConfig{}, changes...)
+	assert.EqualError(t, err, "multi-device transaction: device device-1 is defined more than once", "Expecting duplicate device to be rejected")
+	assert.Nil(t, result, "Not expecting a result")
+
+	for i, change := range changes {
+		assert.Equal(t, 0, servers[i].SessionHandler(change.Session.ID()).ReqCount(), "Not expecting requests")
+	}
+}
+
+// newMultiDeviceChanges creates test servers, and sessions to them, for a number of devices. The request handlers
+// are applied to the second device.
+func newMultiDeviceChanges(t *testing.T, count int, handlers ...testserver.RequestHandler) (servers []*testserver.TestNCServer, changes []DeviceChange) {
+	for i := 1; i <= count; i++ {
+		ts := testserver.NewTestNetconfServer(t).WithCapabilities(candidateCapabilities)
+		if i == 2 {
+			for _, h := range handlers {
+				ts.WithRequestHandler(h)
+			}
+		}
+		servers = append(servers, ts)
+		changes = append(changes, DeviceChange{Device: fmt.Sprintf("device-%d", i), Session: newTransactionSession(t, ts), Edits: transactionEdits[:1]})
+	}
+	return
+}
+
+func closeMultiDeviceChanges(servers []*testserver.TestNCServer, changes []DeviceChange) {
+	for i := range changes {
+		changes[i].Session.Close()
+		servers[i].Close()
+	}
+}
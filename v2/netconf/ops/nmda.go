@@ -0,0 +1,184 @@
+package ops
+
+import (
+	"context"
+	"encoding/xml"
+	"strconv"
+	"strings"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+)
+
+// Defines the get-data and edit-data operations of the Network Management Datastore Architecture (RFC 8526).
+
+// Define NMDA namespaces.
+const (
+	NetconfNmdaNS = "urn:ietf:params:xml:ns:yang:ietf-netconf-nmda"
+	DatastoresNS  = "urn:ietf:params:xml:ns:yang:ietf-datastores"
+	OriginNS      = "urn:ietf:params:xml:ns:yang:ietf-origin"
+)
+
+// Define the origin identities of RFC 8342, for use with OriginFilter.
+const (
+	OriginIntended = "intended"
+	OriginDynamic  = "dynamic"
+	OriginSystem   = "system"
+	OriginLearned  = "learned"
+	OriginDefault  = "default"
+	OriginUnknown  = "unknown"
+)
+
+// GetDataOption configures a get-data operation.
+type GetDataOption func(*GetDataReq)
+
+// SubtreeFilter selects the data to be retrieved using a subtree filter, which may be an xml string or a struct
+// with xml tags.
+func SubtreeFilter(filter interface{}) GetDataOption {
+	return func(req *GetDataReq) {
+		req.SubtreeFilter = &DataFilter{Union: common.GetUnion(filter)}
+		req.XpathFilter = nil
+	}
+}
+
+// XpathFilter selects the data to be retrieved using an xpath expression, with the namespaces used by its prefixes.
+func XpathFilter(xpath string, nslist []Namespace) GetDataOption {
+	return func(req *GetDataReq) {
+		req.XpathFilter = &XpathDataFilter{Select: xpath}
+		for _, ns := range nslist {
+			req.XpathFilter.Namespaces = append(req.XpathFilter.Namespaces, xml.Attr{Name: xml.Name{Local: "xmlns:" + ns.Id}, Value: ns.Path})
+		}
+		req.SubtreeFilter = nil
+	}
+}
+
+// ConfigFilter selects configuration data only (if true) or non-configuration data only (if false).
+func ConfigFilter(config bool) GetDataOption {
+	return func(req *GetDataReq) {
+		req.ConfigFilter = strconv.FormatBool(config)
+	}
+}
+
+// MaxDepth limits the depth of the data to be retrieved.
+func MaxDepth(depth uint16) GetDataOption {
+	return func(req *GetDataReq) {
+		req.MaxDepth = strconv.Itoa(int(depth))
+	}
+}
+
+// OriginFilter selects data with any of the origins (which may be defined without a prefix, e.g. OriginIntended).
+func OriginFilter(origins ...string) GetDataOption {
+	return func(req *GetDataReq) {
+		req.OriginFilter = originIdentities(origins)
+		req.NegatedOriginFilter = nil
+	}
+}
+
+// NegatedOriginFilter selects data with none of the origins.
+func NegatedOriginFilter(origins ...string) GetDataOption {
+	return func(req *GetDataReq) {
+		req.NegatedOriginFilter = originIdentities(origins)
+		req.OriginFilter = nil
+	}
+}
+
+// WithOrigin requests that the origin of each data node is reported, using the origin metadata annotation.
+func WithOrigin() GetDataOption {
+	return func(req *GetDataReq) {
+		req.WithOrigin = "<with-origin/>"
+	}
+}
+
+func (s *sImpl) GetData(datastore string, result interface{}, options ...GetDataOption) error {
+	return s.GetDataContext(context.Background(), datastore, result, options...)
+}
+
+func (s *sImpl) GetDataContext(ctx context.Context, datastore string, result interface{}, options ...GetDataOption) error {
+	return s.handleGetRequest(ctx, createGetDataRequest(datastore, options...), result)
+}
+
+func (s *sImpl) EditData(datastore string, config ConfigOption, options ...EditOption) error {
+	return s.EditDataContext(context.Background(), datastore, config, options...)
+}
+
+func (s *sImpl) EditDataContext(ctx context.Context, datastore string, config ConfigOption, options ...EditOption) error {
+	_, err := s.Session.ExecuteContext(ctx, createEditDataRequest(datastore, config, options...))
+	return err
+}
+
+func createGetDataRequest(datastore string, options ...GetDataOption) *GetDataReq {
+	req := &GetDataReq{DatastoresNS: DatastoresNS, Datastore: datastoreIdentity(datastore)}
+	for _, opt := range options {
+		opt(req)
+	}
+	if len(req.OriginFilter) > 0 || len(req.NegatedOriginFilter) > 0 {
+		req.OriginNS = OriginNS
+	}
+	return req
+}
+
+func createEditDataRequest(datastore string, cfgOpt ConfigOption, options ...EditOption) *EditDataReq {
+	// The edit-data operation accepts the same configuration as edit-config, but only the default operation option.
+	ec := createEditConfigRequest(datastore, cfgOpt, options...)
+	return &EditDataReq{
+		DatastoresNS:     DatastoresNS,
+		Datastore:        datastoreIdentity(datastore),
+		DefaultOperation: ec.DefaultOperation,
+		Config:           ec.Config,
+		ConfigUrl:        ec.ConfigUrl,
+	}
+}
+
+// datastoreIdentity delivers the identity of the datastore, adding the ietf-datastores prefix if required.
+func datastoreIdentity(datastore string) string {
+	if strings.Contains(datastore, ":") {
+		return datastore
+	}
+	return "ds:" + datastore
+}
+
+func originIdentities(origins []string) []string {
+	identities := make([]string, len(origins))
+	for i, origin := range origins {
+		if strings.Contains(origin, ":") {
+			identities[i] = origin
+		} else {
+			identities[i] = "or:" + origin
+		}
+	}
+	return identities
+}
+
+// Request structs.
+
+type DataFilter struct {
+	*common.Union
+}
+
+type XpathDataFilter struct {
+	Namespaces []xml.Attr `xml:",any,attr"`
+	Select     string     `xml:",chardata"`
+}
+
+type GetDataReq struct {
+	XMLName             xml.Name         `xml:"urn:ietf:params:xml:ns:yang:ietf-netconf-nmda get-data"`
+	DatastoresNS        string           `xml:"xmlns:ds,attr"`
+	OriginNS            string           `xml:"xmlns:or,attr,omitempty"`
+	Datastore           string           `xml:"datastore"`
+	SubtreeFilter       *DataFilter      `xml:"subtree-filter"`
+	XpathFilter         *XpathDataFilter `xml:"xpath-filter"`
+	ConfigFilter        string           `xml:"config-filter,omitempty"`
+	OriginFilter        []string         `xml:"origin-filter"`
+	NegatedOriginFilter []string         `xml:"negated-origin-filter"`
+	MaxDepth            string           `xml:"max-depth,omitempty"`
+	// xml Marshaller will not create self-closing tags....
+	WithOrigin string `xml:",innerxml"`
+}
+
+type EditDataReq struct {
+	XMLName          xml.Name `xml:"urn:ietf:params:xml:ns:yang:ietf-netconf-nmda edit-data"`
+	DatastoresNS     string   `xml:"xmlns:ds,attr"`
+	Datastore        string   `xml:"datastore"`
+	DefaultOperation string   `xml:"default-operation,omitempty"`
+	Config           *Config
+	ConfigUrl        string `xml:"url,omitempty"`
+}
@@ -0,0 +1,85 @@
+package ops
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"testing"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestGetDataToString(t *testing.T) {
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("ExecuteContext", context.Background(), createGetDataRequest("operational", SubtreeFilter(`<element/>`))).
+		Return(&common.RPCReply{Data: `<data xmlns="urn:ietf:params:xml:ns:yang:ietf-netconf-nmda"><element attr1="ABC"/></data>`}, nil)
+
+	var result string
+	err := ncs.GetData("operational", &result, SubtreeFilter(`<element/>`))
+	assert.NoError(t, err, "Not expecting call to fail")
+	assert.Equal(t, `<element attr1="ABC"/>`, result, "Reply should contain response data")
+}
+
+func TestGetDataToStruct(t *testing.T) {
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("ExecuteContext", context.Background(), createGetDataRequest("running")).
+		Return(&common.RPCReply{Data: `<data xmlns="urn:ietf:params:xml:ns:yang:ietf-netconf-nmda"><element attr1="ABC"/></data>`}, nil)
+
+	var result = &Element{}
+	err := ncs.GetData("running", result)
+	assert.NoError(t, err, "Not expecting call to fail")
+	assert.Equal(t, `ABC`, result.Attr1, "Reply should contain response data")
+}
+
+func TestGetDataExecuteError(t *testing.T) {
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("ExecuteContext", context.Background(), createGetDataRequest("operational")).Return(nil, errors.New("failed"))
+
+	var result string
+	err := ncs.GetData("operational", &result)
+	assert.Error(t, err, "expecting call to fail")
+}
+
+func TestGetDataRequest(t *testing.T) {
+
+	req, _ := xml.Marshal(createGetDataRequest("operational"))
+	assert.Equal(t, `<get-data xmlns="urn:ietf:params:xml:ns:yang:ietf-netconf-nmda" xmlns:ds="urn:ietf:params:xml:ns:yang:ietf-datastores">`+
+		`<datastore>ds:operational</datastore></get-data>`, string(req), "Expected get-data request")
+
+	req, _ = xml.Marshal(createGetDataRequest("operational",
+		SubtreeFilter(`<top xmlns="urn:tns"/>`), ConfigFilter(false), OriginFilter(OriginIntended, "ex:vendor"), MaxDepth(3), WithOrigin()))
+	assert.Equal(t, `<get-data xmlns="urn:ietf:params:xml:ns:yang:ietf-netconf-nmda" xmlns:ds="urn:ietf:params:xml:ns:yang:ietf-datastores" xmlns:or="urn:ietf:params:xml:ns:yang:ietf-origin">`+
+		`<datastore>ds:operational</datastore><subtree-filter><top xmlns="urn:tns"/></subtree-filter><config-filter>false</config-filter>`+
+		`<origin-filter>or:intended</origin-filter><origin-filter>ex:vendor</origin-filter><max-depth>3</max-depth><with-origin/></get-data>`,
+		string(req), "Expected get-data request")
+
+	req, _ = xml.Marshal(createGetDataRequest("ex:custom", XpathFilter(`/tns:top`, []Namespace{{"tns", "urn:tns"}}), NegatedOriginFilter(OriginSystem)))
+	assert.Equal(t, `<get-data xmlns="urn:ietf:params:xml:ns:yang:ietf-netconf-nmda" xmlns:ds="urn:ietf:params:xml:ns:yang:ietf-datastores" xmlns:or="urn:ietf:params:xml:ns:yang:ietf-origin">`+
+		`<datastore>ex:custom</datastore><xpath-filter xmlns:tns="urn:tns">/tns:top</xpath-filter>`+
+		`<negated-origin-filter>or:system</negated-origin-filter></get-data>`, string(req), "Expected get-data request")
+}
+
+func TestEditData(t *testing.T) {
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("ExecuteContext", context.Background(), createEditDataRequest("running", Cfg(`<top/>`), DefaultOperation(MergeOp))).Return(&common.RPCReply{}, nil)
+
+	err := ncs.EditData("running", Cfg(`<top/>`), DefaultOperation(MergeOp))
+	assert.NoError(t, err, "Not expecting call to fail")
+
+	req, _ := xml.Marshal(createEditDataRequest("running", Cfg(`<top/>`), DefaultOperation(MergeOp), ErrorOption(StopOnErrorErrOpt)))
+	assert.Equal(t, `<edit-data xmlns="urn:ietf:params:xml:ns:yang:ietf-netconf-nmda" xmlns:ds="urn:ietf:params:xml:ns:yang:ietf-datastores">`+
+		`<datastore>ds:running</datastore><default-operation>merge</default-operation><config><top/></config></edit-data>`,
+		string(req), "Expected edit-data request")
+
+	req, _ = xml.Marshal(createEditDataRequest("candidate", CfgUrl("file://cfg.xml")))
+	assert.Equal(t, `<edit-data xmlns="urn:ietf:params:xml:ns:yang:ietf-netconf-nmda" xmlns:ds="urn:ietf:params:xml:ns:yang:ietf-datastores">`+
+		`<datastore>ds:candidate</datastore><url>file://cfg.xml</url></edit-data>`, string(req), "Expected edit-data request")
+
+	mcli.AssertExpectations(t)
+}
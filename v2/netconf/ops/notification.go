@@ -0,0 +1,153 @@
+package ops
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"time"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+)
+
+// Defines the create-subscription operation and event stream discovery of NETCONF Event Notifications (RFC 5277).
+
+// SubscriptionOption configures a create-subscription request.
+type SubscriptionOption func(*CreateSubscriptionReq)
+
+// EventStream identifies the event stream of interest. If not defined, the NETCONF stream is used.
+func EventStream(name string) SubscriptionOption {
+	return func(req *CreateSubscriptionReq) {
+		req.Stream = name
+	}
+}
+
+// NotificationSubtreeFilter selects the notifications of interest using a subtree filter, which may be an
+// xml string or a struct with xml tags.
+func NotificationSubtreeFilter(filter interface{}) SubscriptionOption {
+	return func(req *CreateSubscriptionReq) {
+		req.Filter = &Filter{Type: "subtree", Union: common.GetUnion(filter)}
+		req.FilterBody = ""
+		req.xpathFilter = nil
+	}
+}
+
+// NotificationXpathFilter selects the notifications of interest using an xpath expression, with the namespaces
+// used by its prefixes. The xpath filter is validated before the request is sent; see NewXPathFilter.
+func NotificationXpathFilter(xpath string, nslist []Namespace) SubscriptionOption {
+	return func(req *CreateSubscriptionReq) {
+		req.xpathFilter = &XPathFilter{Select: xpath, Namespaces: nslist}
+		req.FilterBody = req.xpathFilter.element()
+		req.Filter = nil
+	}
+}
+
+// StartTime requests the replay of notifications generated since the time, which requires the stream to
+// support replay. The end of the replay is reported by a notification of type common.ReplayComplete.
+func StartTime(t time.Time) SubscriptionOption {
+	return func(req *CreateSubscriptionReq) {
+		req.StartTime = formatTime(t)
+		req.start = t
+	}
+}
+
+// StopTime requests that the subscription ends at the time, which must be used with StartTime and be after it.
+// The end of the subscription is reported by a notification of type common.NotificationComplete, after which
+// the channel should be released with Unsubscribe.
+func StopTime(t time.Time) SubscriptionOption {
+	return func(req *CreateSubscriptionReq) {
+		req.StopTime = formatTime(t)
+		req.stop = t
+	}
+}
+
+func (s *sImpl) CreateSubscription(nchan chan *common.Notification, options ...SubscriptionOption) error {
+	return s.CreateSubscriptionContext(context.Background(), nchan, options...)
+}
+
+func (s *sImpl) CreateSubscriptionContext(ctx context.Context, nchan chan *common.Notification, options ...SubscriptionOption) error {
+	if err := s.requireCapability(common.CapNotification); err != nil {
+		return err
+	}
+	req := createSubscriptionRequest(options...)
+	if err := req.validate(); err != nil {
+		return err
+	}
+	_, err := s.Session.SubscribeContext(ctx, req, nchan)
+	return err
+}
+
+func (s *sImpl) GetStreams() ([]Stream, error) {
+	return s.GetStreamsContext(context.Background())
+}
+
+func (s *sImpl) GetStreamsContext(ctx context.Context) ([]Stream, error) {
+	nc := &NetconfStreams{}
+	err := s.handleGetRequest(ctx, createGetStreamsRequest(), nc)
+	if err != nil {
+		return nil, err
+	}
+	return nc.Streams, nil
+}
+
+func createSubscriptionRequest(options ...SubscriptionOption) *CreateSubscriptionReq {
+	req := &CreateSubscriptionReq{}
+	for _, opt := range options {
+		opt(req)
+	}
+	return req
+}
+
+// validate checks the options that the server would otherwise reject.
+func (req *CreateSubscriptionReq) validate() error {
+	if req.xpathFilter != nil {
+		if err := req.xpathFilter.Validate(); err != nil {
+			return err
+		}
+	}
+	if req.StopTime != "" {
+		if req.StartTime == "" {
+			return errors.New("create-subscription: stop time requires a start time")
+		}
+		if !req.stop.After(req.start) {
+			return errors.New("create-subscription: stop time must be after the start time")
+		}
+	}
+	return nil
+}
+
+func createGetStreamsRequest() common.Request {
+	return createGetSubtreeRequest(`<netconf xmlns="urn:ietf:params:xml:ns:netmod:notification"><streams/></netconf>`)
+}
+
+func formatTime(t time.Time) string {
+	return t.Format(time.RFC3339Nano)
+}
+
+// Stream describes an event stream supported by the server.
+type Stream struct {
+	Name        string `xml:"name"`
+	Description string `xml:"description"`
+	// ReplaySupport reports whether notifications can be replayed, using StartTime.
+	ReplaySupport bool `xml:"replaySupport"`
+	// ReplayLogCreationTime is the earliest time from which notifications can be replayed, if ReplaySupport is true.
+	ReplayLogCreationTime time.Time `xml:"replayLogCreationTime"`
+}
+
+type NetconfStreams struct {
+	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:netmod:notification netconf"`
+	Streams []Stream `xml:"streams>stream"`
+}
+
+// Request structs.
+
+type CreateSubscriptionReq struct {
+	XMLName    xml.Name `xml:"urn:ietf:params:xml:ns:netconf:notification:1.0 create-subscription"`
+	Stream     string   `xml:"stream,omitempty"`
+	Filter     *Filter
+	FilterBody string `xml:",innerxml"`
+	StartTime  string `xml:"startTime,omitempty"`
+	StopTime   string `xml:"stopTime,omitempty"`
+
+	xpathFilter *XPathFilter
+	start, stop time.Time
+}
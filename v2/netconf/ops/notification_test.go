@@ -0,0 +1,116 @@
+package ops
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+	"github.com/damianoneill/net/v2/netconf/testserver"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestCreateSubscriptionRequest(t *testing.T) {
+
+	req, _ := xml.Marshal(createSubscriptionRequest())
+	assert.Equal(t, `<create-subscription xmlns="urn:ietf:params:xml:ns:netconf:notification:1.0"></create-subscription>`, string(req),
+		"Expected create-subscription request")
+
+	start := time.Date(2020, time.March, 1, 10, 30, 0, 0, time.UTC)
+	req, _ = xml.Marshal(createSubscriptionRequest(EventStream("SYSLOG"), NotificationSubtreeFilter(`<event xmlns="urn:tns"/>`),
+		StartTime(start), StopTime(start.Add(time.Hour))))
+	assert.Equal(t, `<create-subscription xmlns="urn:ietf:params:xml:ns:netconf:notification:1.0"><stream>SYSLOG</stream>`+
+		`<filter type="subtree"><event xmlns="urn:tns"/></filter><startTime>2020-03-01T10:30:00Z</startTime>`+
+		`<stopTime>2020-03-01T11:30:00Z</stopTime></create-subscription>`, string(req), "Expected create-subscription request")
+
+	req, _ = xml.Marshal(createSubscriptionRequest(NotificationXpathFilter(`/tns:event`, []Namespace{{"tns", "urn:tns"}})))
+	assert.Equal(t, `<create-subscription xmlns="urn:ietf:params:xml:ns:netconf:notification:1.0">`+
+		`<filter xmlns:tns="urn:tns" type="xpath" select="/tns:event"/></create-subscription>`, string(req), "Expected create-subscription request")
+}
+
+func TestCreateSubscription(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t).WithCapabilities([]string{common.CapBase10, common.CapBase11, common.CapNotification})
+	defer ts.Close()
+	ncs := newTransactionSession(t, ts)
+	defer ncs.Close()
+
+	nch := make(chan *common.Notification, 2)
+	err := ncs.CreateSubscription(nch, EventStream("NETCONF"), StartTime(time.Now().Add(-time.Hour)))
+	assert.NoError(t, err, "Not expecting subscription to fail")
+
+	sh := ts.SessionHandler(ncs.ID())
+	assert.Equal(t, "create-subscription", sh.LastReq().XMLName.Local, "Expected create-subscription request")
+	sh.SendNotification(`<event xmlns="urn:tns"/>`)
+	sh.SendNotification(`<replayComplete xmlns="urn:ietf:params:xml:ns:netmod:notification"/>`)
+
+	n := <-nch
+	assert.Equal(t, common.EventNotification, n.Type, "Expected event notification")
+	assert.Equal(t, "event", n.XMLName.Local, "Expected event")
+	assert.Equal(t, common.ReplayComplete, (<-nch).Type, "Expected replay complete")
+}
+
+func TestCreateSubscriptionNotSupported(t *testing.T) {
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("Capabilities").Return(common.ParseCapabilities([]string{common.CapBase11}))
+
+	err := ncs.CreateSubscription(make(chan *common.Notification))
+	assert.True(t, errors.Is(err, ErrNotSupported), "Expecting notification capability to be required")
+
+	mcli.AssertExpectations(t)
+}
+
+func TestCreateSubscriptionInvalidXpath(t *testing.T) {
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("Capabilities").Return(common.ParseCapabilities([]string{common.CapBase11, common.CapNotification}))
+
+	err := ncs.CreateSubscription(make(chan *common.Notification), NotificationXpathFilter(`/tns:event`, nil))
+	assert.True(t, errors.Is(err, ErrInvalidXPath), "Expecting undeclared prefix to be rejected")
+	err = ncs.CreateSubscription(make(chan *common.Notification), NotificationXpathFilter(`/tns:event[`, []Namespace{{"tns", "urn:tns"}}))
+	assert.True(t, errors.Is(err, ErrInvalidXPath), "Expecting malformed xpath to be rejected")
+
+	mcli.AssertNotCalled(t, "SubscribeContext")
+	mcli.AssertExpectations(t)
+}
+
+func TestCreateSubscriptionInvalidStopTime(t *testing.T) {
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("Capabilities").Return(common.ParseCapabilities([]string{common.CapBase11, common.CapNotification}))
+
+	start := time.Date(2020, time.March, 1, 10, 30, 0, 0, time.UTC)
+	err := ncs.CreateSubscription(make(chan *common.Notification), StopTime(start))
+	assert.EqualError(t, err, "create-subscription: stop time requires a start time", "Expecting start time to be required")
+	err = ncs.CreateSubscription(make(chan *common.Notification), StartTime(start), StopTime(start))
+	assert.EqualError(t, err, "create-subscription: stop time must be after the start time", "Expecting stop time to follow start time")
+	err = ncs.CreateSubscription(make(chan *common.Notification), StartTime(start), StopTime(start.Add(-time.Hour)))
+	assert.EqualError(t, err, "create-subscription: stop time must be after the start time", "Expecting stop time to follow start time")
+
+	mcli.AssertNotCalled(t, "SubscribeContext")
+	mcli.AssertExpectations(t)
+}
+
+func TestGetStreams(t *testing.T) {
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("ExecuteContext", context.Background(), createGetStreamsRequest()).Return(&common.RPCReply{Data: `<data>` +
+		`<netconf xmlns="urn:ietf:params:xml:ns:netmod:notification"><streams>` +
+		`<stream><name>NETCONF</name><description>default stream</description><replaySupport>true</replaySupport>` +
+		`<replayLogCreationTime>2020-03-01T10:30:00Z</replayLogCreationTime></stream>` +
+		`<stream><name>SNMP</name><replaySupport>false</replaySupport></stream>` +
+		`</streams></netconf></data>`}, nil)
+
+	streams, err := ncs.GetStreams()
+	assert.NoError(t, err, "Not expecting call to fail")
+	assert.Equal(t, []Stream{
+		{Name: "NETCONF", Description: "default stream", ReplaySupport: true, ReplayLogCreationTime: time.Date(2020, time.March, 1, 10, 30, 0, 0, time.UTC)},
+		{Name: "SNMP"},
+	}, streams, "Expected streams")
+
+	mcli.AssertExpectations(t)
+}
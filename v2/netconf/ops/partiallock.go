@@ -0,0 +1,104 @@
+package ops
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+)
+
+// Defines the partial-lock and partial-unlock operations (RFC 5717), which lock parts of the running datastore
+// selected by xpath expressions, and the structured errors reported when a lock is denied.
+
+// PartialLockNS is the namespace of the partial-lock and partial-unlock operations.
+const PartialLockNS = "urn:ietf:params:xml:ns:netconf:partial-lock:1.0"
+
+// LockDeniedError reports that a lock or partial lock was denied because (some of) the configuration is
+// already locked.
+type LockDeniedError struct {
+	// SessionID identifies the session holding the lock, or is zero if the lock is held by a non-NETCONF entity.
+	SessionID uint64
+	// Err holds the details of the rpc-error.
+	Err *common.RPCError
+}
+
+func (e *LockDeniedError) Error() string {
+	return fmt.Sprintf("lock denied, held by session %d: %v", e.SessionID, e.Err)
+}
+
+// Unwrap delivers the rpc-error that reported the lock denial.
+func (e *LockDeniedError) Unwrap() error {
+	return e.Err
+}
+
+func (s *sImpl) PartialLock(selectXPaths []string, nslist []Namespace) (uint32, []string, error) {
+	return s.PartialLockContext(context.Background(), selectXPaths, nslist)
+}
+
+func (s *sImpl) PartialLockContext(ctx context.Context, selectXPaths []string, nslist []Namespace) (uint32, []string, error) {
+	if err := s.requireCapability(common.CapPartialLock); err != nil {
+		return 0, nil, err
+	}
+	reply, err := s.Session.ExecuteContext(ctx, createPartialLockRequest(selectXPaths, nslist))
+	if err != nil {
+		return 0, nil, mapLockError(err)
+	}
+	plr := &partialLockReply{}
+	if err = xml.Unmarshal([]byte("<reply>"+reply.Data+"</reply>"), plr); err != nil {
+		return 0, nil, err
+	}
+	return plr.LockID, plr.LockedNodes, nil
+}
+
+func (s *sImpl) PartialUnlock(lockID uint32) error {
+	return s.PartialUnlockContext(context.Background(), lockID)
+}
+
+func (s *sImpl) PartialUnlockContext(ctx context.Context, lockID uint32) error {
+	if err := s.requireCapability(common.CapPartialLock); err != nil {
+		return err
+	}
+	_, err := s.Session.ExecuteContext(ctx, &PartialUnlockReq{LockID: lockID})
+	return err
+}
+
+func createPartialLockRequest(selectXPaths []string, nslist []Namespace) *PartialLockReq {
+	req := &PartialLockReq{Select: selectXPaths}
+	for _, ns := range nslist {
+		req.Namespaces = append(req.Namespaces, xml.Attr{Name: xml.Name{Local: "xmlns:" + ns.Id}, Value: ns.Path})
+	}
+	return req
+}
+
+// mapLockError delivers a LockDeniedError if err reports a lock-denied rpc-error, otherwise err.
+func mapLockError(err error) error {
+	var rpcErr *common.RPCError
+	if !errors.As(err, &rpcErr) || !errors.Is(rpcErr, common.ErrLockDenied) {
+		return err
+	}
+	denied := &LockDeniedError{Err: rpcErr}
+	if rpcErr.ErrorInfo != nil {
+		denied.SessionID = rpcErr.ErrorInfo.SessionID
+	}
+	return denied
+}
+
+type partialLockReply struct {
+	LockID      uint32   `xml:"lock-id"`
+	LockedNodes []string `xml:"locked-node"`
+}
+
+// Request structs.
+
+type PartialLockReq struct {
+	XMLName    xml.Name   `xml:"urn:ietf:params:xml:ns:netconf:partial-lock:1.0 partial-lock"`
+	Namespaces []xml.Attr `xml:",any,attr"`
+	Select     []string   `xml:"select"`
+}
+
+type PartialUnlockReq struct {
+	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:netconf:partial-lock:1.0 partial-unlock"`
+	LockID  uint32   `xml:"lock-id"`
+}
@@ -0,0 +1,92 @@
+package ops
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"testing"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+var partialLockCapabilities = []string{common.CapBase11, common.CapPartialLock}
+
+var lockDenied = &common.RPCError{Type: "protocol", Tag: "lock-denied", Severity: "error", Message: "Lock failed, lock is already held",
+	ErrorInfo: &common.ErrorInfo{SessionID: 454}}
+
+func TestPartialLock(t *testing.T) {
+
+	nslist := []Namespace{{"rte", "urn:rte"}}
+	selects := []string{`/rte:routing/rte:virtualRouter[rte:routerName='router1']`, `/rte:routing/rte:virtualRouter[rte:routerName='router2']`}
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("Capabilities").Return(common.ParseCapabilities(partialLockCapabilities))
+	mcli.On("ExecuteContext", context.Background(), createPartialLockRequest(selects, nslist)).Return(&common.RPCReply{Data: `<lock-id>127</lock-id>` +
+		`<locked-node xmlns:rte="urn:rte">/rte:routing/rte:virtualRouter[rte:routerName='router1']</locked-node>` +
+		`<locked-node xmlns:rte="urn:rte">/rte:routing/rte:virtualRouter[rte:routerName='router2']</locked-node>`}, nil)
+	mcli.On("ExecuteContext", context.Background(), &PartialUnlockReq{LockID: 127}).Return(&common.RPCReply{}, nil)
+
+	lockID, nodes, err := ncs.PartialLock(selects, nslist)
+	assert.NoError(t, err, "Not expecting partial lock to fail")
+	assert.Equal(t, uint32(127), lockID, "Expected lock id")
+	assert.Equal(t, selects, nodes, "Expected locked nodes")
+
+	err = ncs.PartialUnlock(lockID)
+	assert.NoError(t, err, "Not expecting partial unlock to fail")
+
+	req, _ := xml.Marshal(createPartialLockRequest(selects[:1], nslist))
+	assert.Equal(t, `<partial-lock xmlns="urn:ietf:params:xml:ns:netconf:partial-lock:1.0" xmlns:rte="urn:rte">`+
+		`<select>/rte:routing/rte:virtualRouter[rte:routerName=&#39;router1&#39;]</select></partial-lock>`, string(req), "Expected partial-lock request")
+	req, _ = xml.Marshal(&PartialUnlockReq{LockID: 127})
+	assert.Equal(t, `<partial-unlock xmlns="urn:ietf:params:xml:ns:netconf:partial-lock:1.0"><lock-id>127</lock-id></partial-unlock>`,
+		string(req), "Expected partial-unlock request")
+
+	mcli.AssertExpectations(t)
+}
+
+func TestPartialLockDenied(t *testing.T) {
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("Capabilities").Return(common.ParseCapabilities(partialLockCapabilities))
+	mcli.On("ExecuteContext", context.Background(), createPartialLockRequest([]string{`/top`}, nil)).Return(nil, lockDenied)
+
+	_, _, err := ncs.PartialLock([]string{`/top`}, nil)
+	var denied *LockDeniedError
+	assert.True(t, errors.As(err, &denied), "Expecting lock to be denied")
+	assert.Equal(t, uint64(454), denied.SessionID, "Expected session holding the lock")
+	var rpcErr *common.RPCError
+	assert.True(t, errors.As(err, &rpcErr), "Expected rpc-error")
+	assert.Equal(t, "lock-denied", rpcErr.Tag, "Expected rpc-error details")
+	assert.True(t, errors.Is(err, common.ErrLockDenied), "Expected lock-denied error tag")
+
+	mcli.AssertExpectations(t)
+}
+
+func TestPartialLockNotSupported(t *testing.T) {
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("Capabilities").Return(common.ParseCapabilities([]string{common.CapBase11}))
+
+	_, _, err := ncs.PartialLock([]string{`/top`}, nil)
+	assert.True(t, errors.Is(err, ErrNotSupported), "Expecting partial-lock capability to be required")
+	err = ncs.PartialUnlock(1)
+	assert.True(t, errors.Is(err, ErrNotSupported), "Expecting partial-lock capability to be required")
+
+	mcli.AssertExpectations(t)
+}
+
+func TestLockDenied(t *testing.T) {
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("ExecuteContext", context.Background(), createLockRequest(RunningCfg)).Return(nil, lockDenied)
+
+	err := ncs.Lock(RunningCfg)
+	var denied *LockDeniedError
+	assert.True(t, errors.As(err, &denied), "Expecting lock to be denied")
+	assert.Equal(t, uint64(454), denied.SessionID, "Expected session holding the lock")
+	assert.EqualError(t, err, "lock denied, held by session 454: netconf rpc [error] 'Lock failed, lock is already held'", "Expected error message")
+
+	mcli.AssertExpectations(t)
+}
@@ -0,0 +1,484 @@
+package ops
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/damianoneill/net/v2/netconf/client"
+
+	"github.com/imdario/mergo"
+	"golang.org/x/crypto/ssh"
+)
+
+// Defines a pool of netconf sessions to multiple devices, keyed by target.
+// Sessions are opened when first required, and may be acquired for exclusive use (for example, while the
+// configuration of a device is locked), or shared with other users.
+
+// ErrPoolClosed is returned when a session is acquired from a pool that has been closed.
+var ErrPoolClosed = errors.New("session pool is closed")
+
+// ErrSessionIdle is the reason reported when an idle session is closed by the pool.
+var ErrSessionIdle = errors.New("session idle")
+
+// PoolConfig defines properties that configure session pool behaviour.
+type PoolConfig struct {
+	// Defines the maximum number of sessions that will be opened to a single target.
+	MaxSessionsPerTarget int
+	// Defines the maximum number of sessions that will be opened to all targets. If zero, there is no limit.
+	MaxSessions int
+	// Defines the time after which a session that has not been used is closed.
+	IdleTimeout time.Duration
+	// Defines the interval at which idle sessions are health checked, and closed if they have exceeded the
+	// IdleTimeout.
+	HealthCheckInterval time.Duration
+	// Defines the time allowed for a health check to complete.
+	HealthCheckTimeout time.Duration
+	// HealthCheck, if defined, is used to verify that an idle session is usable. Otherwise, a get request
+	// with an empty subtree filter is issued.
+	HealthCheck func(ctx context.Context, s OpSession) error
+}
+
+// DefaultPoolConfig defines the default session pool configuration.
+var DefaultPoolConfig = &PoolConfig{
+	MaxSessionsPerTarget: 1,
+	IdleTimeout:          time.Minute * time.Duration(5),
+	HealthCheckInterval:  time.Minute,
+	HealthCheckTimeout:   time.Second * time.Duration(10),
+}
+
+// PoolDialer establishes a netconf session to the target.
+type PoolDialer func(ctx context.Context, target string) (OpSession, error)
+
+// SSHPoolDialer delivers a PoolDialer that establishes sessions using the ssh and client configuration.
+func SSHPoolDialer(sshcfg *ssh.ClientConfig, cfg *client.Config) PoolDialer {
+	return func(ctx context.Context, target string) (OpSession, error) {
+		return NewSessionWithConfig(ctx, sshcfg, target, cfg)
+	}
+}
+
+// SessionPool manages netconf sessions to multiple targets.
+type SessionPool struct {
+	ctx   context.Context
+	dial  PoolDialer
+	cfg   *PoolConfig
+	trace *PoolTrace
+
+	lock     sync.Mutex
+	sessions map[string][]*pooledSession
+	// The number of sessions being opened, per target.
+	opening map[string]int
+	open    int
+	waiting int
+	// Closed, and replaced, when a session becomes available or the pool is closed.
+	changed  chan struct{}
+	isClosed bool
+	done     chan struct{}
+	swept    chan struct{}
+}
+
+type pooledSession struct {
+	target    string
+	s         OpSession
+	users     int
+	exclusive bool
+	checking  bool
+	lastUsed  time.Time
+}
+
+// PooledSession is an OpSession acquired from a SessionPool. It must be returned to the pool by calling Release.
+type PooledSession struct {
+	OpSession
+	pool *SessionPool
+	ps   *pooledSession
+	once sync.Once
+}
+
+// NewSessionPool creates a session pool that uses the dialer to open sessions, according to the configuration.
+func NewSessionPool(ctx context.Context, dial PoolDialer, cfg *PoolConfig) *SessionPool {
+
+	// Use supplied config, but apply any defaults to unspecified values.
+	var resolvedConfig PoolConfig = *cfg
+	_ = mergo.Merge(&resolvedConfig, DefaultPoolConfig)
+
+	p := &SessionPool{
+		ctx:      ctx,
+		dial:     dial,
+		cfg:      &resolvedConfig,
+		trace:    ContextPoolTrace(ctx),
+		sessions: make(map[string][]*pooledSession),
+		opening:  make(map[string]int),
+		changed:  make(chan struct{}),
+		done:     make(chan struct{}),
+		swept:    make(chan struct{}),
+	}
+	go p.sweep()
+	return p
+}
+
+// Acquire delivers a session to the target, opening one if required and permitted by the pool limits.
+// If exclusive is true, the session will not be given to any other user until it is released; otherwise it may
+// be shared with other non-exclusive users. If no session is available, Acquire waits until one is released,
+// or ctx is done.
+func (p *SessionPool) Acquire(ctx context.Context, target string, exclusive bool) (s *PooledSession, err error) {
+
+	defer func(begin time.Time) {
+		p.trace.SessionAcquired(target, exclusive, err, time.Since(begin))
+	}(time.Now())
+
+	for {
+		p.lock.Lock()
+		if p.isClosed {
+			p.lock.Unlock()
+			return nil, ErrPoolClosed
+		}
+
+		if ps := p.idleSession(target); ps != nil {
+			s = p.use(ps, exclusive)
+			stats := p.stats()
+			p.lock.Unlock()
+			p.trace.StatsChanged(stats)
+			return s, nil
+		}
+
+		if p.canOpen(target) {
+			p.open++
+			p.opening[target]++
+			p.lock.Unlock()
+			return p.openSession(ctx, target, exclusive)
+		}
+
+		if p.targetCanOpen(target) {
+			// The overall limit has been reached, so close the least recently used idle session to another
+			// target, if any, to make room.
+			// The session is detached while the lock is held, so that it cannot be acquired before it is closed.
+			if ps := p.leastRecentlyUsedIdleSession(); ps != nil {
+				p.detach(ps)
+				stats := p.stats()
+				p.lock.Unlock()
+				p.closeSession(ps, ErrSessionIdle)
+				p.trace.StatsChanged(stats)
+				continue
+			}
+		}
+
+		if !exclusive {
+			if ps := p.sharedSession(target); ps != nil {
+				s = p.use(ps, false)
+				p.lock.Unlock()
+				return s, nil
+			}
+		}
+
+		changed := p.changed
+		p.waiting++
+		stats := p.stats()
+		p.lock.Unlock()
+		p.trace.StatsChanged(stats)
+
+		select {
+		case <-changed:
+		case <-ctx.Done():
+			err = ctx.Err()
+		}
+
+		p.lock.Lock()
+		p.waiting--
+		stats = p.stats()
+		p.lock.Unlock()
+		p.trace.StatsChanged(stats)
+
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// Close closes all sessions held by the pool. Subsequent attempts to acquire a session will fail.
+func (p *SessionPool) Close() {
+	p.lock.Lock()
+	if p.isClosed {
+		p.lock.Unlock()
+		return
+	}
+	p.isClosed = true
+	close(p.done)
+	var closing []*pooledSession
+	for _, sessions := range p.sessions {
+		closing = append(closing, sessions...)
+	}
+	p.sessions = make(map[string][]*pooledSession)
+	p.open -= len(closing)
+	p.notify()
+	stats := p.stats()
+	p.lock.Unlock()
+
+	<-p.swept
+	for _, ps := range closing {
+		p.closeSession(ps, ErrPoolClosed)
+	}
+	p.trace.StatsChanged(stats)
+}
+
+// Release returns the session to the pool. The session must not be used after it has been released.
+func (s *PooledSession) Release() {
+	s.once.Do(func() {
+		s.pool.release(s.ps)
+	})
+}
+
+// Close closes the session, and removes it from the pool. It should be used instead of Release if the session is
+// known to be unusable. Note that a session acquired for shared use may be in use by others.
+func (s *PooledSession) Close() {
+	s.once.Do(func() {
+		s.pool.remove(s.ps, nil)
+	})
+}
+
+func (p *SessionPool) openSession(ctx context.Context, target string, exclusive bool) (*PooledSession, error) {
+
+	begin := time.Now()
+	s, err := p.dial(ctx, target)
+	p.trace.SessionOpened(target, err, time.Since(begin))
+
+	p.lock.Lock()
+	p.opening[target]--
+	if p.opening[target] == 0 {
+		delete(p.opening, target)
+	}
+	if err != nil {
+		p.open--
+		p.notify()
+		stats := p.stats()
+		p.lock.Unlock()
+		p.trace.StatsChanged(stats)
+		return nil, err
+	}
+	if p.isClosed {
+		p.open--
+		p.lock.Unlock()
+		s.Close()
+		return nil, ErrPoolClosed
+	}
+
+	ps := &pooledSession{target: target, s: s}
+	p.sessions[target] = append(p.sessions[target], ps)
+	pooled := p.use(ps, exclusive)
+	stats := p.stats()
+	p.lock.Unlock()
+	p.trace.StatsChanged(stats)
+	return pooled, nil
+}
+
+// idleSession delivers a session to the target that is not in use, if any.
+func (p *SessionPool) idleSession(target string) *pooledSession {
+	for _, ps := range p.sessions[target] {
+		if ps.users == 0 && !ps.checking {
+			return ps
+		}
+	}
+	return nil
+}
+
+// sharedSession delivers the least used session to the target that is in shared use, if any.
+func (p *SessionPool) sharedSession(target string) (shared *pooledSession) {
+	for _, ps := range p.sessions[target] {
+		if ps.users > 0 && !ps.exclusive && (shared == nil || ps.users < shared.users) {
+			shared = ps
+		}
+	}
+	return
+}
+
+func (p *SessionPool) canOpen(target string) bool {
+	if p.cfg.MaxSessions > 0 && p.open >= p.cfg.MaxSessions {
+		return false
+	}
+	return p.targetCanOpen(target)
+}
+
+func (p *SessionPool) targetCanOpen(target string) bool {
+	return len(p.sessions[target])+p.opening[target] < p.cfg.MaxSessionsPerTarget
+}
+
+// leastRecentlyUsedIdleSession delivers the session, to any target, that has been idle for the longest time, if any.
+func (p *SessionPool) leastRecentlyUsedIdleSession() (lru *pooledSession) {
+	for _, sessions := range p.sessions {
+		for _, ps := range sessions {
+			if ps.users == 0 && !ps.checking && (lru == nil || ps.lastUsed.Before(lru.lastUsed)) {
+				lru = ps
+			}
+		}
+	}
+	return
+}
+
+func (p *SessionPool) use(ps *pooledSession, exclusive bool) *PooledSession {
+	ps.users++
+	ps.exclusive = exclusive
+	ps.lastUsed = time.Now()
+	return &PooledSession{OpSession: ps.s, pool: p, ps: ps}
+}
+
+func (p *SessionPool) release(ps *pooledSession) {
+	p.lock.Lock()
+	ps.users--
+	if ps.users == 0 {
+		ps.exclusive = false
+	}
+	ps.lastUsed = time.Now()
+	p.notify()
+	stats := p.stats()
+	p.lock.Unlock()
+
+	p.trace.SessionReleased(ps.target)
+	p.trace.StatsChanged(stats)
+}
+
+// remove removes the session from the pool, and closes it.
+func (p *SessionPool) remove(ps *pooledSession, reason error) {
+	p.lock.Lock()
+	if !p.detach(ps) {
+		p.lock.Unlock()
+		return
+	}
+	stats := p.stats()
+	p.lock.Unlock()
+
+	p.closeSession(ps, reason)
+	p.trace.StatsChanged(stats)
+}
+
+// detach removes the session from the pool, reporting whether it was present. It must be called with the lock held.
+func (p *SessionPool) detach(ps *pooledSession) bool {
+	sessions := p.sessions[ps.target]
+	removed := false
+	for i, s := range sessions {
+		if s == ps {
+			sessions = append(sessions[:i:i], sessions[i+1:]...)
+			removed = true
+			break
+		}
+	}
+	if !removed {
+		return false
+	}
+	if len(sessions) == 0 {
+		delete(p.sessions, ps.target)
+	} else {
+		p.sessions[ps.target] = sessions
+	}
+	p.open--
+	p.notify()
+	return true
+}
+
+func (p *SessionPool) closeSession(ps *pooledSession, reason error) {
+	ps.s.Close()
+	p.trace.SessionClosed(ps.target, reason)
+}
+
+// notify wakes any users waiting for a session. It must be called with the lock held.
+func (p *SessionPool) notify() {
+	close(p.changed)
+	p.changed = make(chan struct{})
+}
+
+// stats delivers the current pool statistics. It must be called with the lock held.
+func (p *SessionPool) stats() PoolStats {
+	stats := PoolStats{Sessions: p.open, Waiting: p.waiting}
+	for _, sessions := range p.sessions {
+		for _, ps := range sessions {
+			if ps.users > 0 {
+				stats.InUse++
+			}
+		}
+	}
+	return stats
+}
+
+// sweep periodically closes sessions that have exceeded the idle timeout, and health checks the remainder.
+func (p *SessionPool) sweep() {
+	defer close(p.swept)
+
+	ticker := time.NewTicker(p.cfg.HealthCheckInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+		case <-p.done:
+			return
+		}
+
+		idle, check, stats := p.idleSessions()
+		for _, ps := range idle {
+			p.closeSession(ps, ErrSessionIdle)
+		}
+		if len(idle) > 0 {
+			p.trace.StatsChanged(stats)
+		}
+
+		var wg sync.WaitGroup
+		for _, ps := range check {
+			wg.Add(1)
+			go func(ps *pooledSession) {
+				defer wg.Done()
+				p.healthCheck(ps)
+			}(ps)
+		}
+		wg.Wait()
+	}
+}
+
+// idleSessions delivers the sessions not in use, separated into those that have exceeded the idle timeout,
+// which are removed from the pool, and those to be health checked, which are marked to prevent them being
+// acquired during the check. The pool statistics are delivered after the removal.
+func (p *SessionPool) idleSessions() (idle, check []*pooledSession, stats PoolStats) {
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	for _, sessions := range p.sessions {
+		for _, ps := range sessions {
+			if ps.users > 0 {
+				continue
+			}
+			if time.Since(ps.lastUsed) >= p.cfg.IdleTimeout {
+				idle = append(idle, ps)
+			} else {
+				ps.checking = true
+				check = append(check, ps)
+			}
+		}
+	}
+	for _, ps := range idle {
+		p.detach(ps)
+	}
+	stats = p.stats()
+	return
+}
+
+func (p *SessionPool) healthCheck(ps *pooledSession) {
+
+	ctx, cancel := context.WithTimeout(p.ctx, p.cfg.HealthCheckTimeout)
+	defer cancel()
+
+	begin := time.Now()
+	var err error
+	if p.cfg.HealthCheck != nil {
+		err = p.cfg.HealthCheck(ctx, ps.s)
+	} else {
+		var result string
+		err = ps.s.GetSubtreeContext(ctx, "", &result)
+	}
+	p.trace.HealthCheckDone(ps.target, err, time.Since(begin))
+
+	if err != nil {
+		p.remove(ps, err)
+		return
+	}
+
+	p.lock.Lock()
+	ps.checking = false
+	p.notify()
+	p.lock.Unlock()
+}
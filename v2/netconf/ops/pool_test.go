@@ -0,0 +1,353 @@
+package ops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/damianoneill/net/v2/netconf/client"
+	"github.com/damianoneill/net/v2/netconf/common"
+	"github.com/damianoneill/net/v2/netconf/mocks"
+	"github.com/damianoneill/net/v2/netconf/testserver"
+
+	"github.com/stretchr/testify/mock"
+	assert "github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+func TestPoolSharedSession(t *testing.T) {
+
+	dialer, dialled := newMockPoolDialer()
+	p := NewSessionPool(context.Background(), dialer, &PoolConfig{})
+	defer p.Close()
+
+	s1, err := p.Acquire(context.Background(), "target", false)
+	assert.NoError(t, err, "Not expecting acquire to fail")
+	s2, err := p.Acquire(context.Background(), "target", false)
+	assert.NoError(t, err, "Not expecting acquire to fail")
+	assert.Equal(t, s1.OpSession, s2.OpSession, "Expected session to be shared")
+	assert.Equal(t, 1, dialled("target"), "Expected a single session to be opened")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*time.Duration(100))
+	defer cancel()
+	_, err = p.Acquire(ctx, "target", true)
+	assert.Equal(t, context.DeadlineExceeded, err, "Expected exclusive acquire to time out")
+
+	s1.Release()
+	s2.Release()
+	s3, err := p.Acquire(context.Background(), "target", true)
+	assert.NoError(t, err, "Not expecting acquire to fail")
+	assert.Equal(t, s1.OpSession, s3.OpSession, "Expected session to be reused")
+	assert.Equal(t, 1, dialled("target"), "Expected a single session to be opened")
+	s3.Release()
+}
+
+func TestPoolExclusiveSessionWait(t *testing.T) {
+
+	dialer, dialled := newMockPoolDialer()
+	p := NewSessionPool(context.Background(), dialer, &PoolConfig{MaxSessionsPerTarget: 2})
+	defer p.Close()
+
+	s1, err := p.Acquire(context.Background(), "target", true)
+	assert.NoError(t, err, "Not expecting acquire to fail")
+	s2, err := p.Acquire(context.Background(), "target", false)
+	assert.NoError(t, err, "Not expecting acquire to fail")
+	assert.NotEqual(t, s1.OpSession, s2.OpSession, "Expected separate sessions")
+	assert.Equal(t, 2, dialled("target"), "Expected two sessions to be opened")
+
+	acquired := make(chan *PooledSession)
+	go func() {
+		s, _ := p.Acquire(context.Background(), "target", true)
+		acquired <- s
+	}()
+
+	time.Sleep(time.Millisecond * time.Duration(100))
+	s1.Release()
+	s3 := <-acquired
+	assert.Equal(t, s1.OpSession, s3.OpSession, "Expected released session to be acquired")
+	s3.Release()
+	s2.Release()
+}
+
+func TestPoolMaxSessions(t *testing.T) {
+
+	dialer, dialled := newMockPoolDialer()
+	p := NewSessionPool(context.Background(), dialer, &PoolConfig{MaxSessions: 1})
+	defer p.Close()
+
+	s1, err := p.Acquire(context.Background(), "target1", false)
+	assert.NoError(t, err, "Not expecting acquire to fail")
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*time.Duration(100))
+	defer cancel()
+	_, err = p.Acquire(ctx, "target2", false)
+	assert.Equal(t, context.DeadlineExceeded, err, "Expected acquire to time out")
+
+	// The idle session to target1 should be closed to make room.
+	s1.Release()
+	s2, err := p.Acquire(context.Background(), "target2", false)
+	assert.NoError(t, err, "Not expecting acquire to fail")
+	assert.Equal(t, 1, dialled("target2"), "Expected a session to be opened")
+	s1.OpSession.(*sImpl).Session.(*mocks.OpSession).AssertCalled(t, "Close")
+	s2.Release()
+}
+
+func TestPoolIdleTimeout(t *testing.T) {
+
+	closed := make(chan error, 1)
+	trace := &PoolTrace{
+		SessionClosed: func(target string, reason error) {
+			closed <- reason
+		},
+	}
+
+	dialer, _ := newMockPoolDialer()
+	cfg := &PoolConfig{IdleTimeout: time.Millisecond * time.Duration(50), HealthCheckInterval: time.Millisecond * time.Duration(20)}
+	p := NewSessionPool(WithPoolTrace(context.Background(), trace), dialer, cfg)
+	defer p.Close()
+
+	s, err := p.Acquire(context.Background(), "target", false)
+	assert.NoError(t, err, "Not expecting acquire to fail")
+	s.Release()
+
+	assert.Equal(t, ErrSessionIdle, <-closed, "Expected idle session to be closed")
+}
+
+func TestPoolConcurrentIdleSessions(t *testing.T) {
+
+	// Sessions are repeatedly released and acquired while the pool closes them for being idle, or to make room
+	// for sessions to other targets; none should be closed while in use.
+	var lock sync.Mutex
+	closed := make(map[OpSession]bool)
+	var failures []string
+	dialer := func(ctx context.Context, target string) (OpSession, error) {
+		ms := &mocks.OpSession{}
+		s := &sImpl{ms}
+		ms.On("Close").Run(func(mock.Arguments) {
+			lock.Lock()
+			defer lock.Unlock()
+			closed[s] = true
+		})
+		return s, nil
+	}
+	check := func(s OpSession, err error) {
+		lock.Lock()
+		defer lock.Unlock()
+		if err != nil {
+			failures = append(failures, err.Error())
+		} else if closed[s] {
+			failures = append(failures, "session in use closed")
+		}
+	}
+
+	cfg := &PoolConfig{MaxSessions: 2, IdleTimeout: time.Millisecond, HealthCheckInterval: time.Millisecond,
+		HealthCheck: func(ctx context.Context, s OpSession) error { return nil }}
+	p := NewSessionPool(context.Background(), dialer, cfg)
+	defer p.Close()
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(target string) {
+			defer wg.Done()
+			for j := 0; j < 200; j++ {
+				s, err := p.Acquire(context.Background(), target, j%2 == 0)
+				if err != nil {
+					check(nil, err)
+					continue
+				}
+				check(s.OpSession, nil)
+				time.Sleep(time.Microsecond * time.Duration(100))
+				check(s.OpSession, nil)
+				s.Release()
+			}
+		}(fmt.Sprintf("target%d", i%3))
+	}
+	wg.Wait()
+	assert.Empty(t, failures, "Not expecting sessions in use to be closed")
+}
+
+func TestPoolHealthCheckFailure(t *testing.T) {
+
+	var lock sync.Mutex
+	var checks []error
+	closed := make(chan error, 1)
+	trace := &PoolTrace{
+		HealthCheckDone: func(target string, err error, d time.Duration) {
+			lock.Lock()
+			defer lock.Unlock()
+			checks = append(checks, err)
+		},
+		SessionClosed: func(target string, reason error) {
+			closed <- reason
+		},
+	}
+
+	healthy := true
+	dialer, _ := newMockPoolDialer()
+	cfg := &PoolConfig{
+		HealthCheckInterval: time.Millisecond * time.Duration(20),
+		HealthCheck: func(ctx context.Context, s OpSession) error {
+			lock.Lock()
+			defer lock.Unlock()
+			if healthy {
+				return nil
+			}
+			return errors.New("unhealthy")
+		},
+	}
+	p := NewSessionPool(WithPoolTrace(context.Background(), trace), dialer, cfg)
+	defer p.Close()
+
+	s, err := p.Acquire(context.Background(), "target", false)
+	assert.NoError(t, err, "Not expecting acquire to fail")
+	s.Release()
+
+	time.Sleep(time.Millisecond * time.Duration(100))
+	lock.Lock()
+	healthy = false
+	lock.Unlock()
+	assert.EqualError(t, <-closed, "unhealthy", "Expected unhealthy session to be closed")
+
+	lock.Lock()
+	defer lock.Unlock()
+	assert.True(t, len(checks) > 1, "Expected health checks")
+	assert.NoError(t, checks[0], "Expected successful health check")
+	assert.Error(t, checks[len(checks)-1], "Expected failed health check")
+}
+
+func TestPoolDefaultHealthCheck(t *testing.T) {
+
+	checked := make(chan error, 1)
+	trace := &PoolTrace{
+		HealthCheckDone: func(target string, err error, d time.Duration) {
+			checked <- err
+		},
+	}
+
+	dialer, _ := newMockPoolDialer()
+	p := NewSessionPool(WithPoolTrace(context.Background(), trace), dialer, &PoolConfig{HealthCheckInterval: time.Millisecond * time.Duration(20)})
+
+	s, err := p.Acquire(context.Background(), "target", false)
+	assert.NoError(t, err, "Not expecting acquire to fail")
+	s.Release()
+
+	assert.NoError(t, <-checked, "Expected successful health check")
+	p.Close()
+	s.OpSession.(*sImpl).Session.(*mocks.OpSession).AssertCalled(t, "ExecuteContext", mock.Anything, createGetSubtreeRequest(""))
+}
+
+func TestPoolClose(t *testing.T) {
+
+	var stats PoolStats
+	closed := make(chan error, 2)
+	trace := &PoolTrace{
+		SessionClosed: func(target string, reason error) {
+			closed <- reason
+		},
+		StatsChanged: func(s PoolStats) {
+			stats = s
+		},
+	}
+
+	dialer, _ := newMockPoolDialer()
+	p := NewSessionPool(WithPoolTrace(context.Background(), trace), dialer, &PoolConfig{})
+
+	s1, err := p.Acquire(context.Background(), "target1", true)
+	assert.NoError(t, err, "Not expecting acquire to fail")
+	_, err = p.Acquire(context.Background(), "target2", true)
+	assert.NoError(t, err, "Not expecting acquire to fail")
+	assert.Equal(t, PoolStats{Sessions: 2, InUse: 2}, stats, "Expected pool stats")
+
+	s1.Release()
+	assert.Equal(t, PoolStats{Sessions: 2, InUse: 1}, stats, "Expected pool stats")
+
+	p.Close()
+	assert.Equal(t, ErrPoolClosed, <-closed, "Expected session to be closed")
+	assert.Equal(t, ErrPoolClosed, <-closed, "Expected session to be closed")
+	assert.Equal(t, PoolStats{}, stats, "Expected pool stats")
+
+	_, err = p.Acquire(context.Background(), "target1", false)
+	assert.Equal(t, ErrPoolClosed, err, "Expected acquire to fail")
+}
+
+func TestPoolSessionClose(t *testing.T) {
+
+	dialer, dialled := newMockPoolDialer()
+	p := NewSessionPool(context.Background(), dialer, &PoolConfig{})
+	defer p.Close()
+
+	s1, err := p.Acquire(context.Background(), "target", true)
+	assert.NoError(t, err, "Not expecting acquire to fail")
+	s1.Close()
+
+	s2, err := p.Acquire(context.Background(), "target", true)
+	assert.NoError(t, err, "Not expecting acquire to fail")
+	assert.NotEqual(t, s1.OpSession, s2.OpSession, "Expected a new session")
+	assert.Equal(t, 2, dialled("target"), "Expected a new session to be opened")
+	s2.Release()
+}
+
+func TestPoolDialFailure(t *testing.T) {
+
+	dialer := func(ctx context.Context, target string) (OpSession, error) {
+		return nil, errors.New("unreachable")
+	}
+	p := NewSessionPool(context.Background(), dialer, &PoolConfig{})
+	defer p.Close()
+
+	_, err := p.Acquire(context.Background(), "target", false)
+	assert.EqualError(t, err, "unreachable", "Expected acquire to fail")
+}
+
+func TestPoolSSHSessions(t *testing.T) {
+
+	ts1 := testserver.NewTestNetconfServer(t)
+	defer ts1.Close()
+	ts2 := testserver.NewTestNetconfServer(t)
+	defer ts2.Close()
+
+	sshConfig := &ssh.ClientConfig{
+		User:            testserver.TestUserName,
+		Auth:            []ssh.AuthMethod{ssh.Password(testserver.TestPassword)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}
+
+	p := NewSessionPool(context.Background(), SSHPoolDialer(sshConfig, client.DefaultConfig), DefaultPoolConfig)
+	defer p.Close()
+
+	for _, ts := range []*testserver.TestNCServer{ts1, ts2} {
+		s, err := p.Acquire(context.Background(), fmt.Sprintf("localhost:%d", ts.Port()), false)
+		assert.NoError(t, err, "Not expecting acquire to fail")
+
+		var result string
+		err = s.GetSubtree(`<response/>`, &result)
+		assert.NoError(t, err, "Not expecting get to fail")
+		assert.Equal(t, `<filter type="subtree"><response/></filter>`, result, "Reply should echo request")
+		s.Release()
+	}
+}
+
+// newMockPoolDialer delivers a dialer that opens mock sessions, and a function that reports the number of sessions
+// opened to a target.
+func newMockPoolDialer() (PoolDialer, func(target string) int) {
+	var lock sync.Mutex
+	dialled := make(map[string]int)
+	dialer := func(ctx context.Context, target string) (OpSession, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		dialled[target]++
+		ms := &mocks.OpSession{}
+		ms.On("ExecuteContext", mock.Anything, mock.Anything).Return(&common.RPCReply{Data: `<data/>`}, nil)
+		ms.On("Close")
+		return &sImpl{ms}, nil
+	}
+	return dialer, func(target string) int {
+		lock.Lock()
+		defer lock.Unlock()
+		return dialled[target]
+	}
+}
@@ -0,0 +1,111 @@
+package ops
+
+import (
+	"context"
+	"log"
+	"time"
+
+	"github.com/imdario/mergo"
+)
+
+// unique type to prevent assignment.
+type poolEventContextKey struct{}
+
+// ContextPoolTrace returns the PoolTrace associated with the
+// provided context. If none, it returns NoOpPoolHooks.
+func ContextPoolTrace(ctx context.Context) *PoolTrace {
+	trace, _ := ctx.Value(poolEventContextKey{}).(*PoolTrace)
+	if trace == nil {
+		trace = NoOpPoolHooks
+	} else {
+		_ = mergo.Merge(trace, NoOpPoolHooks) // nolint: gosec, errcheck
+	}
+	return trace
+}
+
+// WithPoolTrace returns a new context based on the provided parent
+// ctx. Session pools created with the returned context will use
+// the provided trace hooks
+func WithPoolTrace(ctx context.Context, trace *PoolTrace) context.Context {
+	return context.WithValue(ctx, poolEventContextKey{}, trace)
+}
+
+// PoolStats describes the sessions held by a session pool.
+type PoolStats struct {
+	// Sessions is the number of open sessions, including those being opened.
+	Sessions int
+	// InUse is the number of sessions acquired by at least one user.
+	InUse int
+	// Waiting is the number of users waiting to acquire a session.
+	Waiting int
+}
+
+// PoolTrace defines a structure for handling session pool trace events
+type PoolTrace struct {
+	// SessionOpened is called when an attempt to open a session to the target completes, with err
+	// indicating whether it was successful.
+	SessionOpened func(target string, err error, d time.Duration)
+
+	// SessionClosed is called when a session to the target is closed by the pool, with reason indicating why.
+	// The reason is nil if the session was closed by its user.
+	SessionClosed func(target string, reason error)
+
+	// SessionAcquired is called when an attempt to acquire a session to the target completes, with err
+	// indicating whether it was successful, and d the time spent waiting.
+	SessionAcquired func(target string, exclusive bool, err error, d time.Duration)
+
+	// SessionReleased is called when a session to the target is returned to the pool.
+	SessionReleased func(target string)
+
+	// HealthCheckDone is called after a health check of an idle session to the target.
+	HealthCheckDone func(target string, err error, d time.Duration)
+
+	// StatsChanged is called when the number of sessions held by the pool, or their use, changes.
+	StatsChanged func(stats PoolStats)
+}
+
+// DefaultPoolLoggingHooks provides a default logging hook to report session failures.
+var DefaultPoolLoggingHooks = &PoolTrace{
+	SessionOpened: func(target string, err error, d time.Duration) {
+		if err != nil {
+			log.Printf("NETCONF-Pool-SessionOpened target:%s err:%v\n", target, err)
+		}
+	},
+	HealthCheckDone: func(target string, err error, d time.Duration) {
+		if err != nil {
+			log.Printf("NETCONF-Pool-HealthCheckDone target:%s err:%v\n", target, err)
+		}
+	},
+}
+
+// DiagnosticPoolLoggingHooks provides a set of default diagnostic hooks
+var DiagnosticPoolLoggingHooks = &PoolTrace{
+	SessionOpened: func(target string, err error, d time.Duration) {
+		log.Printf("NETCONF-Pool-SessionOpened target:%s err:%v took:%dms\n", target, err, d.Milliseconds())
+	},
+	SessionClosed: func(target string, reason error) {
+		log.Printf("NETCONF-Pool-SessionClosed target:%s reason:%v\n", target, reason)
+	},
+	SessionAcquired: func(target string, exclusive bool, err error, d time.Duration) {
+		log.Printf("NETCONF-Pool-SessionAcquired target:%s exclusive:%v err:%v waited:%dms\n", target, exclusive, err, d.Milliseconds())
+	},
+	SessionReleased: func(target string) {
+		log.Printf("NETCONF-Pool-SessionReleased target:%s\n", target)
+	},
+	HealthCheckDone: func(target string, err error, d time.Duration) {
+		log.Printf("NETCONF-Pool-HealthCheckDone target:%s err:%v took:%dms\n", target, err, d.Milliseconds())
+	},
+	StatsChanged: func(stats PoolStats) {
+		log.Printf("NETCONF-Pool-StatsChanged sessions:%d in-use:%d waiting:%d\n", stats.Sessions, stats.InUse, stats.Waiting)
+	},
+}
+
+// NoOpPoolHooks provides set of hooks that do nothing.
+var NoOpPoolHooks = &PoolTrace{
+	SessionOpened:   func(target string, err error, d time.Duration) {},
+	SessionClosed:   func(target string, reason error) {},
+	SessionAcquired: func(target string, exclusive bool, err error, d time.Duration) {},
+	SessionReleased: func(target string) {},
+	HealthCheckDone: func(target string, err error, d time.Duration) {},
+	StatsChanged:    func(stats PoolStats) {},
+}
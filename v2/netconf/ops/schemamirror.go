@@ -0,0 +1,285 @@
+package ops
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+)
+
+// Defines the mirroring of the YANG schemas supported by a device into a local directory, using get-schema
+// (RFC 6022), with the list of schemas taken from the YANG library (RFC 8525) if the device supports it, or from
+// the netconf-state schemas otherwise.
+
+// YangLibraryNS is the namespace of the ietf-yang-library module.
+const YangLibraryNS = "urn:ietf:params:xml:ns:yang:ietf-yang-library"
+
+// YangLibrary is the yang-library container of the ietf-yang-library module (RFC 8525).
+type YangLibrary struct {
+	XMLName    xml.Name        `xml:"urn:ietf:params:xml:ns:yang:ietf-yang-library yang-library"`
+	ModuleSets []YangModuleSet `xml:"module-set"`
+	ContentID  string          `xml:"content-id"`
+}
+
+// YangModuleSet is a set of modules implemented, or imported, by a device.
+type YangModuleSet struct {
+	Name              string              `xml:"name"`
+	Modules           []YangLibraryModule `xml:"module"`
+	ImportOnlyModules []YangLibraryModule `xml:"import-only-module"`
+}
+
+// YangLibraryModule describes a module, or a submodule, in the YANG library.
+type YangLibraryModule struct {
+	Name       string              `xml:"name"`
+	Revision   string              `xml:"revision"`
+	Namespace  string              `xml:"namespace"`
+	Locations  []string            `xml:"location"`
+	Features   []string            `xml:"feature"`
+	Deviations []string            `xml:"deviation"`
+	Submodules []YangLibraryModule `xml:"submodule"`
+}
+
+// MirrorResult describes the outcome of mirroring schemas.
+type MirrorResult struct {
+	// Downloaded lists the files retrieved from the device.
+	Downloaded []string
+	// Cached lists the files that were already present, with the same revision (or the latest revision, if the
+	// revision is not known).
+	Cached []string
+	// Failed holds the errors for the schemas that could not be retrieved, keyed by module name.
+	Failed map[string]error
+}
+
+// MirrorSchemas retrieves the YANG modules and submodules supported by the device into dir, as files named
+// module@revision.yang (or module.yang if the revision is not known), together with the modules and submodules
+// they import or include. Files already present in dir are not retrieved again.
+// The schemas that could not be retrieved are reported by the result; an error is returned if the list of schemas
+// cannot be retrieved, or the files cannot be written.
+func MirrorSchemas(ctx context.Context, s OpSession, dir string) (*MirrorResult, error) {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, err
+	}
+	m := &schemaMirror{ctx: ctx, s: s, dir: dir, result: &MirrorResult{Failed: map[string]error{}},
+		revisions: map[string]string{}, queued: map[string]bool{}}
+
+	var modules []mirrorModule
+	var err error
+	if s.Capabilities().Supports(common.CapYangLibrary) {
+		modules, err = m.listYangLibrary()
+	} else {
+		modules, err = m.listNetconfState()
+	}
+	if err != nil {
+		return nil, err
+	}
+	for _, mod := range modules {
+		m.revisions[mod.name] = mod.revision
+	}
+	for _, mod := range modules {
+		m.enqueue(mod)
+	}
+	for len(m.queue) > 0 {
+		mod := m.queue[0]
+		m.queue = m.queue[1:]
+		if err = m.mirror(mod); err != nil {
+			return m.result, err
+		}
+	}
+	return m.result, nil
+}
+
+type mirrorModule struct {
+	name     string
+	revision string
+}
+
+type schemaMirror struct {
+	ctx       context.Context
+	s         OpSession
+	dir       string
+	result    *MirrorResult
+	revisions map[string]string
+	queued    map[string]bool
+	queue     []mirrorModule
+}
+
+func (m *schemaMirror) listYangLibrary() ([]mirrorModule, error) {
+	yl := &YangLibrary{}
+	if err := m.s.GetSubtreeContext(m.ctx, `<yang-library xmlns="`+YangLibraryNS+`"/>`, yl); err != nil {
+		return nil, err
+	}
+	var modules []mirrorModule
+	add := func(lm YangLibraryModule) {
+		modules = append(modules, mirrorModule{name: lm.Name, revision: lm.Revision})
+		for _, sm := range lm.Submodules {
+			modules = append(modules, mirrorModule{name: sm.Name, revision: sm.Revision})
+		}
+	}
+	for _, set := range yl.ModuleSets {
+		for _, lm := range set.Modules {
+			add(lm)
+		}
+		for _, lm := range set.ImportOnlyModules {
+			add(lm)
+		}
+	}
+	return modules, nil
+}
+
+func (m *schemaMirror) listNetconfState() ([]mirrorModule, error) {
+	schemas, err := m.s.GetSchemasContext(m.ctx)
+	if err != nil {
+		return nil, err
+	}
+	var modules []mirrorModule
+	for _, schema := range schemas {
+		if schema.Format != "yang" && !strings.HasSuffix(schema.Format, ":yang") {
+			continue
+		}
+		if !availableThroughNetconf(schema.Locations) {
+			m.result.Failed[schema.Identifier] = fmt.Errorf("%w: schema %s is only available from %s", ErrNotSupported,
+				schema.Identifier, strings.Join(schema.Locations, ", "))
+			continue
+		}
+		modules = append(modules, mirrorModule{name: schema.Identifier, revision: schema.Version})
+	}
+	return modules, nil
+}
+
+func availableThroughNetconf(locations []string) bool {
+	for _, l := range locations {
+		if l == "NETCONF" {
+			return true
+		}
+	}
+	return len(locations) == 0
+}
+
+// enqueue adds the module to those to be mirrored, unless it is already queued. A module without a revision
+// is queued unless some revision of it is.
+func (m *schemaMirror) enqueue(mod mirrorModule) {
+	if m.queued[mod.name+"@"+mod.revision] || (mod.revision == "" && m.queued[mod.name]) {
+		return
+	}
+	m.queued[mod.name+"@"+mod.revision] = true
+	m.queued[mod.name] = true
+	m.queue = append(m.queue, mod)
+}
+
+// mirror retrieves the module, unless it is cached, and queues its dependencies.
+func (m *schemaMirror) mirror(mod mirrorModule) error {
+	text, cached := m.cached(mod)
+	if !cached {
+		var err error
+		if text, err = m.s.GetSchemaContext(m.ctx, mod.name, mod.revision, "yang"); err != nil {
+			m.result.Failed[mod.name] = err
+			return nil
+		}
+		file := schemaFileName(mod.name, mod.revision)
+		if mod.revision == "" {
+			file = schemaFileName(mod.name, latestRevision(text))
+		}
+		if err = ioutil.WriteFile(filepath.Join(m.dir, file), []byte(text), 0644); err != nil {
+			return err
+		}
+		m.result.Downloaded = append(m.result.Downloaded, file)
+	}
+
+	deps, err := yangDependencies(text)
+	if err != nil {
+		m.result.Failed[mod.name] = err
+		return nil
+	}
+	for _, dep := range deps {
+		if dep.revision == "" {
+			dep.revision = m.revisions[dep.name]
+		}
+		m.enqueue(dep)
+	}
+	return nil
+}
+
+// cached delivers the content of the module's file, if it is present. If the revision is not known, the file with
+// the latest revision of the module is used, or the file without a revision.
+func (m *schemaMirror) cached(mod mirrorModule) (string, bool) {
+	file := schemaFileName(mod.name, mod.revision)
+	if mod.revision == "" {
+		if file = m.latestFile(mod.name); file == "" {
+			return "", false
+		}
+	}
+	b, err := ioutil.ReadFile(filepath.Join(m.dir, file))
+	if err != nil {
+		return "", false
+	}
+	m.result.Cached = append(m.result.Cached, file)
+	return string(b), true
+}
+
+// latestFile delivers the name of the file holding the latest revision of the module in the directory, or of the
+// file without a revision, or an empty string if there is neither.
+func (m *schemaMirror) latestFile(name string) string {
+	files, err := ioutil.ReadDir(m.dir)
+	if err != nil {
+		return ""
+	}
+	var latest string
+	for _, f := range files {
+		// Files are sorted by name, so a later revision follows an earlier one.
+		if f.Mode().IsRegular() && strings.HasPrefix(f.Name(), name+"@") && strings.HasSuffix(f.Name(), ".yang") {
+			latest = f.Name()
+		}
+	}
+	if latest == "" {
+		if _, err = os.Stat(filepath.Join(m.dir, schemaFileName(name, ""))); err == nil {
+			latest = schemaFileName(name, "")
+		}
+	}
+	return latest
+}
+
+func schemaFileName(name, revision string) string {
+	if revision == "" {
+		return name + ".yang"
+	}
+	return name + "@" + revision + ".yang"
+}
+
+// yangDependencies delivers the modules imported, and the submodules included, by a YANG module or submodule.
+func yangDependencies(text string) ([]mirrorModule, error) {
+	stmts, err := parseYang(text)
+	if err != nil {
+		return nil, err
+	}
+	var deps []mirrorModule
+	for _, s := range stmts {
+		for _, sub := range s.subs {
+			if sub.keyword == "import" || sub.keyword == "include" {
+				deps = append(deps, mirrorModule{name: sub.arg, revision: sub.sub("revision-date")})
+			}
+		}
+	}
+	return deps, nil
+}
+
+// latestRevision delivers the most recent revision of a YANG module, or an empty string if it has none.
+func latestRevision(text string) string {
+	stmts, err := parseYang(text)
+	if err != nil {
+		return ""
+	}
+	var latest string
+	for _, s := range stmts {
+		for _, sub := range s.subs {
+			if sub.keyword == "revision" && sub.arg > latest {
+				latest = sub.arg
+			}
+		}
+	}
+	return latest
+}
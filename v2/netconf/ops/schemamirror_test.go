@@ -0,0 +1,140 @@
+package ops
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+const yangLibraryReply = `<data><yang-library xmlns="urn:ietf:params:xml:ns:yang:ietf-yang-library">
+  <module-set>
+    <name>complete</name>
+    <module>
+      <name>example</name><revision>2020-01-01</revision><namespace>urn:example</namespace>
+      <submodule><name>example-types</name><revision>2020-01-01</revision></submodule>
+    </module>
+    <import-only-module><name>ietf-yang-types</name><revision>2013-07-15</revision><namespace>urn:yang-types</namespace></import-only-module>
+  </module-set>
+  <content-id>1</content-id>
+</yang-library></data>`
+
+const exampleModule = `module example {
+  namespace "urn:example";
+  prefix ex;
+  import ietf-yang-types { prefix yang; revision-date 2013-07-15; }
+  import ietf-inet-types { prefix inet; }
+  include example-types;
+  revision 2020-01-01;
+  leaf address { type string { pattern '[a-z]+&lt;'; } }
+}`
+
+func schemaReply(text string) *common.RPCReply {
+	return &common.RPCReply{Data: `<data xmlns="urn:ietf:params:xml:ns:yang:ietf-netconf-monitoring">` + text + `</data>`}
+}
+
+func TestMirrorSchemasFromYangLibrary(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "schemas")
+	assert.NoError(t, err, "Not expecting temp dir to fail")
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "ietf-yang-types@2013-07-15.yang"), []byte(`module ietf-yang-types { }`), 0644))
+
+	ctx := context.Background()
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("Capabilities").Return(common.ParseCapabilities([]string{common.CapBase11, common.CapYangLibrary}))
+	mcli.On("ExecuteContext", ctx, createGetSubtreeRequest(`<yang-library xmlns="urn:ietf:params:xml:ns:yang:ietf-yang-library"/>`)).
+		Return(&common.RPCReply{Data: yangLibraryReply}, nil)
+	mcli.On("ExecuteContext", ctx, createGetShemaRequest("example", "2020-01-01", "yang")).Return(schemaReply(exampleModule), nil)
+	mcli.On("ExecuteContext", ctx, createGetShemaRequest("example-types", "2020-01-01", "yang")).
+		Return(schemaReply(`submodule example-types { belongs-to example { prefix ex; } }`), nil)
+	mcli.On("ExecuteContext", ctx, createGetShemaRequest("ietf-inet-types", "", "yang")).
+		Return(schemaReply(`module ietf-inet-types { revision 2010-09-24; revision 2013-07-15; }`), nil).Once()
+
+	result, err := MirrorSchemas(ctx, ncs, dir)
+	assert.NoError(t, err, "Not expecting mirror to fail")
+	assert.Equal(t, []string{"example@2020-01-01.yang", "example-types@2020-01-01.yang", "ietf-inet-types@2013-07-15.yang"},
+		result.Downloaded, "Expected schemas to be downloaded")
+	assert.Equal(t, []string{"ietf-yang-types@2013-07-15.yang"}, result.Cached, "Expected cached schema")
+	assert.Empty(t, result.Failed, "Not expecting failures")
+
+	text, err := ioutil.ReadFile(filepath.Join(dir, "example@2020-01-01.yang"))
+	assert.NoError(t, err, "Expected schema file")
+	assert.Contains(t, string(text), `pattern '[a-z]+<';`, "Expected schema text to be unescaped")
+
+	// A second mirror finds everything in the cache, including the latest revision of the module imported without one.
+	result, err = MirrorSchemas(ctx, ncs, dir)
+	assert.NoError(t, err, "Not expecting mirror to fail")
+	assert.Empty(t, result.Downloaded, "Not expecting schemas to be downloaded")
+	assert.Equal(t, []string{"example@2020-01-01.yang", "example-types@2020-01-01.yang", "ietf-yang-types@2013-07-15.yang",
+		"ietf-inet-types@2013-07-15.yang"}, result.Cached, "Expected cached schemas")
+
+	mcli.AssertExpectations(t)
+}
+
+func TestMirrorSchemasFromNetconfState(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "schemas")
+	assert.NoError(t, err, "Not expecting temp dir to fail")
+	defer os.RemoveAll(dir)
+
+	ctx := context.Background()
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("Capabilities").Return(common.ParseCapabilities([]string{common.CapBase11}))
+	mcli.On("ExecuteContext", ctx, createGetShemasRequest()).Return(&common.RPCReply{Data: `<data>
+	<netconf-state xmlns="urn:ietf:params:xml:ns:yang:ietf-netconf-monitoring"><schemas>
+	<schema><identifier>local</identifier><version>2019-01-01</version><format>yang</format><namespace>urn:local</namespace>
+	  <location>NETCONF</location><location>http://example.com/local.yang</location></schema>
+	<schema><identifier>remote</identifier><version>2019-01-01</version><format>yang</format><namespace>urn:remote</namespace>
+	  <location>http://example.com/remote.yang</location></schema>
+	<schema><identifier>local</identifier><version>2019-01-01</version><format>yin</format><namespace>urn:local</namespace>
+	  <location>NETCONF</location></schema>
+	<schema><identifier>broken</identifier><version>2019-01-01</version><format>ncm:yang</format><namespace>urn:broken</namespace></schema>
+	</schemas></netconf-state></data>`}, nil)
+	mcli.On("ExecuteContext", ctx, createGetShemaRequest("local", "2019-01-01", "yang")).Return(schemaReply(`module local { }`), nil)
+	mcli.On("ExecuteContext", ctx, createGetShemaRequest("broken", "2019-01-01", "yang")).Return(nil, errors.New("failed"))
+
+	schemas, err := ncs.GetSchemas()
+	assert.NoError(t, err, "Not expecting get schemas to fail")
+	assert.Equal(t, []string{"NETCONF", "http://example.com/local.yang"}, schemas[0].Locations, "Expected all locations")
+	assert.Equal(t, "NETCONF", schemas[0].Location, "Expected first location")
+
+	result, err := MirrorSchemas(ctx, ncs, filepath.Join(dir, "sub"))
+	assert.NoError(t, err, "Not expecting mirror to fail")
+	assert.Equal(t, []string{"local@2019-01-01.yang"}, result.Downloaded, "Expected schema to be downloaded")
+	assert.Len(t, result.Failed, 2, "Expected failures")
+	assert.True(t, errors.Is(result.Failed["remote"], ErrNotSupported), "Expected remote schema to be unavailable")
+	assert.EqualError(t, result.Failed["broken"], "failed", "Expected get-schema to fail")
+
+	mcli.AssertExpectations(t)
+}
+
+func TestMirrorSchemasCachedWithoutRevision(t *testing.T) {
+
+	dir, err := ioutil.TempDir("", "schemas")
+	assert.NoError(t, err, "Not expecting temp dir to fail")
+	defer os.RemoveAll(dir)
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "local.yang"), []byte(`module local { }`), 0644))
+	assert.NoError(t, ioutil.WriteFile(filepath.Join(dir, "local-ext@2020-01-01.yang"), []byte(`module local-ext { }`), 0644))
+
+	ctx := context.Background()
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("Capabilities").Return(common.ParseCapabilities([]string{common.CapBase11}))
+	mcli.On("ExecuteContext", ctx, createGetShemasRequest()).Return(&common.RPCReply{Data: `<data>
+	<netconf-state xmlns="urn:ietf:params:xml:ns:yang:ietf-netconf-monitoring"><schemas>
+	<schema><identifier>local</identifier><version></version><format>yang</format><namespace>urn:local</namespace></schema>
+	</schemas></netconf-state></data>`}, nil)
+
+	result, err := MirrorSchemas(ctx, ncs, dir)
+	assert.NoError(t, err, "Not expecting mirror to fail")
+	assert.Empty(t, result.Downloaded, "Not expecting schemas to be downloaded")
+	assert.Equal(t, []string{"local.yang"}, result.Cached, "Expected schema without revision to be cached")
+
+	mcli.AssertExpectations(t)
+}
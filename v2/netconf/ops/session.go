@@ -1,15 +1,20 @@
 package ops
 
 import (
+	"context"
 	"encoding/xml"
+	"errors"
 	"fmt"
-	"strings"
 
 	"github.com/damianoneill/net/v2/netconf/client"
 
 	"github.com/damianoneill/net/v2/netconf/common"
 )
 
+// ErrNotSupported is returned when an operation, or one of its options, requires a capability that is not
+// supported by the server.
+var ErrNotSupported = errors.New("operation not supported by server")
+
 type Namespace struct {
 	Id   string
 	Path string
@@ -23,51 +28,152 @@ type OpSession interface {
 	// should be the address of either:
 	// - a string, in which case it will hold the response body, or
 	// - a struct with xml tags.
-	GetSubtree(filter interface{}, result interface{}) error
+	// The filter may be an xml string, a struct with xml tags, or built from FilterNodes (see FilterTree and
+	// FilterFromStruct).
+	// RetrievalOptions can be added to qualify the data retrieved, e.g. WithDefaults(mode).
+	GetSubtree(filter interface{}, result interface{}, options ...RetrievalOption) error
+
+	// GetSubtreeContext is equivalent to GetSubtree, but returns ctx.Err() if ctx is done before the reply is received.
+	GetSubtreeContext(ctx context.Context, filter interface{}, result interface{}, options ...RetrievalOption) error
+
+	// GetSubtreeStream issues a GET request, with the supplied subtree filter, and passes each top-level element of
+	// the response to the handler as it is received, so that very large responses can be processed in constant memory.
+	// See client.Session.ExecuteStream for the restrictions on the handler.
+	GetSubtreeStream(ctx context.Context, filter interface{}, handler client.DataHandler, options ...RetrievalOption) error
 
 	// GetXpath issues a GET request, with the supplied xpath filter and namespace list and stores the response in the result, which
 	// should be the address of either:
 	// - a string, in which case it will hold the response body, or
 	// - a struct with xml tags.
-	GetXpath(xpath string, nslist []Namespace, result interface{}) error
+	// The xpath filter is validated before the request is sent; see NewXPathFilter.
+	GetXpath(xpath string, nslist []Namespace, result interface{}, options ...RetrievalOption) error
+
+	// GetXpathContext is equivalent to GetXpath, but returns ctx.Err() if ctx is done before the reply is received.
+	GetXpathContext(ctx context.Context, xpath string, nslist []Namespace, result interface{}, options ...RetrievalOption) error
+
+	// GetXpathFilter is equivalent to GetXpathContext, but takes the filter, e.g. as delivered by
+	// ModuleRegistry.XPathFilter, which is validated before the request is sent.
+	GetXpathFilter(ctx context.Context, filter *XPathFilter, result interface{}, options ...RetrievalOption) error
 
 	// GetConfigSubtree issues a GET-CONFIG request, with the supplied subtree filter and source, and stores the
 	// response in the result, which should be the address of either:
 	// - a string, in which case it will hold the response body, or
 	// - a struct with xml tags.
-	GetConfigSubtree(filter interface{}, source string, result interface{}) error
+	// RetrievalOptions can be added to qualify the data retrieved, as for GetSubtree.
+	GetConfigSubtree(filter interface{}, source string, result interface{}, options ...RetrievalOption) error
+
+	// GetConfigSubtreeContext is equivalent to GetConfigSubtree, but returns ctx.Err() if ctx is done before the reply is received.
+	GetConfigSubtreeContext(ctx context.Context, filter interface{}, source string, result interface{}, options ...RetrievalOption) error
+
+	// GetConfigSubtreeStream issues a GET-CONFIG request, with the supplied subtree filter and source, and passes each
+	// top-level element of the response to the handler as it is received, as for GetSubtreeStream.
+	GetConfigSubtreeStream(ctx context.Context, filter interface{}, source string, handler client.DataHandler, options ...RetrievalOption) error
 
 	// GetConfigXpath issues a GET-CONFIG request, with the supplied xpath filter, source and namespace list and stores the
 	// response in the result, which should be the address of either:
 	// - a string, in which case it will hold the response body, or
 	// - a struct with xml tags.
-	GetConfigXpath(xpath string, nslist []Namespace, source string, result interface{}) error
+	// The xpath filter, if any, is validated before the request is sent; see NewXPathFilter.
+	GetConfigXpath(xpath string, nslist []Namespace, source string, result interface{}, options ...RetrievalOption) error
+
+	// GetConfigXpathContext is equivalent to GetConfigXpath, but returns ctx.Err() if ctx is done before the reply is received.
+	GetConfigXpathContext(ctx context.Context, xpath string, nslist []Namespace, source string, result interface{}, options ...RetrievalOption) error
+
+	// GetConfigXpathFilter is equivalent to GetConfigXpathContext, but takes the filter, if any, e.g. as delivered by
+	// ModuleRegistry.XPathFilter, which is validated before the request is sent.
+	GetConfigXpathFilter(ctx context.Context, filter *XPathFilter, source string, result interface{}, options ...RetrievalOption) error
 
 	// GetSchemas returns an array of schemas supported by the device.
 	GetSchemas() ([]Schema, error)
 
+	// GetSchemasContext is equivalent to GetSchemas, but returns ctx.Err() if ctx is done before the reply is received.
+	GetSchemasContext(ctx context.Context) ([]Schema, error)
+
 	// GetSchema returns the text of the schema identified by id and version, in the format defined by fmt.
 	GetSchema(id, version, fmt string) (string, error)
 
+	// GetSchemaContext is equivalent to GetSchema, but returns ctx.Err() if ctx is done before the reply is received.
+	GetSchemaContext(ctx context.Context, id, version, fmt string) (string, error)
+
+	// GetStreams returns an array of the event streams supported by the device.
+	GetStreams() ([]Stream, error)
+
+	// GetStreamsContext is equivalent to GetStreams, but returns ctx.Err() if ctx is done before the reply is received.
+	GetStreamsContext(ctx context.Context) ([]Stream, error)
+
+	// CreateSubscription issues a create-subscription request (RFC 5277) and, if successful, sends the notifications
+	// delivered by the subscription to nchan. SubscriptionOptions can be added to define the event stream,
+	// filter, and replay start and stop times.
+	// The progress of a replay is reported by notifications of type common.ReplayComplete and
+	// common.NotificationComplete. The subscription is ended using Unsubscribe(nchan).
+	CreateSubscription(nchan chan *common.Notification, options ...SubscriptionOption) error
+
+	// CreateSubscriptionContext is equivalent to CreateSubscription, but returns ctx.Err() if ctx is done before the
+	// reply is received.
+	CreateSubscriptionContext(ctx context.Context, nchan chan *common.Notification, options ...SubscriptionOption) error
+
+	// EstablishSubscription issues an establish-subscription request for a datastore subscription (RFC 8639/8641)
+	// and, if successful, returns the subscription id and sends the notifications delivered by the subscription to
+	// nchan. PushOptions must be added to define the datastore, selection filter and update trigger.
+	// The subscription is ended using DeleteSubscription(id), after which nchan should be released with Unsubscribe.
+	EstablishSubscription(nchan chan *common.Notification, options ...PushOption) (string, error)
+
+	// EstablishSubscriptionContext is equivalent to EstablishSubscription, but returns ctx.Err() if ctx is done before
+	// the reply is received.
+	EstablishSubscriptionContext(ctx context.Context, nchan chan *common.Notification, options ...PushOption) (string, error)
+
+	// ModifySubscription issues a modify-subscription request, to change the selection filter, update trigger or
+	// stop time of the subscription identified by id.
+	ModifySubscription(id string, options ...PushOption) error
+
+	// ModifySubscriptionContext is equivalent to ModifySubscription, but returns ctx.Err() if ctx is done before the
+	// reply is received.
+	ModifySubscriptionContext(ctx context.Context, id string, options ...PushOption) error
+
+	// DeleteSubscription issues a delete-subscription request, to end a subscription established by the session.
+	DeleteSubscription(id string) error
+
+	// DeleteSubscriptionContext is equivalent to DeleteSubscription, but returns ctx.Err() if ctx is done before the
+	// reply is received.
+	DeleteSubscriptionContext(ctx context.Context, id string) error
+
+	// KillSubscription issues a kill-subscription request, to end a subscription established by any session.
+	KillSubscription(id string) error
+
+	// KillSubscriptionContext is equivalent to KillSubscription, but returns ctx.Err() if ctx is done before the
+	// reply is received.
+	KillSubscriptionContext(ctx context.Context, id string) error
+
 	// EditConfig issues an edit-config request defined by config to be applied to the target configuration.
 	// EditOptions can be added to qualify the operation.
 	// config will be defined by a ConfigOption, which can be one of:
 	// - Cfg(cfg), where cfg is
 	//   o   an xml string, in which case it will be used verbatim as the content of the <config> element.
 	//   o   a struct with xml tags that will be marshalled as the child of the <config> element.
+	// - CfgTree(nodes...), where the configuration is built from ConfigNodes, which can define per-node operations.
 	// - CfgUrl(url), in which case the configuration is defined by a <url> element.
 	EditConfig(target string, config ConfigOption, options ...EditOption) error
 
+	// EditConfigContext is equivalent to EditConfig, but returns ctx.Err() if ctx is done before the reply is received.
+	EditConfigContext(ctx context.Context, target string, config ConfigOption, options ...EditOption) error
+
 	// EditConfigCfg issues an edit-config request defined by config to be applied to the target configuration.
 	// EditOptions can be added to qualify the operation.
 	// Convenience method to avoid complications with function arguments when using EditConfig() with a mock object
 	EditConfigCfg(target string, config interface{}, options ...EditOption) error
 
+	// EditConfigCfgContext is equivalent to EditConfigCfg, but returns ctx.Err() if ctx is done before the reply is received.
+	EditConfigCfgContext(ctx context.Context, target string, config interface{}, options ...EditOption) error
+
 	// CopyConfig issues a copy-config request.
 	// source and target are defined by a CfgDsOpt, which can be one of:
 	// - DsName(name) where name defines the configuration data store name (Running, Candidate ...)
 	// - DsUrl(url) where url defines the url of the datastore
-	CopyConfig(source, target CfgDsOpt) error
+	// RetrievalOptions can be added to qualify the data copied, as for GetSubtree.
+	CopyConfig(source, target CfgDsOpt, options ...RetrievalOption) error
+
+	// CopyConfigContext is equivalent to CopyConfig, but returns ctx.Err() if ctx is done before the reply is received.
+	CopyConfigContext(ctx context.Context, source, target CfgDsOpt, options ...RetrievalOption) error
 
 	// DeleteConfig issues a delete-config request.
 	// target is defined by a CfgDsOpt, which can be one of:
@@ -75,20 +181,106 @@ type OpSession interface {
 	// - DsUrl(url) where url defines the url of the datastore to be deleted
 	DeleteConfig(target CfgDsOpt) error
 
-	// Lock issues a lock request on the target configuration.
+	// DeleteConfigContext is equivalent to DeleteConfig, but returns ctx.Err() if ctx is done before the reply is received.
+	DeleteConfigContext(ctx context.Context, target CfgDsOpt) error
+
+	// Lock issues a lock request on the target configuration. If the configuration is already locked, the error will be
+	// a *LockDeniedError identifying the session that holds the lock.
 	Lock(target string) error
 
+	// LockContext is equivalent to Lock, but returns ctx.Err() if ctx is done before the reply is received.
+	LockContext(ctx context.Context, target string) error
+
 	// Unlock issues an unlock request on the target configuration.
 	Unlock(target string) error
 
+	// UnlockContext is equivalent to Unlock, but returns ctx.Err() if ctx is done before the reply is received.
+	UnlockContext(ctx context.Context, target string) error
+
+	// PartialLock issues a partial-lock request (RFC 5717), to lock the parts of the running configuration selected by
+	// the xpath expressions, which use the prefixes defined by the namespace list. It returns the lock-id, and the
+	// instance identifiers of the locked nodes. If (part of) the configuration is already locked, the error will be
+	// a *LockDeniedError identifying the session that holds the lock.
+	PartialLock(selectXPaths []string, nslist []Namespace) (uint32, []string, error)
+
+	// PartialLockContext is equivalent to PartialLock, but returns ctx.Err() if ctx is done before the reply is received.
+	PartialLockContext(ctx context.Context, selectXPaths []string, nslist []Namespace) (uint32, []string, error)
+
+	// PartialUnlock issues a partial-unlock request, to release the partial lock identified by lockID.
+	PartialUnlock(lockID uint32) error
+
+	// PartialUnlockContext is equivalent to PartialUnlock, but returns ctx.Err() if ctx is done before the reply is received.
+	PartialUnlockContext(ctx context.Context, lockID uint32) error
+
 	// Discard issues a discard changes request.
 	Discard() error
 
+	// DiscardContext is equivalent to Discard, but returns ctx.Err() if ctx is done before the reply is received.
+	DiscardContext(ctx context.Context) error
+
+	// Commit issues a commit request, to commit the candidate configuration to the running configuration.
+	// CommitOptions can be added to request a confirmed commit, which requires the :confirmed-commit capability.
+	Commit(options ...CommitOption) error
+
+	// CommitContext is equivalent to Commit, but returns ctx.Err() if ctx is done before the reply is received.
+	CommitContext(ctx context.Context, options ...CommitOption) error
+
+	// CancelCommit issues a cancel-commit request, to cancel an ongoing confirmed commit.
+	// If persistID is not empty, it identifies a persistent confirmed commit, which may have been issued on
+	// another session.
+	CancelCommit(persistID string) error
+
+	// CancelCommitContext is equivalent to CancelCommit, but returns ctx.Err() if ctx is done before the reply is received.
+	CancelCommitContext(ctx context.Context, persistID string) error
+
+	// Validate issues a validate request.
+	// source is defined by a CfgDsOpt, which can be one of:
+	// - DsName(name) where name defines the configuration data store name (Candidate ...)
+	// - DsUrl(url) where url defines the url of the configuration
+	// - DsCfg(cfg) where cfg defines the configuration, as for Cfg(cfg)
+	Validate(source CfgDsOpt) error
+
+	// ValidateContext is equivalent to Validate, but returns ctx.Err() if ctx is done before the reply is received.
+	ValidateContext(ctx context.Context, source CfgDsOpt) error
+
+	// GetData issues an NMDA get-data request (RFC 8526) on the datastore, and stores the response in the result,
+	// which should be the address of either:
+	// - a string, in which case it will hold the response body, or
+	// - a struct with xml tags.
+	// datastore is the name of an ietf-datastores identity (e.g. "operational"), or a prefixed identity.
+	// GetDataOptions can be added to filter the data and qualify the response.
+	GetData(datastore string, result interface{}, options ...GetDataOption) error
+
+	// GetDataContext is equivalent to GetData, but returns ctx.Err() if ctx is done before the reply is received.
+	GetDataContext(ctx context.Context, datastore string, result interface{}, options ...GetDataOption) error
+
+	// EditData issues an NMDA edit-data request (RFC 8526) defined by config to be applied to the datastore.
+	// config is defined as for EditConfig. Of the EditOptions, only DefaultOperation is applicable.
+	EditData(datastore string, config ConfigOption, options ...EditOption) error
+
+	// EditDataContext is equivalent to EditData, but returns ctx.Err() if ctx is done before the reply is received.
+	EditDataContext(ctx context.Context, datastore string, config ConfigOption, options ...EditOption) error
+
 	// CloseSession issues a close session request.
 	CloseSession() error
 
+	// CloseSessionContext is equivalent to CloseSession, but returns ctx.Err() if ctx is done before the reply is received.
+	CloseSessionContext(ctx context.Context) error
+
 	// KillSession issues a kill session request for the specified session id.
 	KillSession(id uint64) error
+
+	// KillSessionContext is equivalent to KillSession, but returns ctx.Err() if ctx is done before the reply is received.
+	KillSessionContext(ctx context.Context, id uint64) error
+
+	// Call issues any request, e.g. a custom or vendor rpc, or an Action, and decodes the reply into resp, which should
+	// be nil, or the address of either:
+	// - a string, in which case it will hold the reply body, or
+	// - a struct with xml tags.
+	// The reply body is the content of a <data> element, if that is the only element of the reply, or the elements of
+	// the reply otherwise, excluding any <ok/> and warnings. A struct with an XMLName field is decoded from the first
+	// of these elements, a struct without one from all of them, as for rpc output parameters.
+	Call(ctx context.Context, req common.Request, resp interface{}) error
 }
 
 type sImpl struct {
@@ -99,69 +291,237 @@ func (s *sImpl) Close() {
 	s.Session.Close()
 }
 
-func (s *sImpl) GetSubtree(filter interface{}, result interface{}) error {
-	return s.handleGetRequest(createGetSubtreeRequest(filter), result)
+func (s *sImpl) GetSubtree(filter interface{}, result interface{}, options ...RetrievalOption) error {
+	return s.GetSubtreeContext(context.Background(), filter, result, options...)
+}
+
+func (s *sImpl) GetSubtreeContext(ctx context.Context, filter interface{}, result interface{}, options ...RetrievalOption) error {
+	if err := s.requireRetrievalCapabilities(options); err != nil {
+		return err
+	}
+	return s.handleGetRequest(ctx, createGetSubtreeRequest(filter, options...), result)
+}
+
+func (s *sImpl) GetSubtreeStream(ctx context.Context, filter interface{}, handler client.DataHandler, options ...RetrievalOption) error {
+	if err := s.requireRetrievalCapabilities(options); err != nil {
+		return err
+	}
+	_, err := s.Session.ExecuteStream(ctx, createGetSubtreeRequest(filter, options...), handler)
+	return err
+}
+
+func (s *sImpl) GetXpath(xpath string, nslist []Namespace, result interface{}, options ...RetrievalOption) error {
+	return s.GetXpathContext(context.Background(), xpath, nslist, result, options...)
+}
+
+func (s *sImpl) GetXpathContext(ctx context.Context, xpath string, nslist []Namespace, result interface{}, options ...RetrievalOption) error {
+	return s.GetXpathFilter(ctx, &XPathFilter{Select: xpath, Namespaces: nslist}, result, options...)
+}
+
+func (s *sImpl) GetXpathFilter(ctx context.Context, filter *XPathFilter, result interface{}, options ...RetrievalOption) error {
+	if err := s.requireRetrievalCapabilities(options); err != nil {
+		return err
+	}
+	if filter == nil {
+		return fmt.Errorf("%w: filter is not defined", ErrInvalidXPath)
+	}
+	if err := filter.Validate(); err != nil {
+		return err
+	}
+	return s.handleGetRequest(ctx, createGetXpathFilterRequest(filter, options...), result)
+}
+
+func (s *sImpl) GetConfigSubtree(filter interface{}, source string, result interface{}, options ...RetrievalOption) error {
+	return s.GetConfigSubtreeContext(context.Background(), filter, source, result, options...)
 }
 
-func (s *sImpl) GetXpath(xpath string, nslist []Namespace, result interface{}) error {
-	return s.handleGetRequest(createGetXpathRequest(xpath, nslist), result)
+func (s *sImpl) GetConfigSubtreeContext(ctx context.Context, filter interface{}, source string, result interface{}, options ...RetrievalOption) error {
+	if err := s.requireRetrievalCapabilities(options); err != nil {
+		return err
+	}
+	return s.handleGetRequest(ctx, createGetConfigSubtreeRequest(filter, source, options...), result)
+}
+
+func (s *sImpl) GetConfigSubtreeStream(ctx context.Context, filter interface{}, source string, handler client.DataHandler, options ...RetrievalOption) error {
+	if err := s.requireRetrievalCapabilities(options); err != nil {
+		return err
+	}
+	_, err := s.Session.ExecuteStream(ctx, createGetConfigSubtreeRequest(filter, source, options...), handler)
+	return err
+}
+
+func (s *sImpl) GetConfigXpath(xpath string, nslist []Namespace, source string, result interface{}, options ...RetrievalOption) error {
+	return s.GetConfigXpathContext(context.Background(), xpath, nslist, source, result, options...)
 }
 
-func (s *sImpl) GetConfigSubtree(filter interface{}, source string, result interface{}) error {
-	return s.handleGetRequest(createGetConfigSubtreeRequest(filter, source), result)
+func (s *sImpl) GetConfigXpathContext(ctx context.Context, xpath string, nslist []Namespace, source string, result interface{}, options ...RetrievalOption) error {
+	var filter *XPathFilter
+	if xpath != "" {
+		filter = &XPathFilter{Select: xpath, Namespaces: nslist}
+	}
+	return s.GetConfigXpathFilter(ctx, filter, source, result, options...)
 }
 
-func (s *sImpl) GetConfigXpath(xpath string, nslist []Namespace, source string, result interface{}) error {
-	return s.handleGetRequest(createGetConfigXpathRequest(xpath, source, nslist), result)
+func (s *sImpl) GetConfigXpathFilter(ctx context.Context, filter *XPathFilter, source string, result interface{}, options ...RetrievalOption) error {
+	if err := s.requireRetrievalCapabilities(options); err != nil {
+		return err
+	}
+	if filter != nil {
+		if err := filter.Validate(); err != nil {
+			return err
+		}
+	}
+	return s.handleGetRequest(ctx, createGetConfigXpathFilterRequest(filter, source, options...), result)
 }
 
 func (s *sImpl) EditConfig(target string, config ConfigOption, options ...EditOption) error {
-	_, err := s.Session.Execute(createEditConfigRequest(target, config, options...))
+	return s.EditConfigContext(context.Background(), target, config, options...)
+}
+
+func (s *sImpl) EditConfigContext(ctx context.Context, target string, config ConfigOption, options ...EditOption) error {
+	_, err := s.Session.ExecuteContext(ctx, createEditConfigRequest(target, config, options...))
 	return err
 }
 
 func (s *sImpl) EditConfigCfg(target string, config interface{}, options ...EditOption) error {
-	return s.EditConfig(target, Cfg(config), options...)
+	return s.EditConfigCfgContext(context.Background(), target, config, options...)
+}
+
+func (s *sImpl) EditConfigCfgContext(ctx context.Context, target string, config interface{}, options ...EditOption) error {
+	return s.EditConfigContext(ctx, target, Cfg(config), options...)
 }
 
-func (s *sImpl) CopyConfig(source, target CfgDsOpt) error {
-	_, err := s.Session.Execute(createCopyConfigRequest(source, target))
+func (s *sImpl) CopyConfig(source, target CfgDsOpt, options ...RetrievalOption) error {
+	return s.CopyConfigContext(context.Background(), source, target, options...)
+}
+
+func (s *sImpl) CopyConfigContext(ctx context.Context, source, target CfgDsOpt, options ...RetrievalOption) error {
+	if err := s.requireRetrievalCapabilities(options); err != nil {
+		return err
+	}
+	_, err := s.Session.ExecuteContext(ctx, createCopyConfigRequest(source, target, options...))
 	return err
 }
 
 func (s *sImpl) DeleteConfig(target CfgDsOpt) error {
-	_, err := s.Session.Execute(createDeleteConfigRequest(target))
+	return s.DeleteConfigContext(context.Background(), target)
+}
+
+func (s *sImpl) DeleteConfigContext(ctx context.Context, target CfgDsOpt) error {
+	_, err := s.Session.ExecuteContext(ctx, createDeleteConfigRequest(target))
 	return err
 }
 
 func (s *sImpl) Lock(target string) error {
-	_, err := s.Session.Execute(createLockRequest(target))
-	return err
+	return s.LockContext(context.Background(), target)
+}
+
+func (s *sImpl) LockContext(ctx context.Context, target string) error {
+	_, err := s.Session.ExecuteContext(ctx, createLockRequest(target))
+	return mapLockError(err)
 }
 
 func (s *sImpl) Unlock(target string) error {
-	_, err := s.Session.Execute(createUnlockRequest(target))
+	return s.UnlockContext(context.Background(), target)
+}
+
+func (s *sImpl) UnlockContext(ctx context.Context, target string) error {
+	_, err := s.Session.ExecuteContext(ctx, createUnlockRequest(target))
 	return err
 }
 
 func (s *sImpl) Discard() error {
-	_, err := s.Session.Execute(createDiscardRequest())
+	return s.DiscardContext(context.Background())
+}
+
+func (s *sImpl) DiscardContext(ctx context.Context) error {
+	_, err := s.Session.ExecuteContext(ctx, createDiscardRequest())
+	return err
+}
+
+func (s *sImpl) Commit(options ...CommitOption) error {
+	return s.CommitContext(context.Background(), options...)
+}
+
+func (s *sImpl) CommitContext(ctx context.Context, options ...CommitOption) error {
+	req := createCommitRequest(options...)
+	if err := s.requireCommitCapabilities(req); err != nil {
+		return err
+	}
+	_, err := s.Session.ExecuteContext(ctx, req)
+	return err
+}
+
+func (s *sImpl) CancelCommit(persistID string) error {
+	return s.CancelCommitContext(context.Background(), persistID)
+}
+
+func (s *sImpl) CancelCommitContext(ctx context.Context, persistID string) error {
+	if err := s.requireCapability(common.CapConfirmedCommit); err != nil {
+		return err
+	}
+	_, err := s.Session.ExecuteContext(ctx, createCancelCommitRequest(persistID))
+	return err
+}
+
+func (s *sImpl) Validate(source CfgDsOpt) error {
+	return s.ValidateContext(context.Background(), source)
+}
+
+func (s *sImpl) ValidateContext(ctx context.Context, source CfgDsOpt) error {
+	if err := s.requireCapability(common.CapValidate, common.CapValidate10); err != nil {
+		return err
+	}
+	_, err := s.Session.ExecuteContext(ctx, createValidateRequest(source))
 	return err
 }
 
+func (s *sImpl) requireCommitCapabilities(req *CommitReq) error {
+	if err := s.requireCapability(common.CapCandidate); err != nil {
+		return err
+	}
+	switch {
+	case req.Persist != "" || req.PersistID != "":
+		return s.requireCapability(common.CapConfirmedCommit)
+	case req.Confirmed != "":
+		return s.requireCapability(common.CapConfirmedCommit, common.CapConfirmedCommit10)
+	}
+	return nil
+}
+
+// requireCapability returns ErrNotSupported unless the server supports one of the capabilities.
+func (s *sImpl) requireCapability(caps ...string) error {
+	if s.Capabilities().Supports(caps...) {
+		return nil
+	}
+	return fmt.Errorf("%w: requires capability %s", ErrNotSupported, caps[0])
+}
+
 func (s *sImpl) CloseSession() error {
-	_, err := s.Session.Execute(createCloseSessionRequest())
+	return s.CloseSessionContext(context.Background())
+}
+
+func (s *sImpl) CloseSessionContext(ctx context.Context) error {
+	_, err := s.Session.ExecuteContext(ctx, createCloseSessionRequest())
 	return err
 }
 
 func (s *sImpl) KillSession(id uint64) error {
-	_, err := s.Session.Execute(createKillSessionRequest(id))
+	return s.KillSessionContext(context.Background(), id)
+}
+
+func (s *sImpl) KillSessionContext(ctx context.Context, id uint64) error {
+	_, err := s.Session.ExecuteContext(ctx, createKillSessionRequest(id))
 	return err
 }
 
 func (s *sImpl) GetSchemas() ([]Schema, error) {
+	return s.GetSchemasContext(context.Background())
+}
+
+func (s *sImpl) GetSchemasContext(ctx context.Context) ([]Schema, error) {
 	ncs := &NetconfState{}
-	err := s.handleGetRequest(createGetShemasRequest(), ncs)
+	err := s.handleGetRequest(ctx, createGetShemasRequest(), ncs)
 	if err != nil {
 		return nil, err
 	}
@@ -169,14 +529,22 @@ func (s *sImpl) GetSchemas() ([]Schema, error) {
 }
 
 func (s *sImpl) GetSchema(id, version, format string) (string, error) {
+	return s.GetSchemaContext(context.Background(), id, version, format)
+}
+
+func (s *sImpl) GetSchemaContext(ctx context.Context, id, version, format string) (string, error) {
 	req := createGetShemaRequest(id, version, format)
-	rply, err := s.Session.Execute(req)
+	rply, err := s.Session.ExecuteContext(ctx, req)
 
 	if err != nil {
 		return "", err
 	}
-	data := &Data{}
+	data := &schemaData{}
 	err = xml.Unmarshal([]byte(rply.Data), data)
+	if len(data.Elements) == 0 {
+		// A yang schema is text, which must be unescaped.
+		return data.Text, err
+	}
 	return data.Content, err
 }
 
@@ -210,6 +578,49 @@ func DsUrl(url string) CfgDsOpt {
 	}
 }
 
+func DsCfg(cfg interface{}) CfgDsOpt {
+	return func(t *ConfigType) {
+		t.Config = &Config{Union: common.GetUnion(cfg)}
+	}
+}
+
+// CommitOption configures a commit operation.
+type CommitOption func(*CommitReq)
+
+// Confirmed requests a confirmed commit, which will be rolled back unless it is confirmed by a subsequent
+// commit within the default timeout of 600 seconds.
+func Confirmed() CommitOption {
+	return func(req *CommitReq) {
+		req.Confirmed = "<confirmed/>"
+	}
+}
+
+// ConfirmTimeout requests a confirmed commit, which will be rolled back unless it is confirmed within
+// the timeout, in seconds.
+func ConfirmTimeout(secs uint32) CommitOption {
+	return func(req *CommitReq) {
+		Confirmed()(req)
+		req.ConfirmTimeout = secs
+	}
+}
+
+// Persist requests a confirmed commit that will survive the end of the session, and may be confirmed or
+// cancelled on another session using the id.
+func Persist(id string) CommitOption {
+	return func(req *CommitReq) {
+		Confirmed()(req)
+		req.Persist = id
+	}
+}
+
+// PersistID identifies the persistent confirmed commit that is being confirmed, or extended if the commit
+// is itself confirmed.
+func PersistID(id string) CommitOption {
+	return func(req *CommitReq) {
+		req.PersistID = id
+	}
+}
+
 // EditOption configures an edit config operation.
 type EditOption func(*EditConfigReq)
 
@@ -237,46 +648,55 @@ func (r *EditConfigReq) applyOpts(options ...EditOption) {
 	}
 }
 
-func createGetSubtreeRequest(s interface{}) common.Request {
+func createGetSubtreeRequest(s interface{}, options ...RetrievalOption) common.Request {
 	req := &GetReq{}
 	if s != nil {
 		req.Filter = &Filter{Type: "subtree", Union: common.GetUnion(s)}
 	}
+	req.applyOpts(options...)
 	return req
 }
 
-func createGetXpathRequest(xpath string, nslist []Namespace) common.Request {
-	return fmt.Sprintf(`<get><filter %s type="xpath" select="%s"/></get>`, getNamespaceAttributes(nslist), xpath)
+func createGetXpathRequest(xpath string, nslist []Namespace, options ...RetrievalOption) common.Request {
+	return createGetXpathFilterRequest(&XPathFilter{Select: xpath, Namespaces: nslist}, options...)
 }
 
-func getNamespaceAttributes(nslist []Namespace) string {
-	var attrs string
-	for _, ns := range nslist {
-		attrs = fmt.Sprintf(`%s xmlns:%s="%s"`, attrs, ns.Id, ns.Path)
-	}
-	return strings.TrimSpace(attrs)
+func createGetXpathFilterRequest(filter *XPathFilter, options ...RetrievalOption) common.Request {
+	req := &GetReq{FilterBody: filter.element()}
+	req.applyOpts(options...)
+	return req
 }
 
-func createGetConfigSubtreeRequest(s interface{}, source string) common.Request {
+func createGetConfigSubtreeRequest(s interface{}, source string, options ...RetrievalOption) common.Request {
 	// xml Marshaller will not create self-closing tags (and some devices require it)...
 	req := &GetConfigReq{Source: &ConfigType{Type: "<" + source + "/>"}}
 	if s != nil {
 		req.Filter = &Filter{Type: "subtree", Union: common.GetUnion(s)}
 	}
+	req.applyOpts(options...)
 	return req
 }
 
-func createGetConfigXpathRequest(xpath string, source string, nslist []Namespace) common.Request {
+func createGetConfigXpathRequest(xpath string, source string, nslist []Namespace, options ...RetrievalOption) common.Request {
+	var filter *XPathFilter
+	if xpath != "" {
+		filter = &XPathFilter{Select: xpath, Namespaces: nslist}
+	}
+	return createGetConfigXpathFilterRequest(filter, source, options...)
+}
+
+func createGetConfigXpathFilterRequest(filter *XPathFilter, source string, options ...RetrievalOption) common.Request {
 	// xml Marshaller will not create self-closing tags....
 	req := &GetConfigReq{Source: &ConfigType{Type: "<" + source + "/>"}}
-	if xpath != "" {
-		req.FilterBody = createXpathFilter(xpath, nslist)
+	if filter != nil {
+		req.FilterBody = filter.element()
 	}
+	req.applyOpts(options...)
 	return req
 }
 
 func createXpathFilter(xpath string, nslist []Namespace) string {
-	return fmt.Sprintf(`<filter %s type="xpath" select="%s"/>`, getNamespaceAttributes(nslist), xpath)
+	return (&XPathFilter{Select: xpath, Namespaces: nslist}).element()
 }
 
 func createEditConfigRequest(target string, cfgOpt ConfigOption, options ...EditOption) *EditConfigReq {
@@ -286,10 +706,11 @@ func createEditConfigRequest(target string, cfgOpt ConfigOption, options ...Edit
 	return req
 }
 
-func createCopyConfigRequest(source, target CfgDsOpt) *CopyConfigReq {
+func createCopyConfigRequest(source, target CfgDsOpt, options ...RetrievalOption) *CopyConfigReq {
 	req := &CopyConfigReq{Source: &ConfigType{}, Target: &ConfigType{}}
 	source(req.Source)
 	target(req.Target)
+	req.applyOpts(options...)
 	return req
 }
 
@@ -307,6 +728,24 @@ func createUnlockRequest(target string) *UnlockReq {
 	return &UnlockReq{Target: &ConfigType{Type: "<" + target + "/>"}}
 }
 
+func createCommitRequest(options ...CommitOption) *CommitReq {
+	req := &CommitReq{}
+	for _, opt := range options {
+		opt(req)
+	}
+	return req
+}
+
+func createCancelCommitRequest(persistID string) *CancelCommitReq {
+	return &CancelCommitReq{PersistID: persistID}
+}
+
+func createValidateRequest(source CfgDsOpt) *ValidateReq {
+	req := &ValidateReq{Source: &ConfigType{}}
+	source(req.Source)
+	return req
+}
+
 func createDiscardRequest() *DiscardReq {
 	return &DiscardReq{}
 }
@@ -327,8 +766,8 @@ func createGetShemasRequest() common.Request {
 	return createGetSubtreeRequest("<netconf-state><schemas/></netconf-state>")
 }
 
-func (s *sImpl) handleGetRequest(req common.Request, result interface{}) error {
-	reply, err := s.Session.Execute(req)
+func (s *sImpl) handleGetRequest(ctx context.Context, req common.Request, result interface{}) error {
+	reply, err := s.Session.ExecuteContext(ctx, req)
 	if err != nil {
 		return err
 	}
@@ -360,13 +799,16 @@ type Config struct {
 }
 
 type GetReq struct {
-	XMLName xml.Name `xml:"get"`
-	Filter  *Filter
+	XMLName    xml.Name `xml:"get"`
+	Filter     *Filter
+	FilterBody string `xml:",innerxml"`
+	Retrieval
 }
 
 type ConfigType struct {
-	Type string `xml:",innerxml"`
-	Url  string `xml:"url,omitempty"`
+	Type   string `xml:",innerxml"`
+	Url    string `xml:"url,omitempty"`
+	Config *Config
 }
 
 type GetConfigReq struct {
@@ -374,6 +816,7 @@ type GetConfigReq struct {
 	Source     *ConfigType `xml:"source"`
 	Filter     *Filter
 	FilterBody string `xml:",innerxml"`
+	Retrieval
 }
 
 type EditConfigReq struct {
@@ -390,6 +833,7 @@ type CopyConfigReq struct {
 	XMLName xml.Name    `xml:"copy-config"`
 	Target  *ConfigType `xml:"target"`
 	Source  *ConfigType `xml:"source"`
+	Retrieval
 }
 
 type DeleteConfigReq struct {
@@ -411,6 +855,25 @@ type DiscardReq struct {
 	XMLName xml.Name `xml:"discard-changes"`
 }
 
+type CommitReq struct {
+	XMLName xml.Name `xml:"commit"`
+	// xml Marshaller will not create self-closing tags....
+	Confirmed      string `xml:",innerxml"`
+	ConfirmTimeout uint32 `xml:"confirm-timeout,omitempty"`
+	Persist        string `xml:"persist,omitempty"`
+	PersistID      string `xml:"persist-id,omitempty"`
+}
+
+type CancelCommitReq struct {
+	XMLName   xml.Name `xml:"cancel-commit"`
+	PersistID string   `xml:"persist-id,omitempty"`
+}
+
+type ValidateReq struct {
+	XMLName xml.Name    `xml:"validate"`
+	Source  *ConfigType `xml:"source"`
+}
+
 type CloseSessionReq struct {
 	XMLName xml.Name `xml:"close-session"`
 }
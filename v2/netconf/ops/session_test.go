@@ -1,6 +1,7 @@
 package ops
 
 import (
+	"context"
 	"encoding/xml"
 	"errors"
 	"testing"
@@ -9,6 +10,7 @@ import (
 
 	"github.com/damianoneill/net/v2/netconf/mocks"
 
+	"github.com/stretchr/testify/mock"
 	assert "github.com/stretchr/testify/require"
 )
 
@@ -16,7 +18,7 @@ func TestGetSubtreeToString(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
 	defer ncs.Close()
-	mcli.On("Execute", createGetSubtreeRequest(`<subtree-element/>`)).Return(&common.RPCReply{Data: `<data><element attr1="ABC"/></data>`}, nil)
+	mcli.On("ExecuteContext", context.Background(), createGetSubtreeRequest(`<subtree-element/>`)).Return(&common.RPCReply{Data: `<data><element attr1="ABC"/></data>`}, nil)
 	mcli.On("Close")
 
 	var result string
@@ -28,7 +30,7 @@ func TestGetSubtreeToString(t *testing.T) {
 func TestGetSubtreeToStruct(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createGetSubtreeRequest(`<subtree-element/>`)).Return(&common.RPCReply{Data: `<data><element attr1="ABC"/></data>`}, nil)
+	mcli.On("ExecuteContext", context.Background(), createGetSubtreeRequest(`<subtree-element/>`)).Return(&common.RPCReply{Data: `<data><element attr1="ABC"/></data>`}, nil)
 
 	var result = &Element{}
 	err := ncs.GetSubtree(`<subtree-element/>`, result)
@@ -39,7 +41,7 @@ func TestGetSubtreeToStruct(t *testing.T) {
 func TestGetSubtreeExecuteError(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createGetSubtreeRequest(`<subtree-element/>`)).Return(nil, errors.New("failed"))
+	mcli.On("ExecuteContext", context.Background(), createGetSubtreeRequest(`<subtree-element/>`)).Return(nil, errors.New("failed"))
 
 	var result string
 	err := ncs.GetSubtree(`<subtree-element/>`, &result)
@@ -49,7 +51,7 @@ func TestGetSubtreeExecuteError(t *testing.T) {
 func TestGetXpathToString(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createGetXpathRequest(`/tns:element`, []Namespace{{"tns", "urn:tns"}})).Return(&common.RPCReply{Data: `<data><element attr1="ABC"/></data>`}, nil)
+	mcli.On("ExecuteContext", context.Background(), createGetXpathRequest(`/tns:element`, []Namespace{{"tns", "urn:tns"}})).Return(&common.RPCReply{Data: `<data><element attr1="ABC"/></data>`}, nil)
 
 	var result string
 	err := ncs.GetXpath(`/tns:element`, []Namespace{{"tns", "urn:tns"}}, &result)
@@ -60,7 +62,7 @@ func TestGetXpathToString(t *testing.T) {
 func TestGetXpathToStruct(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createGetXpathRequest(`/tns:element`, []Namespace{{"tns", "urn:tns"}})).Return(&common.RPCReply{Data: `<data><element attr1="ABC"/></data>`}, nil)
+	mcli.On("ExecuteContext", context.Background(), createGetXpathRequest(`/tns:element`, []Namespace{{"tns", "urn:tns"}})).Return(&common.RPCReply{Data: `<data><element attr1="ABC"/></data>`}, nil)
 
 	var result = &Element{}
 	err := ncs.GetXpath(`/tns:element`, []Namespace{{"tns", "urn:tns"}}, result)
@@ -71,7 +73,7 @@ func TestGetXpathToStruct(t *testing.T) {
 func TestGetXpathExecuteError(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createGetXpathRequest(`/tns:element`, []Namespace{{"tns", "urn:tns"}})).Return(nil, errors.New("failed"))
+	mcli.On("ExecuteContext", context.Background(), createGetXpathRequest(`/tns:element`, []Namespace{{"tns", "urn:tns"}})).Return(nil, errors.New("failed"))
 
 	var result string
 	err := ncs.GetXpath(`/tns:element`, []Namespace{{"tns", "urn:tns"}}, &result)
@@ -81,7 +83,7 @@ func TestGetXpathExecuteError(t *testing.T) {
 func TestGetConfigSubtreeToString(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createGetConfigSubtreeRequest(`<subtree-element/>`, RunningCfg)).Return(&common.RPCReply{Data: `<data><element attr1="ABC"/></data>`}, nil)
+	mcli.On("ExecuteContext", context.Background(), createGetConfigSubtreeRequest(`<subtree-element/>`, RunningCfg)).Return(&common.RPCReply{Data: `<data><element attr1="ABC"/></data>`}, nil)
 
 	var result string
 	err := ncs.GetConfigSubtree(`<subtree-element/>`, RunningCfg, &result)
@@ -92,7 +94,7 @@ func TestGetConfigSubtreeToString(t *testing.T) {
 func TestGetConfigSubtreeToStruct(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createGetConfigSubtreeRequest(`<subtree-element/>`, RunningCfg)).Return(&common.RPCReply{Data: `<data><element attr1="ABC"/></data>`}, nil)
+	mcli.On("ExecuteContext", context.Background(), createGetConfigSubtreeRequest(`<subtree-element/>`, RunningCfg)).Return(&common.RPCReply{Data: `<data><element attr1="ABC"/></data>`}, nil)
 
 	var result = &Element{}
 	err := ncs.GetConfigSubtree(`<subtree-element/>`, RunningCfg, result)
@@ -103,7 +105,7 @@ func TestGetConfigSubtreeToStruct(t *testing.T) {
 func TestGetConfigSubtreeExecuteError(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createGetConfigSubtreeRequest(`<subtree-element/>`, RunningCfg)).Return(nil, errors.New("failed"))
+	mcli.On("ExecuteContext", context.Background(), createGetConfigSubtreeRequest(`<subtree-element/>`, RunningCfg)).Return(nil, errors.New("failed"))
 
 	var result string
 	err := ncs.GetConfigSubtree(`<subtree-element/>`, RunningCfg, &result)
@@ -113,7 +115,7 @@ func TestGetConfigSubtreeExecuteError(t *testing.T) {
 func TestGetConfigXpathToString(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createGetConfigXpathRequest(`/tns:element`, RunningCfg, []Namespace{{"tns", "urn:tns"}})).Return(&common.RPCReply{Data: `<data><element attr1="ABC"/></data>`}, nil)
+	mcli.On("ExecuteContext", context.Background(), createGetConfigXpathRequest(`/tns:element`, RunningCfg, []Namespace{{"tns", "urn:tns"}})).Return(&common.RPCReply{Data: `<data><element attr1="ABC"/></data>`}, nil)
 
 	var result string
 	err := ncs.GetConfigXpath(`/tns:element`, []Namespace{{"tns", "urn:tns"}}, RunningCfg, &result)
@@ -124,7 +126,7 @@ func TestGetConfigXpathToString(t *testing.T) {
 func TestGetConfigXpathToStruct(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createGetConfigXpathRequest(`/tns:element`, RunningCfg, []Namespace{{"tns", "urn:tns"}})).Return(&common.RPCReply{Data: `<data><element attr1="ABC"/></data>`}, nil)
+	mcli.On("ExecuteContext", context.Background(), createGetConfigXpathRequest(`/tns:element`, RunningCfg, []Namespace{{"tns", "urn:tns"}})).Return(&common.RPCReply{Data: `<data><element attr1="ABC"/></data>`}, nil)
 
 	var result = &Element{}
 	err := ncs.GetConfigXpath(`/tns:element`, []Namespace{{"tns", "urn:tns"}}, RunningCfg, result)
@@ -135,7 +137,7 @@ func TestGetConfigXpathToStruct(t *testing.T) {
 func TestGetConfigXpathExecuteError(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createGetConfigXpathRequest(`/tns:element`, RunningCfg, []Namespace{{"tns", "urn:tns"}})).Return(nil, errors.New("failed"))
+	mcli.On("ExecuteContext", context.Background(), createGetConfigXpathRequest(`/tns:element`, RunningCfg, []Namespace{{"tns", "urn:tns"}})).Return(nil, errors.New("failed"))
 
 	var result string
 	err := ncs.GetConfigXpath(`/tns:element`, []Namespace{{"tns", "urn:tns"}}, RunningCfg, &result)
@@ -145,7 +147,7 @@ func TestGetConfigXpathExecuteError(t *testing.T) {
 func TestEditConfigString(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createEditConfigRequest(CandidateCfg, Cfg(`<configuration/>`))).Return(&common.RPCReply{}, nil)
+	mcli.On("ExecuteContext", context.Background(), createEditConfigRequest(CandidateCfg, Cfg(`<configuration/>`))).Return(&common.RPCReply{}, nil)
 
 	err := ncs.EditConfig(CandidateCfg, Cfg(`<configuration/>`))
 	assert.NoError(t, err, "Not expecting call to fail")
@@ -160,7 +162,7 @@ type testConfig struct {
 func TestEditConfigStruct(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createEditConfigRequest(CandidateCfg, Cfg(&testConfig{}))).Return(&common.RPCReply{}, nil)
+	mcli.On("ExecuteContext", context.Background(), createEditConfigRequest(CandidateCfg, Cfg(&testConfig{}))).Return(&common.RPCReply{}, nil)
 
 	err := ncs.EditConfig(CandidateCfg, Cfg(&testConfig{}))
 	assert.NoError(t, err, "Not expecting call to fail")
@@ -171,7 +173,7 @@ func TestEditConfigStruct(t *testing.T) {
 func TestEditConfigUrl(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createEditConfigRequest(CandidateCfg, CfgUrl("file://checkpoint.conf"))).Return(&common.RPCReply{}, nil)
+	mcli.On("ExecuteContext", context.Background(), createEditConfigRequest(CandidateCfg, CfgUrl("file://checkpoint.conf"))).Return(&common.RPCReply{}, nil)
 
 	err := ncs.EditConfig(CandidateCfg, CfgUrl("file://checkpoint.conf"))
 	assert.NoError(t, err, "Not expecting call to fail")
@@ -182,7 +184,7 @@ func TestEditConfigUrl(t *testing.T) {
 func TestEditConfigOptions(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute",
+	mcli.On("ExecuteContext", context.Background(),
 		createEditConfigRequest(CandidateCfg, Cfg(`<configuration/>`), ErrorOption(StopOnErrorErrOpt), DefaultOperation(NoneOp), TestOption(TestThenSetOpt))).Return(&common.RPCReply{}, nil)
 
 	err := ncs.EditConfig(CandidateCfg, Cfg(`<configuration/>`), ErrorOption(StopOnErrorErrOpt), DefaultOperation(NoneOp), TestOption(TestThenSetOpt))
@@ -194,7 +196,7 @@ func TestEditConfigOptions(t *testing.T) {
 func TestEditConfigCfg(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createEditConfigRequest(CandidateCfg, Cfg(`<configuration/>`))).Return(&common.RPCReply{}, nil)
+	mcli.On("ExecuteContext", context.Background(), createEditConfigRequest(CandidateCfg, Cfg(`<configuration/>`))).Return(&common.RPCReply{}, nil)
 
 	err := ncs.EditConfigCfg(CandidateCfg, `<configuration/>`)
 	assert.NoError(t, err, "Not expecting call to fail")
@@ -205,7 +207,7 @@ func TestEditConfigCfg(t *testing.T) {
 func TestCopyConfig(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createCopyConfigRequest(DsName(CandidateCfg), DsUrl("file://checkpoint.conf"))).Return(&common.RPCReply{}, nil)
+	mcli.On("ExecuteContext", context.Background(), createCopyConfigRequest(DsName(CandidateCfg), DsUrl("file://checkpoint.conf"))).Return(&common.RPCReply{}, nil)
 
 	err := ncs.CopyConfig(DsName(CandidateCfg), DsUrl("file://checkpoint.conf"))
 	assert.NoError(t, err, "Not expecting call to fail")
@@ -216,7 +218,7 @@ func TestCopyConfig(t *testing.T) {
 func TestDeleteConfig(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createDeleteConfigRequest(DsUrl("file://checkpoint.conf"))).Return(&common.RPCReply{}, nil)
+	mcli.On("ExecuteContext", context.Background(), createDeleteConfigRequest(DsUrl("file://checkpoint.conf"))).Return(&common.RPCReply{}, nil)
 
 	err := ncs.DeleteConfig(DsUrl("file://checkpoint.conf"))
 	assert.NoError(t, err, "Not expecting call to fail")
@@ -227,7 +229,7 @@ func TestDeleteConfig(t *testing.T) {
 func TestLock(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createLockRequest(CandidateCfg)).Return(&common.RPCReply{}, nil)
+	mcli.On("ExecuteContext", context.Background(), createLockRequest(CandidateCfg)).Return(&common.RPCReply{}, nil)
 
 	err := ncs.Lock(CandidateCfg)
 	assert.NoError(t, err, "Not expecting call to fail")
@@ -235,10 +237,55 @@ func TestLock(t *testing.T) {
 	mcli.AssertExpectations(t)
 }
 
+func TestLockContext(t *testing.T) {
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	mcli.On("ExecuteContext", ctx, createLockRequest(CandidateCfg)).Return(nil, context.Canceled)
+
+	err := ncs.LockContext(ctx, CandidateCfg)
+	assert.Equal(t, context.Canceled, err, "Expecting call to be cancelled")
+
+	mcli.AssertExpectations(t)
+}
+
+func TestGetSubtreeContext(t *testing.T) {
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mcli.On("ExecuteContext", ctx, createGetSubtreeRequest(`<subtree-element/>`)).Return(&common.RPCReply{Data: `<data><element/></data>`}, nil)
+
+	var result string
+	err := ncs.GetSubtreeContext(ctx, `<subtree-element/>`, &result)
+	assert.NoError(t, err, "Not expecting call to fail")
+	assert.Equal(t, `<element/>`, result, "Expected result")
+
+	mcli.AssertExpectations(t)
+}
+
+func TestGetSubtreeStream(t *testing.T) {
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	ctx := context.Background()
+	mcli.On("ExecuteStream", ctx, createGetSubtreeRequest(`<subtree-element/>`), mock.Anything).Return(&common.RPCReply{}, nil)
+	mcli.On("ExecuteStream", ctx, createGetConfigSubtreeRequest(`<subtree-element/>`, RunningCfg), mock.Anything).Return(nil, errors.New("failed"))
+
+	handler := func(start xml.StartElement, tr xml.TokenReader) error { return nil }
+	err := ncs.GetSubtreeStream(ctx, `<subtree-element/>`, handler)
+	assert.NoError(t, err, "Not expecting call to fail")
+
+	err = ncs.GetConfigSubtreeStream(ctx, `<subtree-element/>`, RunningCfg, handler)
+	assert.EqualError(t, err, "failed", "Expecting call to fail")
+
+	mcli.AssertExpectations(t)
+}
+
 func TestUnlock(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createUnlockRequest(CandidateCfg)).Return(&common.RPCReply{}, nil)
+	mcli.On("ExecuteContext", context.Background(), createUnlockRequest(CandidateCfg)).Return(&common.RPCReply{}, nil)
 
 	err := ncs.Unlock(CandidateCfg)
 	assert.NoError(t, err, "Not expecting call to fail")
@@ -249,7 +296,7 @@ func TestUnlock(t *testing.T) {
 func TestDiscard(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createDiscardRequest()).Return(&common.RPCReply{}, nil)
+	mcli.On("ExecuteContext", context.Background(), createDiscardRequest()).Return(&common.RPCReply{}, nil)
 
 	err := ncs.Discard()
 	assert.NoError(t, err, "Not expecting call to fail")
@@ -257,10 +304,129 @@ func TestDiscard(t *testing.T) {
 	mcli.AssertExpectations(t)
 }
 
+func TestCommit(t *testing.T) {
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("Capabilities").Return(common.ParseCapabilities([]string{common.CapCandidate}))
+	mcli.On("ExecuteContext", context.Background(), createCommitRequest()).Return(&common.RPCReply{}, nil)
+
+	err := ncs.Commit()
+	assert.NoError(t, err, "Not expecting call to fail")
+
+	mcli.AssertExpectations(t)
+}
+
+func TestConfirmedCommit(t *testing.T) {
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("Capabilities").Return(common.ParseCapabilities([]string{common.CapCandidate, common.CapConfirmedCommit}))
+	mcli.On("ExecuteContext", context.Background(), createCommitRequest(ConfirmTimeout(120), Persist("p1"))).Return(&common.RPCReply{}, nil)
+	mcli.On("ExecuteContext", context.Background(), createCommitRequest(PersistID("p1"))).Return(&common.RPCReply{}, nil)
+
+	err := ncs.Commit(ConfirmTimeout(120), Persist("p1"))
+	assert.NoError(t, err, "Not expecting call to fail")
+	err = ncs.Commit(PersistID("p1"))
+	assert.NoError(t, err, "Not expecting call to fail")
+
+	mcli.AssertExpectations(t)
+}
+
+func TestCommitNotSupported(t *testing.T) {
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("Capabilities").Return(common.ParseCapabilities([]string{common.CapWritableRunning}))
+	err := ncs.Commit()
+	assert.True(t, errors.Is(err, ErrNotSupported), "Expecting candidate to be required")
+
+	ncs, mcli = newOpsSessionWithMockClient(t)
+	mcli.On("Capabilities").Return(common.ParseCapabilities([]string{common.CapCandidate}))
+	err = ncs.Commit(Confirmed())
+	assert.True(t, errors.Is(err, ErrNotSupported), "Expecting confirmed-commit to be required")
+
+	ncs, mcli = newOpsSessionWithMockClient(t)
+	mcli.On("Capabilities").Return(common.ParseCapabilities([]string{common.CapCandidate, common.CapConfirmedCommit10}))
+	mcli.On("ExecuteContext", context.Background(), createCommitRequest(Confirmed())).Return(&common.RPCReply{}, nil)
+	err = ncs.Commit(Confirmed())
+	assert.NoError(t, err, "Expecting confirmed-commit 1.0 to be sufficient")
+	err = ncs.Commit(Persist("p1"))
+	assert.EqualError(t, err, "operation not supported by server: requires capability "+common.CapConfirmedCommit, "Expecting confirmed-commit 1.1 to be required")
+
+	mcli.AssertExpectations(t)
+}
+
+func TestCommitRequest(t *testing.T) {
+
+	for _, tc := range []struct {
+		options  []CommitOption
+		expected string
+	}{
+		{nil, `<commit></commit>`},
+		{[]CommitOption{Confirmed()}, `<commit><confirmed/></commit>`},
+		{[]CommitOption{ConfirmTimeout(60)}, `<commit><confirmed/><confirm-timeout>60</confirm-timeout></commit>`},
+		{[]CommitOption{Persist("p1")}, `<commit><confirmed/><persist>p1</persist></commit>`},
+		{[]CommitOption{PersistID("p1")}, `<commit><persist-id>p1</persist-id></commit>`},
+		{[]CommitOption{Confirmed(), PersistID("p1")}, `<commit><confirmed/><persist-id>p1</persist-id></commit>`},
+	} {
+		req, err := xml.Marshal(createCommitRequest(tc.options...))
+		assert.NoError(t, err, "Not expecting marshal to fail")
+		assert.Equal(t, tc.expected, string(req), "Expected commit request")
+	}
+}
+
+func TestCancelCommit(t *testing.T) {
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("Capabilities").Return(common.ParseCapabilities([]string{common.CapCandidate, common.CapConfirmedCommit}))
+	mcli.On("ExecuteContext", context.Background(), createCancelCommitRequest("p1")).Return(&common.RPCReply{}, nil)
+
+	err := ncs.CancelCommit("p1")
+	assert.NoError(t, err, "Not expecting call to fail")
+
+	req, _ := xml.Marshal(createCancelCommitRequest(""))
+	assert.Equal(t, `<cancel-commit></cancel-commit>`, string(req), "Expected cancel-commit request")
+
+	ncs, mcli = newOpsSessionWithMockClient(t)
+	mcli.On("Capabilities").Return(common.ParseCapabilities([]string{common.CapCandidate}))
+	err = ncs.CancelCommit("")
+	assert.True(t, errors.Is(err, ErrNotSupported), "Expecting confirmed-commit to be required")
+
+	mcli.AssertExpectations(t)
+}
+
+func TestValidate(t *testing.T) {
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("Capabilities").Return(common.ParseCapabilities([]string{common.CapValidate}))
+	mcli.On("ExecuteContext", context.Background(), createValidateRequest(DsName(CandidateCfg))).Return(&common.RPCReply{}, nil)
+	mcli.On("ExecuteContext", context.Background(), createValidateRequest(DsCfg(`<top/>`))).Return(&common.RPCReply{}, nil)
+
+	err := ncs.Validate(DsName(CandidateCfg))
+	assert.NoError(t, err, "Not expecting call to fail")
+	err = ncs.Validate(DsCfg(`<top/>`))
+	assert.NoError(t, err, "Not expecting call to fail")
+
+	req, _ := xml.Marshal(createValidateRequest(DsName(CandidateCfg)))
+	assert.Equal(t, `<validate><source><candidate/></source></validate>`, string(req), "Expected validate request")
+	req, _ = xml.Marshal(createValidateRequest(DsCfg(`<top/>`)))
+	assert.Equal(t, `<validate><source><config><top/></config></source></validate>`, string(req), "Expected validate request")
+
+	ncs, mcli = newOpsSessionWithMockClient(t)
+	mcli.On("Capabilities").Return(common.ParseCapabilities([]string{common.CapCandidate}))
+	err = ncs.Validate(DsName(CandidateCfg))
+	assert.True(t, errors.Is(err, ErrNotSupported), "Expecting validate to be required")
+
+	ncs, mcli = newOpsSessionWithMockClient(t)
+	mcli.On("Capabilities").Return(nil)
+	err = ncs.Validate(DsName(CandidateCfg))
+	assert.True(t, errors.Is(err, ErrNotSupported), "Expecting validate to be required when capabilities are unknown")
+
+	mcli.AssertExpectations(t)
+}
+
 func TestCloseSession(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createCloseSessionRequest()).Return(&common.RPCReply{}, nil)
+	mcli.On("ExecuteContext", context.Background(), createCloseSessionRequest()).Return(&common.RPCReply{}, nil)
 
 	err := ncs.CloseSession()
 	assert.NoError(t, err, "Not expecting call to fail")
@@ -271,7 +437,7 @@ func TestCloseSession(t *testing.T) {
 func TestKillSession(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createKillSessionRequest(999)).Return(&common.RPCReply{}, nil)
+	mcli.On("ExecuteContext", context.Background(), createKillSessionRequest(999)).Return(&common.RPCReply{}, nil)
 
 	err := ncs.KillSession(999)
 	assert.NoError(t, err, "Not expecting call to fail")
@@ -283,7 +449,7 @@ func TestGetSchemas(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
 
-	mcli.On("Execute", createGetShemasRequest()).Return(&common.RPCReply{Data: `
+	mcli.On("ExecuteContext", context.Background(), createGetShemasRequest()).Return(&common.RPCReply{Data: `
     <data>
 	<netconf-state xmlns="urn:ietf:params:xml:ns:yang:ietf-netconf-monitoring">
 	<schemas>
@@ -316,7 +482,7 @@ func TestGetSchemas(t *testing.T) {
 func TestGetSchemasExecuteError(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createGetShemasRequest()).Return(nil, errors.New("failure"))
+	mcli.On("ExecuteContext", context.Background(), createGetShemasRequest()).Return(nil, errors.New("failure"))
 
 	_, err := ncs.GetSchemas()
 	assert.Error(t, err, "Expecting exec to fail")
@@ -325,7 +491,7 @@ func TestGetSchemasExecuteError(t *testing.T) {
 func TestGetSchema(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createGetShemaRequest("id", "vsn", "yang")).
+	mcli.On("ExecuteContext", context.Background(), createGetShemaRequest("id", "vsn", "yang")).
 		Return(&common.RPCReply{Data: `<data>Some Yang</data>`}, nil)
 
 	reply, err := ncs.GetSchema("id", "vsn", "yang")
@@ -337,7 +503,7 @@ func TestGetSchema(t *testing.T) {
 func TestGetSchemaExecuteError(t *testing.T) {
 
 	ncs, mcli := newOpsSessionWithMockClient(t)
-	mcli.On("Execute", createGetShemaRequest("id", "vsn", "yang")).
+	mcli.On("ExecuteContext", context.Background(), createGetShemaRequest("id", "vsn", "yang")).
 		Return(nil, errors.New("failed"))
 
 	reply, err := ncs.GetSchema("id", "vsn", "yang")
@@ -29,3 +29,28 @@ func NewSessionWithConfig(ctx context.Context, sshcfg *ssh.ClientConfig, target
 	s = &sImpl{Session: cs}
 	return
 }
+
+// NewResilientSession connects to the target using the ssh configuration, and establishes a netconf session
+// with the client configuration, that is re-established according to the reconnect configuration when the
+// connection is lost.
+func NewResilientSession(ctx context.Context, sshcfg *ssh.ClientConfig, target string, cfg *client.Config,
+	rcfg *client.ReconnectConfig) (s OpSession, err error) {
+
+	var cs client.Session
+	if cs, err = client.NewResilientRPCSession(ctx, sshcfg, target, cfg, rcfg); err != nil {
+		return
+	}
+
+	s = &sImpl{Session: cs}
+	return
+}
+
+// NewCallHomeListener starts listening for devices calling home on the address, establishing a netconf
+// session with each one according to the configuration, and delivering it to the handler.
+func NewCallHomeListener(ctx context.Context, address string, cfg *client.CallHomeConfig,
+	handler func(dev *client.CallHomeDevice, s OpSession)) (*client.CallHomeListener, error) {
+
+	return client.NewCallHomeListener(ctx, address, cfg, func(dev *client.CallHomeDevice, cs client.Session) {
+		handler(dev, &sImpl{Session: cs})
+	})
+}
@@ -52,6 +52,37 @@ func TestSessionSetupSuccess(t *testing.T) {
 	assert.NotNil(t, s, "OpSession should not be nil")
 }
 
+func TestCallHomeSessionSetup(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t).WithRequestHandler(testserver.SmartRequesttHandler)
+	defer ts.Close()
+
+	cfg := &client.CallHomeConfig{
+		SSHConfig: &ssh.ClientConfig{
+			User:            testserver.TestUserName,
+			Auth:            []ssh.AuthMethod{ssh.Password(testserver.TestPassword)},
+			HostKeyCallback: ssh.FixedHostKey(ts.HostKey()),
+		},
+	}
+
+	sch := make(chan OpSession)
+	l, err := NewCallHomeListener(context.Background(), "localhost:0", cfg, func(dev *client.CallHomeDevice, s OpSession) {
+		sch <- s
+	})
+	assert.NoError(t, err, "Expecting new listener to succeed")
+	defer l.Close()
+
+	assert.NoError(t, ts.CallHome(fmt.Sprintf("localhost:%d", l.Port())), "Expecting call home to succeed")
+
+	s := <-sch
+	defer s.Close()
+
+	var result string
+	err = s.GetSubtree(`<top/>`, &result)
+	assert.NoError(t, err, "Not expecting call to fail")
+	assert.Contains(t, result, "<top>", "Reply should contain response data")
+}
+
 // Simple real NE access test
 
 // func TestRealNewSession(t *testing.T) {
@@ -72,3 +103,27 @@ func TestSessionSetupSuccess(t *testing.T) {
 // 	assert.NoError(t, err, "Not expecting exec to fail")
 // 	assert.NotNil(t, reply, "Reply should be non-nil")
 // }
+
+func TestResilientSessionSetup(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t).WithRequestHandler(testserver.SmartRequesttHandler)
+	defer ts.Close()
+
+	sshConfig := &ssh.ClientConfig{
+		User:            testserver.TestUserName,
+		Auth:            []ssh.AuthMethod{ssh.Password(testserver.TestPassword)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}
+
+	s, err := NewResilientSession(context.Background(), sshConfig, fmt.Sprintf("localhost:%d", ts.Port()), client.DefaultConfig, &client.ReconnectConfig{})
+	assert.NoError(t, err, "Expecting new session to succeed")
+	defer s.Close()
+
+	var result string
+	err = s.GetSubtree(`<top/>`, &result)
+	assert.NoError(t, err, "Not expecting get to fail")
+	assert.Equal(t, `<top><sub attr="avalue"><child1>cvalue</child1><child2/></sub></top>`, result, "Expected result")
+
+	_, err = NewResilientSession(context.Background(), sshConfig, "localhost:0", client.DefaultConfig, &client.ReconnectConfig{})
+	assert.Error(t, err, "Expecting new session to fail")
+}
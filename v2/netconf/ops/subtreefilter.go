@@ -0,0 +1,291 @@
+package ops
+
+import (
+	"encoding/xml"
+	"fmt"
+	"reflect"
+	"strings"
+)
+
+// Defines a builder for subtree filters (RFC 6241 6), which can be passed as the filter of GetSubtree and
+// GetConfigSubtree, or of the other operations that accept a subtree filter.
+
+// FilterNode is a node of a subtree filter, which is one of:
+// - a containment node, which contains other filter nodes,
+// - a selection node, which selects the element and all of its descendants, or
+// - a content match node, which selects its siblings if the element has the value.
+// Any node can also hold attribute matches.
+type FilterNode struct {
+	name      string
+	namespace string
+	match     *string
+	attrs     []xml.Attr
+	children  []*FilterNode
+}
+
+// FilterTree is a subtree filter, defined by its top-level filter nodes.
+type FilterTree []*FilterNode
+
+// ContainmentNode delivers a containment node with the children.
+func ContainmentNode(name string, children ...*FilterNode) *FilterNode {
+	return &FilterNode{name: name, children: children}
+}
+
+// SelectionNode delivers a selection node.
+func SelectionNode(name string) *FilterNode {
+	return &FilterNode{name: name}
+}
+
+// ContentMatchNode delivers a content match node, that matches the element with the value.
+func ContentMatchNode(name, value string) *FilterNode {
+	return &FilterNode{name: name, match: &value}
+}
+
+// NS sets the namespace of the node, which is inherited by its descendants.
+func (n *FilterNode) NS(namespace string) *FilterNode {
+	n.namespace = namespace
+	return n
+}
+
+// MatchAttr adds an attribute match, that matches elements where the attribute has the value.
+func (n *FilterNode) MatchAttr(name, value string) *FilterNode {
+	return n.MatchAttrNS("", name, value)
+}
+
+// MatchAttrNS adds an attribute match for an attribute in the namespace, that matches elements where the
+// attribute has the value. A prefix is declared for the namespace on the element.
+func (n *FilterNode) MatchAttrNS(namespace, name, value string) *FilterNode {
+	n.attrs = append(n.attrs, xml.Attr{Name: xml.Name{Space: namespace, Local: name}, Value: value})
+	return n
+}
+
+// Add appends the children to the node, making it a containment node.
+func (n *FilterNode) Add(children ...*FilterNode) *FilterNode {
+	n.children = append(n.children, children...)
+	return n
+}
+
+// MarshalXML encodes the node and its descendants, ignoring start.
+func (n *FilterNode) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	return n.marshal(e, "")
+}
+
+// MarshalXML encodes the nodes of the filter, ignoring start.
+func (t FilterTree) MarshalXML(e *xml.Encoder, _ xml.StartElement) error {
+	for _, n := range t {
+		if err := n.marshal(e, ""); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (n *FilterNode) marshal(e *xml.Encoder, parentNS string) error {
+	var attrs []xml.Attr
+	ns := parentNS
+	if n.namespace != "" && n.namespace != parentNS {
+		ns = n.namespace
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: "xmlns"}, Value: ns})
+	}
+	start := xml.StartElement{Name: xml.Name{Local: n.name}, Attr: append(attrs, n.attrMatches()...)}
+	if err := e.EncodeToken(start); err != nil {
+		return err
+	}
+	if n.match != nil {
+		if err := e.EncodeToken(xml.CharData(*n.match)); err != nil {
+			return err
+		}
+	}
+	for _, c := range n.children {
+		if err := c.marshal(e, ns); err != nil {
+			return err
+		}
+	}
+	return e.EncodeToken(start.End())
+}
+
+// attrMatches delivers the attribute matches, with the prefixes used by namespaced attributes (a0, a1...) declared
+// ahead of them.
+func (n *FilterNode) attrMatches() []xml.Attr {
+	var decls, attrs []xml.Attr
+	prefixes := map[string]string{}
+	for _, a := range n.attrs {
+		if a.Name.Space == "" {
+			attrs = append(attrs, a)
+			continue
+		}
+		prefix, ok := prefixes[a.Name.Space]
+		if !ok {
+			prefix = fmt.Sprintf("a%d", len(prefixes))
+			prefixes[a.Name.Space] = prefix
+			decls = append(decls, xml.Attr{Name: xml.Name{Local: "xmlns:" + prefix}, Value: a.Name.Space})
+		}
+		attrs = append(attrs, xml.Attr{Name: xml.Name{Local: prefix + ":" + a.Name.Local}, Value: a.Value})
+	}
+	return append(decls, attrs...)
+}
+
+// FilterFromStruct delivers a subtree filter built from v, which should be a struct (or the address of a struct)
+// with xml tags, as used to decode the response.
+//   - Non-zero fields holding simple values become content match nodes (or attribute matches for attribute fields).
+//   - Each of the paths, a dot-separated list of field names (e.g. "Interface.Statistics"), becomes a selection
+//     node for the final field, contained by nodes for the other fields.
+//
+// Fields holding structs, or slices of structs, become containment nodes if they contain any matches or selections.
+func FilterFromStruct(v interface{}, paths ...string) (*FilterNode, error) {
+	rv := reflect.Indirect(reflect.ValueOf(v))
+	if rv.Kind() != reflect.Struct {
+		return nil, fmt.Errorf("subtree filter: %T is not a struct", v)
+	}
+	root := ContainmentNode(rv.Type().Name())
+	if f, ok := rv.Type().FieldByName("XMLName"); ok {
+		root.namespace, root.name = parseFilterTag(f.Tag.Get("xml"), root.name)
+	}
+	if err := addStructMatches(root, rv); err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		if err := addSelection(root, rv.Type(), strings.Split(path, "."), path); err != nil {
+			return nil, err
+		}
+	}
+	return root, nil
+}
+
+// parseFilterTag delivers the namespace and element name defined by an xml tag, or the default name.
+func parseFilterTag(tag, name string) (namespace, local string) {
+	tag = strings.Split(tag, ",")[0]
+	if i := strings.LastIndex(tag, " "); i >= 0 {
+		namespace, tag = tag[:i], tag[i+1:]
+	}
+	if tag != "" {
+		name = tag
+	}
+	return namespace, name
+}
+
+// filterField describes the element or attribute that a struct field is encoded as.
+type filterField struct {
+	namespace string
+	name      string
+	attr      bool
+}
+
+func structFilterField(f reflect.StructField) (*filterField, bool) {
+	tag := f.Tag.Get("xml")
+	if f.PkgPath != "" || f.Name == "XMLName" || tag == "-" {
+		return nil, false
+	}
+	ff := &filterField{}
+	opts := strings.Split(tag, ",")
+	for _, opt := range opts[1:] {
+		switch opt {
+		case "attr":
+			ff.attr = true
+		case "chardata", "cdata", "innerxml", "comment", "any":
+			return nil, false
+		}
+	}
+	// Nested element paths (a>b) are not supported by the builder.
+	if strings.Contains(opts[0], ">") {
+		return nil, false
+	}
+	ff.namespace, ff.name = parseFilterTag(tag, f.Name)
+	return ff, true
+}
+
+func addStructMatches(n *FilterNode, rv reflect.Value) error {
+	for i := 0; i < rv.NumField(); i++ {
+		ff, ok := structFilterField(rv.Type().Field(i))
+		if !ok {
+			continue
+		}
+		fv := rv.Field(i)
+		for fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface {
+			if fv.IsNil() {
+				break
+			}
+			fv = fv.Elem()
+		}
+		switch {
+		case fv.Kind() == reflect.Ptr || fv.Kind() == reflect.Interface:
+			// nil
+		case ff.attr:
+			if !fv.IsZero() {
+				n.MatchAttrNS(ff.namespace, ff.name, fmt.Sprint(fv.Interface()))
+			}
+		case fv.Kind() == reflect.Struct:
+			if err := addStructChild(n, ff, fv); err != nil {
+				return err
+			}
+		case fv.Kind() == reflect.Slice && fv.Type().Elem().Kind() != reflect.Uint8:
+			for j := 0; j < fv.Len(); j++ {
+				ev := reflect.Indirect(fv.Index(j))
+				if ev.Kind() == reflect.Struct {
+					if err := addStructChild(n, ff, ev); err != nil {
+						return err
+					}
+				} else if ev.IsValid() {
+					n.Add(ContentMatchNode(ff.name, fmt.Sprint(ev.Interface())).NS(ff.namespace))
+				}
+			}
+		case !fv.IsZero():
+			n.Add(ContentMatchNode(ff.name, fmt.Sprint(fv.Interface())).NS(ff.namespace))
+		}
+	}
+	return nil
+}
+
+func addStructChild(n *FilterNode, ff *filterField, rv reflect.Value) error {
+	child := ContainmentNode(ff.name).NS(ff.namespace)
+	if err := addStructMatches(child, rv); err != nil {
+		return err
+	}
+	if len(child.children) > 0 || len(child.attrs) > 0 {
+		n.Add(child)
+	}
+	return nil
+}
+
+// addSelection adds the nodes selecting the field path to n, whose children are encoded from typ.
+// The path applies to every existing containment node for a field, as there may be several for a slice.
+func addSelection(n *FilterNode, typ reflect.Type, fields []string, path string) error {
+	for typ.Kind() == reflect.Ptr || typ.Kind() == reflect.Slice {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return fmt.Errorf("subtree filter: invalid path %s", path)
+	}
+	f, ok := typ.FieldByName(fields[0])
+	if !ok {
+		return fmt.Errorf("subtree filter: invalid path %s, %s has no field %s", path, typ.Name(), fields[0])
+	}
+	ff, ok := structFilterField(f)
+	if !ok || ff.attr {
+		return fmt.Errorf("subtree filter: invalid path %s, field %s is not an element", path, fields[0])
+	}
+
+	var matching []*FilterNode
+	for _, c := range n.children {
+		if c.name == ff.name && c.namespace == ff.namespace && c.match == nil {
+			matching = append(matching, c)
+		}
+	}
+	if len(fields) == 1 {
+		if len(matching) == 0 {
+			n.Add(SelectionNode(ff.name).NS(ff.namespace))
+		}
+		return nil
+	}
+	if len(matching) == 0 {
+		c := ContainmentNode(ff.name).NS(ff.namespace)
+		n.Add(c)
+		matching = append(matching, c)
+	}
+	for _, c := range matching {
+		if err := addSelection(c, f.Type, fields[1:], path); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,108 @@
+package ops
+
+import (
+	"context"
+	"encoding/xml"
+	"testing"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestFilterTree(t *testing.T) {
+
+	filter := FilterTree{
+		ContainmentNode("top",
+			ContainmentNode("users",
+				ContainmentNode("user",
+					ContentMatchNode("name", "fred & co"),
+					SelectionNode("company-info"),
+				),
+			),
+			ContainmentNode("interfaces").MatchAttr("ifName", "eth0").
+				MatchAttrNS("urn:ann", "tag", "uplink").MatchAttrNS("urn:other", "tag", "x").MatchAttrNS("urn:ann", "owner", "ops"),
+		).NS("http://example.com/schema/1.2/config"),
+		ContainmentNode("system",
+			SelectionNode("hostname"),
+			SelectionNode("state").NS("urn:state"),
+		).NS("urn:sys"),
+	}
+
+	req, err := xml.Marshal(createGetSubtreeRequest(filter))
+	assert.NoError(t, err, "Not expecting marshal to fail")
+	assert.Equal(t, `<get><filter type="subtree">`+
+		`<top xmlns="http://example.com/schema/1.2/config"><users><user><name>fred &amp; co</name><company-info></company-info></user></users>`+
+		`<interfaces xmlns:a0="urn:ann" xmlns:a1="urn:other" ifName="eth0" a0:tag="uplink" a1:tag="x" a0:owner="ops"></interfaces></top>`+
+		`<system xmlns="urn:sys"><hostname></hostname><state xmlns="urn:state"></state></system>`+
+		`</filter></get>`, string(req), "Expected subtree filter")
+}
+
+type filterInterfaces struct {
+	XMLName   xml.Name          `xml:"urn:ietf:params:xml:ns:yang:ietf-interfaces interfaces"`
+	Interface []filterInterface `xml:"interface"`
+}
+
+type filterInterface struct {
+	Name       string            `xml:"name"`
+	Type       string            `xml:"type,attr"`
+	Origin     string            `xml:"urn:ietf:params:xml:ns:yang:ietf-origin origin,attr"`
+	Enabled    bool              `xml:"enabled"`
+	MTU        *int              `xml:"mtu"`
+	Statistics *filterStatistics `xml:"statistics"`
+	IPv4       *filterIPv4       `xml:"urn:ietf:params:xml:ns:yang:ietf-ip ipv4"`
+	Content    string            `xml:",innerxml"`
+}
+
+type filterStatistics struct {
+	InOctets  uint64 `xml:"in-octets"`
+	OutOctets uint64 `xml:"out-octets"`
+}
+
+type filterIPv4 struct {
+	Address []struct {
+		IP string `xml:"ip"`
+	} `xml:"address"`
+}
+
+func TestFilterFromStruct(t *testing.T) {
+
+	filter, err := FilterFromStruct(&filterInterfaces{Interface: []filterInterface{{Name: "eth0"}, {Name: "eth1", Type: "ethernet", Origin: "or:learned"}}},
+		"Interface.Statistics.InOctets", "Interface.IPv4", "Interface.MTU")
+	assert.NoError(t, err, "Not expecting filter to fail")
+
+	req, _ := xml.Marshal(createGetConfigSubtreeRequest(filter, RunningCfg))
+	assert.Equal(t, `<get-config><source><running/></source><filter type="subtree">`+
+		`<interfaces xmlns="urn:ietf:params:xml:ns:yang:ietf-interfaces">`+
+		`<interface><name>eth0</name><statistics><in-octets></in-octets></statistics><ipv4 xmlns="urn:ietf:params:xml:ns:yang:ietf-ip"></ipv4><mtu></mtu></interface>`+
+		`<interface xmlns:a0="urn:ietf:params:xml:ns:yang:ietf-origin" type="ethernet" a0:origin="or:learned"><name>eth1</name><statistics><in-octets></in-octets></statistics><ipv4 xmlns="urn:ietf:params:xml:ns:yang:ietf-ip"></ipv4><mtu></mtu></interface>`+
+		`</interfaces></filter></get-config>`, string(req), "Expected subtree filter")
+
+	filter, err = FilterFromStruct(filterInterfaces{}, "Interface.IPv4.Address.IP")
+	assert.NoError(t, err, "Not expecting filter to fail")
+	out, _ := xml.Marshal(filter)
+	assert.Equal(t, `<interfaces xmlns="urn:ietf:params:xml:ns:yang:ietf-interfaces"><interface><ipv4 xmlns="urn:ietf:params:xml:ns:yang:ietf-ip">`+
+		`<address><ip></ip></address></ipv4></interface></interfaces>`, string(out), "Expected subtree filter")
+
+	_, err = FilterFromStruct(filterInterfaces{}, "Interface.Speed")
+	assert.EqualError(t, err, "subtree filter: invalid path Interface.Speed, filterInterface has no field Speed", "Expected invalid path")
+	_, err = FilterFromStruct(filterInterfaces{}, "Interface.Type")
+	assert.EqualError(t, err, "subtree filter: invalid path Interface.Type, field Type is not an element", "Expected invalid path")
+	_, err = FilterFromStruct("top")
+	assert.EqualError(t, err, "subtree filter: string is not a struct", "Expected invalid filter")
+}
+
+func TestGetSubtreeFilterTree(t *testing.T) {
+
+	filter := ContainmentNode("top", ContentMatchNode("name", "x")).NS("urn:tns")
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("ExecuteContext", context.Background(), createGetSubtreeRequest(filter)).Return(&common.RPCReply{Data: `<data><top xmlns="urn:tns"><name>x</name></top></data>`}, nil)
+
+	var result string
+	err := ncs.GetSubtree(filter, &result)
+	assert.NoError(t, err, "Not expecting get to fail")
+	assert.Equal(t, `<top xmlns="urn:tns"><name>x</name></top>`, result, "Expected result")
+
+	mcli.AssertExpectations(t)
+}
@@ -0,0 +1,306 @@
+package ops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+
+	"github.com/imdario/mergo"
+)
+
+// Defines a configuration transaction, which applies a set of edits to a device as a single unit.
+//
+// If the device supports the candidate datastore, the transaction will:
+// - lock the candidate datastore,
+// - apply the edits to the candidate datastore,
+// - validate the candidate datastore (if the :validate capability is supported),
+// - commit the changes (as a confirmed commit, if configured and the :confirmed-commit capability is supported),
+// - verify the changes (if configured),
+// - confirm the commit, and
+// - unlock the candidate datastore.
+// If any step fails, the changes are cancelled and/or discarded, and the datastore is unlocked.
+//
+// Otherwise, if the device supports the :writable-running capability, the transaction will lock the running
+// datastore, apply the edits with the rollback-on-error option (if supported), verify the changes (if configured)
+// and unlock the datastore. Note that a failed edit can only roll back its own changes, so edits that have already
+// been applied will remain in effect.
+
+// TransactionStep identifies a step of a configuration transaction.
+type TransactionStep string
+
+// Define the steps of a configuration transaction.
+const (
+	StepLock     TransactionStep = "lock"
+	StepEdit     TransactionStep = "edit-config"
+	StepValidate TransactionStep = "validate"
+	StepCommit   TransactionStep = "commit"
+	StepVerify   TransactionStep = "verify"
+	StepConfirm  TransactionStep = "confirm"
+	StepCancel   TransactionStep = "cancel-commit"
+	StepDiscard  TransactionStep = "discard-changes"
+	StepUnlock   TransactionStep = "unlock"
+)
+
+// TransactionConfig defines properties that configure transaction behaviour.
+type TransactionConfig struct {
+	// ConfirmTimeout defines the time, in seconds, within which a confirmed commit must be confirmed.
+	// If zero, or the device does not support the :confirmed-commit capability, a simple commit is issued.
+	ConfirmTimeout uint32
+	// Verify, if defined, is called after the changes have been committed (but before they are confirmed),
+	// to verify that the device is behaving as expected. If it returns an error, the changes are cancelled
+	// where possible.
+	Verify func(ctx context.Context, s OpSession) error
+	// CleanupTimeout defines the time allowed for the requests that cancel, discard and unlock following
+	// a failure, which are issued even if the transaction context is done.
+	CleanupTimeout time.Duration
+}
+
+// DefaultTransactionConfig defines the default transaction configuration.
+var DefaultTransactionConfig = &TransactionConfig{
+	CleanupTimeout: time.Second * time.Duration(30),
+}
+
+// Edit defines an edit-config operation to be applied by a transaction.
+type Edit struct {
+	Config  ConfigOption
+	Options []EditOption
+}
+
+// TransactionError reports the failure of a transaction step.
+type TransactionError struct {
+	Step TransactionStep
+	Err  error
+}
+
+func (e *TransactionError) Error() string {
+	return fmt.Sprintf("transaction %s failed: %v", e.Step, e.Err)
+}
+
+// Unwrap delivers the error that caused the step to fail.
+func (e *TransactionError) Unwrap() error {
+	return e.Err
+}
+
+// TransactionResult reports the outcome of a transaction.
+type TransactionResult struct {
+	// Target is the datastore to which the edits were applied.
+	Target string
+	// Completed lists the steps that completed successfully, in order.
+	Completed []TransactionStep
+	// Err reports the step that failed, if any.
+	Err *TransactionError
+	// RPCError holds the details of the rpc-error that caused the step to fail, if any.
+	RPCError *common.RPCError
+	// Cleanup lists the steps taken to cancel, discard and unlock after the failure, or to unlock after success.
+	Cleanup []TransactionStep
+	// CleanupErrors reports the cleanup steps that failed.
+	CleanupErrors []*TransactionError
+}
+
+// FailedStep delivers the step that failed, or an empty string if the transaction succeeded.
+func (r *TransactionResult) FailedStep() TransactionStep {
+	if r.Err == nil {
+		return ""
+	}
+	return r.Err.Step
+}
+
+// RunTransaction applies the edits to the device as a single unit, as described above.
+// It returns a result describing the steps taken, and an error (of type *TransactionError) if the transaction failed.
+func RunTransaction(ctx context.Context, s OpSession, cfg *TransactionConfig, edits ...Edit) (*TransactionResult, error) {
+
+	// Use supplied config, but apply any defaults to unspecified values.
+	var resolvedConfig TransactionConfig = *cfg
+	_ = mergo.Merge(&resolvedConfig, DefaultTransactionConfig)
+
+	t := &transaction{ctx: ctx, s: s, cfg: &resolvedConfig, caps: s.Capabilities(), result: &TransactionResult{}}
+	switch {
+	case t.caps.Supports(common.CapCandidate):
+		t.runCandidate(edits)
+	case t.caps.Supports(common.CapWritableRunning):
+		t.runRunning(edits)
+	default:
+		t.fail(StepEdit, fmt.Errorf("%w: requires capability %s or %s", ErrNotSupported, common.CapCandidate, common.CapWritableRunning))
+	}
+	t.cleanup(t.result.Err != nil)
+
+	if t.result.Err != nil {
+		return t.result, t.result.Err
+	}
+	return t.result, nil
+}
+
+type transaction struct {
+	ctx    context.Context
+	s      OpSession
+	cfg    *TransactionConfig
+	caps   *common.Capabilities
+	result *TransactionResult
+	// If defined, called as each step is taken.
+	progress func(step TransactionStep, err error)
+	// Steps to be taken when the transaction ends, in reverse order.
+	deferred []deferredStep
+}
+
+type deferredStep struct {
+	step TransactionStep
+	// If true, the step is only taken if the transaction fails.
+	onFailure bool
+	fn        func(ctx context.Context) error
+}
+
+func (t *transaction) runCandidate(edits []Edit) {
+
+	if !t.editCandidate(edits) || !t.validateCandidate() {
+		return
+	}
+
+	confirmed := t.cfg.ConfirmTimeout > 0 && t.caps.Supports(common.CapConfirmedCommit, common.CapConfirmedCommit10)
+	var options []CommitOption
+	if confirmed {
+		options = append(options, ConfirmTimeout(t.cfg.ConfirmTimeout))
+	}
+	if !t.do(StepCommit, func(ctx context.Context) error { return t.s.CommitContext(ctx, options...) }) {
+		return
+	}
+	if confirmed && t.caps.Supports(common.CapConfirmedCommit) {
+		// Otherwise, the commit will be rolled back when the confirm timeout expires.
+		t.deferStep(StepCancel, true, func(ctx context.Context) error { return t.s.CancelCommitContext(ctx, "") })
+	}
+
+	if !t.verify() {
+		return
+	}
+
+	if confirmed {
+		t.do(StepConfirm, func(ctx context.Context) error { return t.s.CommitContext(ctx) })
+	}
+}
+
+// editCandidate locks the candidate datastore and applies the edits, arranging for the changes to be discarded
+// if the transaction fails, and the datastore to be unlocked when it ends.
+func (t *transaction) editCandidate(edits []Edit) bool {
+
+	t.result.Target = CandidateCfg
+	if !t.do(StepLock, func(ctx context.Context) error { return t.s.LockContext(ctx, CandidateCfg) }) {
+		return false
+	}
+	t.deferStep(StepUnlock, false, func(ctx context.Context) error { return t.s.UnlockContext(ctx, CandidateCfg) })
+	t.deferStep(StepDiscard, true, t.s.DiscardContext)
+
+	for _, edit := range edits {
+		if !t.edit(CandidateCfg, edit) {
+			return false
+		}
+	}
+	return true
+}
+
+// validateCandidate validates the candidate datastore, if the :validate capability is supported.
+func (t *transaction) validateCandidate() bool {
+	if !t.caps.Supports(common.CapValidate, common.CapValidate10) {
+		return true
+	}
+	return t.do(StepValidate, func(ctx context.Context) error { return t.s.ValidateContext(ctx, DsName(CandidateCfg)) })
+}
+
+func (t *transaction) runRunning(edits []Edit) {
+
+	t.result.Target = RunningCfg
+	if !t.do(StepLock, func(ctx context.Context) error { return t.s.LockContext(ctx, RunningCfg) }) {
+		return
+	}
+	t.deferStep(StepUnlock, false, func(ctx context.Context) error { return t.s.UnlockContext(ctx, RunningCfg) })
+
+	for _, edit := range edits {
+		if t.caps.Supports(common.CapRollbackOnError) {
+			edit.Options = append([]EditOption{ErrorOption(RollbackOnErrorErrOpt)}, edit.Options...)
+		}
+		if !t.edit(RunningCfg, edit) {
+			return
+		}
+	}
+
+	t.verify()
+}
+
+func (t *transaction) edit(target string, edit Edit) bool {
+	return t.do(StepEdit, func(ctx context.Context) error {
+		return t.s.EditConfigContext(ctx, target, edit.Config, edit.Options...)
+	})
+}
+
+func (t *transaction) verify() bool {
+	if t.cfg.Verify == nil {
+		return true
+	}
+	return t.do(StepVerify, func(ctx context.Context) error { return t.cfg.Verify(ctx, t.s) })
+}
+
+// do takes the step, recording its outcome, and returns true if it was successful.
+func (t *transaction) do(step TransactionStep, fn func(ctx context.Context) error) bool {
+	err := fn(t.ctx)
+	t.report(step, err)
+	if err != nil {
+		t.fail(step, err)
+		return false
+	}
+	t.result.Completed = append(t.result.Completed, step)
+	return true
+}
+
+func (t *transaction) fail(step TransactionStep, err error) {
+	t.result.Err = &TransactionError{Step: step, Err: err}
+	var rpcErr *common.RPCError
+	if errors.As(err, &rpcErr) {
+		t.result.RPCError = rpcErr
+	}
+}
+
+// deferStep arranges for the step to be taken when the transaction ends, or only if it fails.
+func (t *transaction) deferStep(step TransactionStep, onFailure bool, fn func(ctx context.Context) error) {
+	t.deferred = append(t.deferred, deferredStep{step: step, onFailure: onFailure, fn: fn})
+}
+
+// dropDeferred removes the step from those to be taken when the transaction ends.
+func (t *transaction) dropDeferred(step TransactionStep) {
+	deferred := t.deferred[:0]
+	for _, d := range t.deferred {
+		if d.step != step {
+			deferred = append(deferred, d)
+		}
+	}
+	t.deferred = deferred
+}
+
+// cleanup takes the deferred steps, in reverse order, including those to be taken on failure if failed is true.
+// It uses a context that is independent of the transaction context, so that cleanup is attempted even if the
+// transaction context is done.
+func (t *transaction) cleanup(failed bool) {
+
+	ctx, cancel := context.WithTimeout(context.Background(), t.cfg.CleanupTimeout)
+	defer cancel()
+
+	for i := len(t.deferred) - 1; i >= 0; i-- {
+		d := t.deferred[i]
+		if d.onFailure && !failed {
+			continue
+		}
+		err := d.fn(ctx)
+		t.report(d.step, err)
+		if err != nil {
+			t.result.CleanupErrors = append(t.result.CleanupErrors, &TransactionError{Step: d.step, Err: err})
+		} else {
+			t.result.Cleanup = append(t.result.Cleanup, d.step)
+		}
+	}
+}
+
+func (t *transaction) report(step TransactionStep, err error) {
+	if t.progress != nil {
+		t.progress(step, err)
+	}
+}
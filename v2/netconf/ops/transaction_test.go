@@ -0,0 +1,158 @@
+package ops
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+	"github.com/damianoneill/net/v2/netconf/testserver"
+
+	assert "github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+var candidateCapabilities = []string{
+	common.CapBase10,
+	common.CapBase11,
+	common.CapCandidate,
+	common.CapConfirmedCommit,
+	common.CapValidate,
+}
+
+var transactionEdits = []Edit{
+	{Config: Cfg(`<top><sub>1</sub></top>`)},
+	{Config: Cfg(`<top><sub>2</sub></top>`), Options: []EditOption{DefaultOperation(MergeOp)}},
+}
+
+func TestTransactionCandidate(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t).WithCapabilities(candidateCapabilities)
+	defer ts.Close()
+	ncs := newTransactionSession(t, ts)
+	defer ncs.Close()
+
+	verified := false
+	cfg := &TransactionConfig{ConfirmTimeout: 60, Verify: func(ctx context.Context, s OpSession) error {
+		verified = true
+		return nil
+	}}
+	result, err := RunTransaction(context.Background(), ncs, cfg, transactionEdits...)
+	assert.NoError(t, err, "Not expecting transaction to fail")
+	assert.True(t, verified, "Expected changes to be verified")
+	assert.Equal(t, CandidateCfg, result.Target, "Expected candidate target")
+	assert.Equal(t, []TransactionStep{StepLock, StepEdit, StepEdit, StepValidate, StepCommit, StepVerify, StepConfirm}, result.Completed, "Expected completed steps")
+	assert.Equal(t, []TransactionStep{StepUnlock}, result.Cleanup, "Expected cleanup steps")
+	assert.Equal(t, TransactionStep(""), result.FailedStep(), "Not expecting failed step")
+
+	sh := ts.SessionHandler(ncs.ID())
+	assert.Equal(t, []string{"lock", "edit-config", "edit-config", "validate", "commit", "commit", "unlock"}, sh.ReqNames(), "Expected requests")
+	assert.Equal(t, "<confirmed/><confirm-timeout>60</confirm-timeout>", sh.Reqs[4].Body, "Expected confirmed commit")
+}
+
+func TestTransactionCandidateValidateFailure(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t).WithCapabilities(candidateCapabilities).
+		WithRequestHandler(testserver.EchoRequestHandler).
+		WithRequestHandler(testserver.EchoRequestHandler).
+		WithRequestHandler(testserver.FailingRequestHandler)
+	defer ts.Close()
+	ncs := newTransactionSession(t, ts)
+	defer ncs.Close()
+
+	result, err := RunTransaction(context.Background(), ncs, &TransactionConfig{}, transactionEdits[0])
+	assert.Error(t, err, "Expecting transaction to fail")
+	assert.Equal(t, StepValidate, result.FailedStep(), "Expected validate to fail")
+	assert.Equal(t, "oops", result.RPCError.Message, "Expected rpc-error details")
+
+	var txErr *TransactionError
+	assert.True(t, errors.As(err, &txErr), "Expected transaction error")
+	assert.Equal(t, StepValidate, txErr.Step, "Expected validate to fail")
+	var rpcErr *common.RPCError
+	assert.True(t, errors.As(err, &rpcErr), "Expected rpc error")
+
+	assert.Equal(t, []TransactionStep{StepDiscard, StepUnlock}, result.Cleanup, "Expected cleanup steps")
+	assert.Equal(t, []string{"lock", "edit-config", "validate", "discard-changes", "unlock"}, ts.SessionHandler(ncs.ID()).ReqNames(), "Expected requests")
+}
+
+func TestTransactionCandidateVerifyFailure(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t).WithCapabilities(candidateCapabilities)
+	defer ts.Close()
+	ncs := newTransactionSession(t, ts)
+	defer ncs.Close()
+
+	cfg := &TransactionConfig{ConfirmTimeout: 60, Verify: func(ctx context.Context, s OpSession) error {
+		return errors.New("unreachable")
+	}}
+	result, err := RunTransaction(context.Background(), ncs, cfg, transactionEdits[0])
+	assert.EqualError(t, err, "transaction verify failed: unreachable", "Expecting transaction to fail")
+	assert.Nil(t, result.RPCError, "Not expecting rpc-error details")
+	assert.Equal(t, []TransactionStep{StepCancel, StepDiscard, StepUnlock}, result.Cleanup, "Expected cleanup steps")
+	assert.Equal(t, []string{"lock", "edit-config", "validate", "commit", "cancel-commit", "discard-changes", "unlock"},
+		ts.SessionHandler(ncs.ID()).ReqNames(), "Expected requests")
+}
+
+func TestTransactionCandidateLockFailure(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t).WithCapabilities(candidateCapabilities).
+		WithRequestHandler(testserver.FailingRequestHandler)
+	defer ts.Close()
+	ncs := newTransactionSession(t, ts)
+	defer ncs.Close()
+
+	result, err := RunTransaction(context.Background(), ncs, &TransactionConfig{}, transactionEdits...)
+	assert.Error(t, err, "Expecting transaction to fail")
+	assert.Equal(t, StepLock, result.FailedStep(), "Expected lock to fail")
+	assert.Empty(t, result.Cleanup, "Not expecting cleanup")
+	assert.Equal(t, []string{"lock"}, ts.SessionHandler(ncs.ID()).ReqNames(), "Expected requests")
+}
+
+func TestTransactionRunning(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t).
+		WithCapabilities([]string{common.CapBase10, common.CapBase11, common.CapWritableRunning, common.CapRollbackOnError}).
+		WithRequestHandler(testserver.EchoRequestHandler).
+		WithRequestHandler(testserver.EchoRequestHandler).
+		WithRequestHandler(testserver.FailingRequestHandler)
+	defer ts.Close()
+	ncs := newTransactionSession(t, ts)
+	defer ncs.Close()
+
+	result, err := RunTransaction(context.Background(), ncs, &TransactionConfig{}, transactionEdits...)
+	assert.Error(t, err, "Expecting transaction to fail")
+	assert.Equal(t, RunningCfg, result.Target, "Expected running target")
+	assert.Equal(t, StepEdit, result.FailedStep(), "Expected edit to fail")
+	assert.Equal(t, []TransactionStep{StepLock, StepEdit}, result.Completed, "Expected completed steps")
+	assert.Equal(t, []TransactionStep{StepUnlock}, result.Cleanup, "Expected cleanup steps")
+
+	sh := ts.SessionHandler(ncs.ID())
+	assert.Equal(t, []string{"lock", "edit-config", "edit-config", "unlock"}, sh.ReqNames(), "Expected requests")
+	assert.Equal(t, `<target><running/></target><error-option>rollback-on-error</error-option>`+
+		`<default-operation>merge</default-operation><config><top><sub>2</sub></top></config>`, sh.Reqs[2].Body, "Expected rollback-on-error")
+}
+
+func TestTransactionNotSupported(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t)
+	defer ts.Close()
+	ncs := newTransactionSession(t, ts)
+	defer ncs.Close()
+
+	result, err := RunTransaction(context.Background(), ncs, &TransactionConfig{}, transactionEdits...)
+	assert.True(t, errors.Is(err, ErrNotSupported), "Expecting transaction to be unsupported")
+	assert.Empty(t, result.Completed, "Not expecting completed steps")
+	assert.Equal(t, 0, ts.SessionHandler(ncs.ID()).ReqCount(), "Not expecting requests")
+}
+
+func newTransactionSession(t *testing.T, ts *testserver.TestNCServer) OpSession {
+	sshConfig := &ssh.ClientConfig{
+		User:            testserver.TestUserName,
+		Auth:            []ssh.AuthMethod{ssh.Password(testserver.TestPassword)},
+		HostKeyCallback: ssh.InsecureIgnoreHostKey(),
+	}
+	s, err := NewSession(context.Background(), sshConfig, fmt.Sprintf("localhost:%d", ts.Port()))
+	assert.NoError(t, err, "Not expecting new session to fail")
+	return s
+}
@@ -0,0 +1,93 @@
+package ops
+
+import (
+	"encoding/xml"
+	"fmt"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+)
+
+// Defines the with-defaults retrieval option of the get, get-config and copy-config operations (RFC 6243),
+// which controls how data nodes that hold default values are reported.
+
+// Define with-defaults namespaces.
+const (
+	// WithDefaultsNS is the namespace of the with-defaults parameter.
+	WithDefaultsNS = "urn:ietf:params:xml:ns:yang:ietf-netconf-with-defaults"
+	// DefaultAttrNS is the namespace of the default attribute used to tag default values.
+	DefaultAttrNS = "urn:ietf:params:xml:ns:netconf:default:1.0"
+)
+
+// Define the with-defaults retrieval modes.
+const (
+	// ReportAllMode reports all data nodes, including those that hold default values.
+	ReportAllMode = "report-all"
+	// ReportAllTaggedMode reports all data nodes, and tags those that hold default values with wd:default="true".
+	ReportAllTaggedMode = "report-all-tagged"
+	// TrimMode omits data nodes that hold default values.
+	TrimMode = "trim"
+	// ExplicitMode reports data nodes that have been explicitly set, even if they hold default values.
+	ExplicitMode = "explicit"
+)
+
+// RetrievalOption qualifies the data retrieved by get, get-config and copy-config operations.
+type RetrievalOption func(*Retrieval)
+
+// WithDefaults requests that data nodes holding default values are reported according to the mode, which must be
+// supported by the server's :with-defaults capability.
+// In ReportAllTaggedMode, the default values can be identified by decoding into Tagged or TaggedLeaf types.
+func WithDefaults(mode string) RetrievalOption {
+	return func(r *Retrieval) {
+		r.WithDefaults = &WithDefaultsParam{Mode: mode}
+	}
+}
+
+// Tagged can be embedded in a struct with xml tags, to report whether the element was tagged as holding its
+// default value in a report-all-tagged response.
+type Tagged struct {
+	Default bool `xml:"urn:ietf:params:xml:ns:netconf:default:1.0 default,attr,omitempty"`
+}
+
+// TaggedLeaf can be used to decode the value of a leaf, and whether it was tagged as holding its default value
+// in a report-all-tagged response.
+type TaggedLeaf struct {
+	Tagged
+	Value string `xml:",chardata"`
+}
+
+// Retrieval holds the retrieval options of a request.
+type Retrieval struct {
+	WithDefaults *WithDefaultsParam
+}
+
+type WithDefaultsParam struct {
+	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:yang:ietf-netconf-with-defaults with-defaults"`
+	Mode    string   `xml:",chardata"`
+}
+
+func (r *Retrieval) applyOpts(options ...RetrievalOption) {
+	for _, opt := range options {
+		opt(r)
+	}
+}
+
+// requireRetrievalCapabilities verifies that the server supports the retrieval options.
+func (s *sImpl) requireRetrievalCapabilities(options []RetrievalOption) error {
+	r := &Retrieval{}
+	r.applyOpts(options...)
+	if r.WithDefaults == nil {
+		return nil
+	}
+	mode := r.WithDefaults.Mode
+	if wd := s.Capabilities().Capability(common.CapWithDefaults); wd != nil {
+		if wd.Param("basic-mode") == mode {
+			return nil
+		}
+		for _, m := range wd.ParamList("also-supported") {
+			if m == mode {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%w: requires capability %s supporting %s mode", ErrNotSupported, common.CapWithDefaults, mode)
+}
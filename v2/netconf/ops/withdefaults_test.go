@@ -0,0 +1,92 @@
+package ops
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"testing"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+var withDefaultsCapabilities = []string{common.CapBase11, common.CapWithDefaults + "?basic-mode=explicit&also-supported=report-all-tagged,trim"}
+
+func TestWithDefaultsRequests(t *testing.T) {
+
+	req, _ := xml.Marshal(createGetSubtreeRequest(`<top/>`, WithDefaults(ReportAllMode)))
+	assert.Equal(t, `<get><filter type="subtree"><top/></filter>`+
+		`<with-defaults xmlns="urn:ietf:params:xml:ns:yang:ietf-netconf-with-defaults">report-all</with-defaults></get>`, string(req), "Expected get request")
+
+	req, _ = xml.Marshal(createGetXpathRequest(`/tns:top`, []Namespace{{"tns", "urn:tns"}}, WithDefaults(TrimMode)))
+	assert.Equal(t, `<get><filter xmlns:tns="urn:tns" type="xpath" select="/tns:top"/>`+
+		`<with-defaults xmlns="urn:ietf:params:xml:ns:yang:ietf-netconf-with-defaults">trim</with-defaults></get>`, string(req), "Expected get request")
+
+	req, _ = xml.Marshal(createGetConfigSubtreeRequest(`<top/>`, RunningCfg, WithDefaults(ExplicitMode)))
+	assert.Equal(t, `<get-config><source><running/></source><filter type="subtree"><top/></filter>`+
+		`<with-defaults xmlns="urn:ietf:params:xml:ns:yang:ietf-netconf-with-defaults">explicit</with-defaults></get-config>`, string(req), "Expected get-config request")
+
+	req, _ = xml.Marshal(createGetConfigXpathRequest(`/top`, RunningCfg, nil, WithDefaults(ReportAllTaggedMode)))
+	assert.Equal(t, `<get-config><source><running/></source><filter type="xpath" select="/top"/>`+
+		`<with-defaults xmlns="urn:ietf:params:xml:ns:yang:ietf-netconf-with-defaults">report-all-tagged</with-defaults></get-config>`, string(req), "Expected get-config request")
+
+	req, _ = xml.Marshal(createCopyConfigRequest(DsName(RunningCfg), DsUrl("file://cfg.xml"), WithDefaults(ReportAllMode)))
+	assert.Equal(t, `<copy-config><target><url>file://cfg.xml</url></target><source><running/></source>`+
+		`<with-defaults xmlns="urn:ietf:params:xml:ns:yang:ietf-netconf-with-defaults">report-all</with-defaults></copy-config>`, string(req), "Expected copy-config request")
+
+	req, _ = xml.Marshal(createGetSubtreeRequest(`<top/>`))
+	assert.Equal(t, `<get><filter type="subtree"><top/></filter></get>`, string(req), "Not expecting with-defaults")
+}
+
+func TestWithDefaultsTagged(t *testing.T) {
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("Capabilities").Return(common.ParseCapabilities(withDefaultsCapabilities))
+	mcli.On("ExecuteContext", context.Background(), createGetConfigSubtreeRequest(`<interface/>`, RunningCfg, WithDefaults(ReportAllTaggedMode))).
+		Return(&common.RPCReply{Data: `<data><interface xmlns:wd="urn:ietf:params:xml:ns:netconf:default:1.0">` +
+			`<name>eth0</name><mtu wd:default="true">1500</mtu><status wd:default="true"><up/></status></interface></data>`}, nil)
+
+	type status struct {
+		Tagged
+		Up *struct{} `xml:"up"`
+	}
+	type intf struct {
+		XMLName xml.Name   `xml:"interface"`
+		Name    TaggedLeaf `xml:"name"`
+		Mtu     TaggedLeaf `xml:"mtu"`
+		Status  status     `xml:"status"`
+	}
+	result := &intf{}
+	err := ncs.GetConfigSubtree(`<interface/>`, RunningCfg, result, WithDefaults(ReportAllTaggedMode))
+	assert.NoError(t, err, "Not expecting call to fail")
+	assert.Equal(t, "eth0", result.Name.Value, "Expected leaf value")
+	assert.False(t, result.Name.Default, "Not expecting default value")
+	assert.Equal(t, "1500", result.Mtu.Value, "Expected leaf value")
+	assert.True(t, result.Mtu.Default, "Expecting default value")
+	assert.True(t, result.Status.Default, "Expecting default container")
+	assert.NotNil(t, result.Status.Up, "Expected container content")
+
+	mcli.AssertExpectations(t)
+}
+
+func TestWithDefaultsNotSupported(t *testing.T) {
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("Capabilities").Return(common.ParseCapabilities(withDefaultsCapabilities))
+	mcli.On("ExecuteContext", context.Background(), createGetSubtreeRequest(`<top/>`, WithDefaults(ExplicitMode))).Return(&common.RPCReply{Data: `<data/>`}, nil)
+
+	var result string
+	err := ncs.GetSubtree(`<top/>`, &result, WithDefaults(ExplicitMode))
+	assert.NoError(t, err, "Expecting basic-mode to be supported")
+
+	err = ncs.GetSubtree(`<top/>`, &result, WithDefaults(ReportAllMode))
+	assert.True(t, errors.Is(err, ErrNotSupported), "Expecting report-all mode to be unsupported")
+
+	ncs, mcli = newOpsSessionWithMockClient(t)
+	mcli.On("Capabilities").Return(common.ParseCapabilities([]string{common.CapBase11}))
+	err = ncs.CopyConfig(DsName(RunningCfg), DsName(StartupCfg), WithDefaults(TrimMode))
+	assert.True(t, errors.Is(err, ErrNotSupported), "Expecting with-defaults capability to be required")
+
+	mcli.AssertExpectations(t)
+}
@@ -0,0 +1,554 @@
+package ops
+
+import (
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Defines the xpath filter used by the get and get-config operations, which is validated before it is sent, so that
+// a malformed expression or an undeclared prefix is reported by the client rather than the server.
+
+// ErrInvalidXPath is returned when an xpath filter is not a valid XPath 1.0 expression, or uses a prefix that is not
+// declared by its namespace list.
+var ErrInvalidXPath = errors.New("invalid xpath")
+
+// XPathFilter is an xpath filter expression, with the namespaces used by its prefixes.
+type XPathFilter struct {
+	Select     string
+	Namespaces []Namespace
+}
+
+// NewXPathFilter delivers a filter for the xpath expression, after checking its syntax and that every prefix used
+// by the expression is declared by the namespace list.
+func NewXPathFilter(xpath string, nslist []Namespace) (*XPathFilter, error) {
+	f := &XPathFilter{Select: xpath, Namespaces: nslist}
+	if err := f.Validate(); err != nil {
+		return nil, err
+	}
+	return f, nil
+}
+
+// Validate checks the syntax of the filter expression, that every namespace prefix is an NCName, and that every
+// prefix used by the expression is declared.
+func (f *XPathFilter) Validate() error {
+	prefixes, err := xpathPrefixes(f.Select)
+	if err != nil {
+		return err
+	}
+	declared, err := declaredPrefixes(f.Namespaces)
+	if err != nil {
+		return err
+	}
+	for _, p := range prefixes {
+		if !declared[p] {
+			return fmt.Errorf("%w: prefix %q is not declared in %q", ErrInvalidXPath, p, f.Select)
+		}
+	}
+	return nil
+}
+
+// element delivers the filter element, with the expression and namespaces escaped as attribute values.
+// A namespace whose prefix is not an NCName cannot be declared, and is omitted.
+func (f *XPathFilter) element() string {
+	var b strings.Builder
+	b.WriteString("<filter")
+	for _, ns := range f.Namespaces {
+		if !isNCName(ns.Id) {
+			continue
+		}
+		b.WriteString(` xmlns:` + ns.Id + `="`)
+		_ = xml.EscapeText(&b, []byte(ns.Path))
+		b.WriteString(`"`)
+	}
+	b.WriteString(` type="xpath" select="`)
+	_ = xml.EscapeText(&b, []byte(f.Select))
+	b.WriteString(`"/>`)
+	return b.String()
+}
+
+// ModuleRegistry maps YANG module names to their namespaces, so that xpath expressions can use module names as
+// prefixes without declaring them.
+type ModuleRegistry map[string]string
+
+// NewModuleRegistry delivers a registry of the modules described by schemas, as returned by GetSchemas.
+func NewModuleRegistry(schemas []Schema) ModuleRegistry {
+	r := ModuleRegistry{}
+	for _, s := range schemas {
+		if s.Namespace != "" {
+			r[s.Identifier] = s.Namespace
+		}
+	}
+	return r
+}
+
+// XPathFilter delivers a filter for the xpath expression, where any prefix that is not declared by the namespace
+// list is inferred by looking it up as a module name in the registry.
+func (r ModuleRegistry) XPathFilter(xpath string, nslist []Namespace) (*XPathFilter, error) {
+	prefixes, err := xpathPrefixes(xpath)
+	if err != nil {
+		return nil, err
+	}
+	declared, err := declaredPrefixes(nslist)
+	if err != nil {
+		return nil, err
+	}
+	f := &XPathFilter{Select: xpath, Namespaces: append([]Namespace(nil), nslist...)}
+	for _, p := range prefixes {
+		if declared[p] {
+			continue
+		}
+		path, ok := r[p]
+		if !ok {
+			return nil, fmt.Errorf("%w: prefix %q is not declared or a known module in %q", ErrInvalidXPath, p, xpath)
+		}
+		f.Namespaces = append(f.Namespaces, Namespace{Id: p, Path: path})
+		declared[p] = true
+	}
+	return f, nil
+}
+
+// declaredPrefixes delivers the prefixes declared by the namespace list, and the predefined xml prefix, after
+// checking that each is an NCName, so that it can be declared by the filter element.
+func declaredPrefixes(nslist []Namespace) (map[string]bool, error) {
+	declared := map[string]bool{"xml": true}
+	for _, ns := range nslist {
+		if !isNCName(ns.Id) {
+			return nil, fmt.Errorf("%w: namespace prefix %q is not an NCName", ErrInvalidXPath, ns.Id)
+		}
+		declared[ns.Id] = true
+	}
+	return declared, nil
+}
+
+// xpathPrefixes parses the XPath 1.0 expression, and delivers the prefixes used by its names, in order.
+func xpathPrefixes(expr string) ([]string, error) {
+	tokens, err := tokenizeXPath(expr)
+	if err != nil {
+		return nil, err
+	}
+	p := &xpathParser{expr: expr, tokens: tokens, used: map[string]bool{}}
+	if err = p.parseExpr(); err != nil {
+		return nil, err
+	}
+	if t := p.peek(); t.kind != xtEOF {
+		return nil, p.errorf(t, "unexpected %q", t.val)
+	}
+	prefixes := make([]string, 0, len(p.used))
+	for prefix := range p.used {
+		prefixes = append(prefixes, prefix)
+	}
+	sort.Strings(prefixes)
+	return prefixes, nil
+}
+
+type xpathTokenKind int
+
+const (
+	xtEOF xpathTokenKind = iota
+	xtName
+	xtLiteral
+	xtNumber
+	xtVariable
+	xtOperator
+	xtPunct
+)
+
+type xpathToken struct {
+	kind xpathTokenKind
+	val  string
+	pos  int
+}
+
+var xpathAxes = map[string]bool{
+	"ancestor": true, "ancestor-or-self": true, "attribute": true, "child": true, "descendant": true,
+	"descendant-or-self": true, "following": true, "following-sibling": true, "namespace": true, "parent": true,
+	"preceding": true, "preceding-sibling": true, "self": true,
+}
+
+var xpathNodeTypes = map[string]bool{"comment": true, "text": true, "processing-instruction": true, "node": true}
+
+func tokenizeXPath(expr string) ([]xpathToken, error) {
+	var tokens []xpathToken
+	rs := []rune(expr)
+	// An operand precedes the current token, so that '*' is multiplication and names such as 'and' are operators.
+	operandPrecedes := func() bool {
+		if len(tokens) == 0 {
+			return false
+		}
+		last := tokens[len(tokens)-1]
+		switch {
+		case last.kind == xtOperator:
+			return false
+		case last.kind == xtPunct:
+			return last.val == ")" || last.val == "]" || last.val == "." || last.val == ".."
+		}
+		return true
+	}
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		start := i
+		add := func(kind xpathTokenKind, val string) {
+			tokens = append(tokens, xpathToken{kind: kind, val: val, pos: start})
+		}
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '"' || r == '\'':
+			end := i + 1
+			for end < len(rs) && rs[end] != r {
+				end++
+			}
+			if end == len(rs) {
+				return nil, fmt.Errorf("%w: unterminated literal at offset %d in %q", ErrInvalidXPath, start, expr)
+			}
+			add(xtLiteral, string(rs[i+1:end]))
+			i = end + 1
+		case unicode.IsDigit(r) || (r == '.' && i+1 < len(rs) && unicode.IsDigit(rs[i+1])):
+			for i < len(rs) && unicode.IsDigit(rs[i]) {
+				i++
+			}
+			if i < len(rs) && rs[i] == '.' {
+				i++
+				for i < len(rs) && unicode.IsDigit(rs[i]) {
+					i++
+				}
+			}
+			add(xtNumber, string(rs[start:i]))
+		case r == '.':
+			if i+1 < len(rs) && rs[i+1] == '.' {
+				i++
+			}
+			i++
+			add(xtPunct, string(rs[start:i]))
+		case r == '/':
+			if i+1 < len(rs) && rs[i+1] == '/' {
+				i++
+			}
+			i++
+			add(xtOperator, string(rs[start:i]))
+		case r == '(' || r == ')' || r == '[' || r == ']' || r == '@' || r == ',':
+			i++
+			add(xtPunct, string(r))
+		case r == ':':
+			if i+1 >= len(rs) || rs[i+1] != ':' {
+				return nil, fmt.Errorf("%w: unexpected ':' at offset %d in %q", ErrInvalidXPath, start, expr)
+			}
+			i += 2
+			add(xtPunct, "::")
+		case r == '|' || r == '+' || r == '-' || r == '=':
+			i++
+			add(xtOperator, string(r))
+		case r == '!' || r == '<' || r == '>':
+			i++
+			if i < len(rs) && rs[i] == '=' {
+				i++
+			} else if r == '!' {
+				return nil, fmt.Errorf("%w: unexpected '!' at offset %d in %q", ErrInvalidXPath, start, expr)
+			}
+			add(xtOperator, string(rs[start:i]))
+		case r == '*':
+			i++
+			if operandPrecedes() {
+				add(xtOperator, "*")
+			} else {
+				add(xtName, "*")
+			}
+		case r == '$':
+			i++
+			name, end := scanQName(rs, i, false)
+			if name == "" {
+				return nil, fmt.Errorf("%w: missing variable name at offset %d in %q", ErrInvalidXPath, start, expr)
+			}
+			i = end
+			add(xtVariable, name)
+		case isNameStartChar(r):
+			name, end := scanQName(rs, i, true)
+			i = end
+			if operandPrecedes() && (name == "and" || name == "or" || name == "div" || name == "mod") {
+				add(xtOperator, name)
+			} else {
+				add(xtName, name)
+			}
+		default:
+			return nil, fmt.Errorf("%w: unexpected %q at offset %d in %q", ErrInvalidXPath, r, start, expr)
+		}
+	}
+	return append(tokens, xpathToken{kind: xtEOF, pos: len(rs)}), nil
+}
+
+// scanQName scans a name, optionally qualified by a prefix, starting at i. If wildcard is set, the local part
+// may be '*'.
+func scanQName(rs []rune, i int, wildcard bool) (string, int) {
+	start := i
+	scanNCName := func() bool {
+		if i >= len(rs) || !isNameStartChar(rs[i]) {
+			return false
+		}
+		for i < len(rs) && isNameChar(rs[i]) {
+			i++
+		}
+		return true
+	}
+	if !scanNCName() {
+		return "", start
+	}
+	// A single ':' separates prefix and local name, a '::' follows an axis name.
+	if i+1 < len(rs) && rs[i] == ':' && rs[i+1] != ':' {
+		prefixEnd := i
+		i++
+		if wildcard && rs[i] == '*' {
+			i++
+		} else if !scanNCName() {
+			return string(rs[start:prefixEnd]), prefixEnd
+		}
+	}
+	return string(rs[start:i]), i
+}
+
+func isNameStartChar(r rune) bool {
+	return r == '_' || unicode.IsLetter(r)
+}
+
+// isNCName reports whether s is a name without a colon, as used for a namespace prefix.
+func isNCName(s string) bool {
+	for i, r := range s {
+		if (i == 0 && !isNameStartChar(r)) || !isNameChar(r) {
+			return false
+		}
+	}
+	return s != ""
+}
+
+func isNameChar(r rune) bool {
+	return isNameStartChar(r) || unicode.IsDigit(r) || r == '-' || r == '.' || unicode.Is(unicode.Mn, r)
+}
+
+// xpathParser is a recursive descent parser for the XPath 1.0 grammar, which records the prefixes of the names
+// it encounters.
+type xpathParser struct {
+	expr   string
+	tokens []xpathToken
+	pos    int
+	used   map[string]bool
+}
+
+func (p *xpathParser) peek() xpathToken {
+	return p.tokens[p.pos]
+}
+
+func (p *xpathParser) peekAt(n int) xpathToken {
+	if p.pos+n < len(p.tokens) {
+		return p.tokens[p.pos+n]
+	}
+	return p.tokens[len(p.tokens)-1]
+}
+
+func (p *xpathParser) next() xpathToken {
+	t := p.tokens[p.pos]
+	if t.kind != xtEOF {
+		p.pos++
+	}
+	return t
+}
+
+func (p *xpathParser) is(kind xpathTokenKind, vals ...string) bool {
+	t := p.peek()
+	if t.kind != kind {
+		return false
+	}
+	for _, v := range vals {
+		if t.val == v {
+			return true
+		}
+	}
+	return len(vals) == 0
+}
+
+func (p *xpathParser) expect(kind xpathTokenKind, val string) error {
+	if !p.is(kind, val) {
+		return p.errorf(p.peek(), "expected %q", val)
+	}
+	p.next()
+	return nil
+}
+
+func (p *xpathParser) errorf(t xpathToken, format string, args ...interface{}) error {
+	if t.kind == xtEOF {
+		return fmt.Errorf("%w: %s at end of %q", ErrInvalidXPath, fmt.Sprintf(format, args...), p.expr)
+	}
+	return fmt.Errorf("%w: %s at offset %d in %q", ErrInvalidXPath, fmt.Sprintf(format, args...), t.pos, p.expr)
+}
+
+func (p *xpathParser) useName(name string) {
+	if i := strings.Index(name, ":"); i > 0 {
+		p.used[name[:i]] = true
+	}
+}
+
+func (p *xpathParser) parseExpr() error {
+	return p.parseBinary(0)
+}
+
+// xpathBinaryOperators lists the binary operators in increasing order of precedence.
+var xpathBinaryOperators = [][]string{
+	{"or"}, {"and"}, {"=", "!="}, {"<", "<=", ">", ">="}, {"+", "-"}, {"*", "div", "mod"},
+}
+
+func (p *xpathParser) parseBinary(level int) error {
+	if level == len(xpathBinaryOperators) {
+		return p.parseUnary()
+	}
+	if err := p.parseBinary(level + 1); err != nil {
+		return err
+	}
+	for p.is(xtOperator, xpathBinaryOperators[level]...) {
+		p.next()
+		if err := p.parseBinary(level + 1); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *xpathParser) parseUnary() error {
+	for p.is(xtOperator, "-") {
+		p.next()
+	}
+	if err := p.parsePath(); err != nil {
+		return err
+	}
+	for p.is(xtOperator, "|") {
+		p.next()
+		if err := p.parsePath(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (p *xpathParser) parsePath() error {
+	t := p.peek()
+	switch {
+	case t.kind == xtOperator && (t.val == "/" || t.val == "//"):
+		p.next()
+		if t.val == "//" || p.startsStep() {
+			return p.parseRelativePath()
+		}
+		return nil
+	case t.kind == xtVariable, t.kind == xtLiteral, t.kind == xtNumber, p.is(xtPunct, "("), p.startsFunctionCall():
+		if err := p.parseFilter(); err != nil {
+			return err
+		}
+		if p.is(xtOperator, "/", "//") {
+			p.next()
+			return p.parseRelativePath()
+		}
+		return nil
+	}
+	return p.parseRelativePath()
+}
+
+func (p *xpathParser) startsFunctionCall() bool {
+	t := p.peek()
+	return t.kind == xtName && !xpathNodeTypes[t.val] && p.peekAt(1).kind == xtPunct && p.peekAt(1).val == "("
+}
+
+func (p *xpathParser) startsStep() bool {
+	t := p.peek()
+	return t.kind == xtName || (t.kind == xtPunct && (t.val == "." || t.val == ".." || t.val == "@"))
+}
+
+func (p *xpathParser) parseFilter() error {
+	t := p.next()
+	switch {
+	case t.kind == xtVariable:
+		p.useName(t.val)
+	case t.kind == xtLiteral, t.kind == xtNumber:
+	case t.kind == xtPunct && t.val == "(":
+		if err := p.parseExpr(); err != nil {
+			return err
+		}
+		if err := p.expect(xtPunct, ")"); err != nil {
+			return err
+		}
+	default:
+		p.useName(t.val)
+		p.next()
+		if !p.is(xtPunct, ")") {
+			for {
+				if err := p.parseExpr(); err != nil {
+					return err
+				}
+				if !p.is(xtPunct, ",") {
+					break
+				}
+				p.next()
+			}
+		}
+		if err := p.expect(xtPunct, ")"); err != nil {
+			return err
+		}
+	}
+	return p.parsePredicates()
+}
+
+func (p *xpathParser) parseRelativePath() error {
+	for {
+		if err := p.parseStep(); err != nil {
+			return err
+		}
+		if !p.is(xtOperator, "/", "//") {
+			return nil
+		}
+		p.next()
+	}
+}
+
+func (p *xpathParser) parseStep() error {
+	if p.is(xtPunct, ".", "..") {
+		p.next()
+		return nil
+	}
+	if p.is(xtPunct, "@") {
+		p.next()
+	} else if p.peek().kind == xtName && p.peekAt(1).kind == xtPunct && p.peekAt(1).val == "::" {
+		if axis := p.next(); !xpathAxes[axis.val] {
+			return p.errorf(axis, "unknown axis %q", axis.val)
+		}
+		p.next()
+	}
+	t := p.peek()
+	if t.kind != xtName {
+		return p.errorf(t, "expected node test")
+	}
+	p.next()
+	if xpathNodeTypes[t.val] && p.is(xtPunct, "(") {
+		p.next()
+		if t.val == "processing-instruction" && p.is(xtLiteral) {
+			p.next()
+		}
+		if err := p.expect(xtPunct, ")"); err != nil {
+			return err
+		}
+	} else {
+		p.useName(t.val)
+	}
+	return p.parsePredicates()
+}
+
+func (p *xpathParser) parsePredicates() error {
+	for p.is(xtPunct, "[") {
+		p.next()
+		if err := p.parseExpr(); err != nil {
+			return err
+		}
+		if err := p.expect(xtPunct, "]"); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,157 @@
+package ops
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/damianoneill/net/v2/netconf/common"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestXPathFilterValid(t *testing.T) {
+
+	nslist := []Namespace{{"if", "urn:ietf:params:xml:ns:yang:ietf-interfaces"}, {"ip", "urn:ietf:params:xml:ns:yang:ietf-ip"}, {"fn", "urn:fn"}}
+	for _, xpath := range []string{
+		`/`,
+		`/if:interfaces`,
+		`//if:interface`,
+		`/if:interfaces/if:interface[if:name="eth0"]/ip:ipv4`,
+		`/if:interfaces/if:interface[if:name='eth"0']`,
+		`/if:interfaces/if:interface[if:mtu > 1500 and if:mtu <= 9000 or not(if:enabled)]`,
+		`/if:interfaces/*`,
+		`/if:interfaces/if:*`,
+		`/if:interfaces/if:interface[count(ip:ipv4/ip:address) * 2 != 4 div 2 - -1 mod 3]`,
+		`/if:interfaces/if:interface[position() = last()]/@if:name`,
+		`/if:interfaces/if:interface/child::if:name | /if:interfaces/descendant-or-self::node()/text()`,
+		`(/if:interfaces/if:interface)[1]/..`,
+		`/if:interfaces/if:interface[starts-with(if:name, 'eth')][fn:custom(., .5, 'x')]`,
+		`/and/or/div`,
+		`/if:interfaces/if:interface[@xml:lang='en']`,
+	} {
+		_, err := NewXPathFilter(xpath, nslist)
+		assert.NoError(t, err, "Expecting xpath %s to be valid", xpath)
+	}
+}
+
+func TestXPathFilterInvalid(t *testing.T) {
+
+	nslist := []Namespace{{"tns", "urn:tns"}}
+	for xpath, message := range map[string]string{
+		``:                           `invalid xpath: expected node test at end of ""`,
+		`/tns:top[`:                  `invalid xpath: expected node test at end of "/tns:top["`,
+		`/tns:top[tns:name='x`:       `invalid xpath: unterminated literal at offset 18 in "/tns:top[tns:name='x"`,
+		`/tns:top]`:                  `invalid xpath: unexpected "]" at offset 8 in "/tns:top]"`,
+		`/tns:top/other:leaf`:        `invalid xpath: prefix "other" is not declared in "/tns:top/other:leaf"`,
+		`/tns:top[other:f(.)]`:       `invalid xpath: prefix "other" is not declared in "/tns:top[other:f(.)]"`,
+		`/tns:top/sideways::tns:a`:   `invalid xpath: unknown axis "sideways" at offset 9 in "/tns:top/sideways::tns:a"`,
+		`/tns:top[count(tns:a]`:      `invalid xpath: expected ")" at offset 20 in "/tns:top[count(tns:a]"`,
+		`/tns:top#`:                  `invalid xpath: unexpected '#' at offset 8 in "/tns:top#"`,
+		`/tns:top[tns:a ! tns:b]`:    `invalid xpath: unexpected '!' at offset 15 in "/tns:top[tns:a ! tns:b]"`,
+		`/tns:top"/><inject/><x a="`: `invalid xpath: unexpected "/><inject/><x a=" at offset 8 in "/tns:top\"/><inject/><x a=\""`,
+	} {
+		_, err := NewXPathFilter(xpath, nslist)
+		assert.True(t, errors.Is(err, ErrInvalidXPath), "Expecting xpath %s to be invalid", xpath)
+		assert.EqualError(t, err, message, "Expected error for xpath %s", xpath)
+	}
+}
+
+func TestXPathFilterEscaping(t *testing.T) {
+
+	req, _ := xml.Marshal(createGetXpathRequest(`/tns:top[tns:name="a<b&c"]`, []Namespace{{"tns", `urn:tns?a="1"&b=2`}}))
+	assert.Equal(t, `<get><filter xmlns:tns="urn:tns?a=&#34;1&#34;&amp;b=2" type="xpath" select="/tns:top[tns:name=&#34;a&lt;b&amp;c&#34;]"/></get>`,
+		string(req), "Expected escaped xpath filter")
+
+	var filter struct {
+		Namespace string `xml:"tns,attr"`
+		Select    string `xml:"select,attr"`
+	}
+	assert.NoError(t, xml.Unmarshal([]byte(createXpathFilter(`/tns:top[tns:name="a<b&c"]`, []Namespace{{"tns", "urn:tns"}})), &filter))
+	assert.Equal(t, `/tns:top[tns:name="a<b&c"]`, filter.Select, "Expected xpath to survive escaping")
+}
+
+func TestXPathFilterNamespacePrefix(t *testing.T) {
+
+	for _, id := range []string{``, `tns:x`, `1tns`, `tns="urn:x" xmlns:evil`, `t ns`} {
+		_, err := NewXPathFilter(`/top`, []Namespace{{id, "urn:tns"}})
+		assert.True(t, errors.Is(err, ErrInvalidXPath), "Expecting prefix %q to be invalid", id)
+		assert.EqualError(t, err, fmt.Sprintf("invalid xpath: namespace prefix %q is not an NCName", id), "Expected error for prefix %q", id)
+
+		_, err = NewModuleRegistry(nil).XPathFilter(`/top`, []Namespace{{id, "urn:tns"}})
+		assert.True(t, errors.Is(err, ErrInvalidXPath), "Expecting prefix %q to be invalid", id)
+	}
+	_, err := NewXPathFilter(`/t-1.x_y:top`, []Namespace{{"t-1.x_y", "urn:tns"}})
+	assert.NoError(t, err, "Expecting prefix to be valid")
+
+	req, _ := xml.Marshal(createGetXpathRequest(`/top`, []Namespace{{`tns="urn:x" xmlns:evil`, "urn:tns"}}))
+	assert.Equal(t, `<get><filter type="xpath" select="/top"/></get>`, string(req), "Expected invalid prefix to be omitted")
+}
+
+func TestModuleRegistryXPathFilter(t *testing.T) {
+
+	registry := NewModuleRegistry([]Schema{
+		{Identifier: "ietf-interfaces", Namespace: "urn:ietf:params:xml:ns:yang:ietf-interfaces"},
+		{Identifier: "ietf-ip", Namespace: "urn:ietf:params:xml:ns:yang:ietf-ip"},
+		{Identifier: "no-namespace"},
+	})
+	assert.Len(t, registry, 2, "Expected modules with namespaces")
+
+	filter, err := registry.XPathFilter(`/ietf-interfaces:interfaces/ietf-interfaces:interface/ietf-ip:ipv4/x:enabled`, []Namespace{{"x", "urn:x"}})
+	assert.NoError(t, err, "Not expecting filter to fail")
+	assert.Equal(t, []Namespace{{"x", "urn:x"}, {"ietf-interfaces", "urn:ietf:params:xml:ns:yang:ietf-interfaces"},
+		{"ietf-ip", "urn:ietf:params:xml:ns:yang:ietf-ip"}}, filter.Namespaces, "Expected inferred namespaces")
+	assert.NoError(t, filter.Validate(), "Expected inferred filter to be valid")
+
+	_, err = registry.XPathFilter(`/unknown:top`, nil)
+	assert.EqualError(t, err, `invalid xpath: prefix "unknown" is not declared or a known module in "/unknown:top"`, "Expected unknown module")
+}
+
+func TestGetXpathInvalid(t *testing.T) {
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("Capabilities").Return(common.ParseCapabilities([]string{common.CapBase11})).Maybe()
+
+	var result string
+	err := ncs.GetXpathContext(context.Background(), `/tns:element`, nil, &result)
+	assert.True(t, errors.Is(err, ErrInvalidXPath), "Expecting undeclared prefix to be rejected")
+	err = ncs.GetConfigXpath(`/tns:element[`, []Namespace{{"tns", "urn:tns"}}, RunningCfg, &result)
+	assert.True(t, errors.Is(err, ErrInvalidXPath), "Expecting malformed xpath to be rejected")
+
+	err = ncs.GetXpathFilter(context.Background(), &XPathFilter{Select: `/tns:element`}, &result)
+	assert.True(t, errors.Is(err, ErrInvalidXPath), "Expecting undeclared prefix to be rejected")
+	err = ncs.GetConfigXpathFilter(context.Background(), &XPathFilter{Select: `/top`, Namespaces: []Namespace{{"a:b", "urn:tns"}}}, RunningCfg, &result)
+	assert.True(t, errors.Is(err, ErrInvalidXPath), "Expecting invalid prefix to be rejected")
+	err = ncs.GetXpathFilter(context.Background(), nil, &result)
+	assert.True(t, errors.Is(err, ErrInvalidXPath), "Expecting missing filter to be rejected")
+
+	mcli.AssertNotCalled(t, "ExecuteContext")
+}
+
+func TestGetXpathFilter(t *testing.T) {
+
+	registry := NewModuleRegistry([]Schema{{Identifier: "ietf-interfaces", Namespace: ifNS}})
+	filter, err := registry.XPathFilter(`/ietf-interfaces:interfaces`, nil)
+	assert.NoError(t, err, "Not expecting filter to fail")
+
+	ctx := context.Background()
+	ncs, mcli := newOpsSessionWithMockClient(t)
+	mcli.On("ExecuteContext", ctx, createGetXpathFilterRequest(filter)).Return(&common.RPCReply{Data: `<data><interfaces/></data>`}, nil)
+	mcli.On("ExecuteContext", ctx, createGetConfigXpathFilterRequest(filter, RunningCfg)).Return(&common.RPCReply{Data: `<data><interfaces/></data>`}, nil)
+	mcli.On("ExecuteContext", ctx, createGetConfigXpathFilterRequest(nil, RunningCfg)).Return(&common.RPCReply{Data: `<data/>`}, nil)
+
+	var result string
+	assert.NoError(t, ncs.GetXpathFilter(ctx, filter, &result), "Not expecting get to fail")
+	assert.Equal(t, `<interfaces/>`, result, "Expected result")
+	assert.NoError(t, ncs.GetConfigXpathFilter(ctx, filter, RunningCfg, &result), "Not expecting get-config to fail")
+	assert.Equal(t, `<interfaces/>`, result, "Expected result")
+	assert.NoError(t, ncs.GetConfigXpathFilter(ctx, nil, RunningCfg, &result), "Not expecting get-config to fail")
+
+	req, _ := xml.Marshal(createGetXpathFilterRequest(filter))
+	assert.Equal(t, `<get><filter xmlns:ietf-interfaces="urn:ietf:params:xml:ns:yang:ietf-interfaces" type="xpath" `+
+		`select="/ietf-interfaces:interfaces"/></get>`, string(req), "Expected inferred namespace to be declared")
+
+	mcli.AssertExpectations(t)
+}
@@ -0,0 +1,207 @@
+package ops
+
+import (
+	"encoding/xml"
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// Derives the list keys used to compare configurations from the text of YANG modules (RFC 7950), as returned by
+// GetSchema.
+
+// ListKeysFromYang delivers the keys of the lists, and the leaf-lists, defined by the YANG modules and submodules.
+// Lists are qualified by the namespace of the module, except for those defined in groupings or submodules, which
+// may be used in other namespaces.
+func ListKeysFromYang(modules ...string) (ListKeys, error) {
+	keys := ListKeys{}
+	for _, module := range modules {
+		stmts, err := parseYang(module)
+		if err != nil {
+			return nil, err
+		}
+		for _, s := range stmts {
+			var ns string
+			if s.keyword == "module" {
+				ns = s.sub("namespace")
+			}
+			collectYangKeys(s.subs, ns, keys)
+		}
+	}
+	return keys, nil
+}
+
+func collectYangKeys(stmts []*yangStatement, ns string, keys ListKeys) {
+	for _, s := range stmts {
+		switch s.keyword {
+		case "list":
+			if key := s.sub("key"); key != "" {
+				keys[xml.Name{Space: ns, Local: s.arg}] = strings.Fields(key)
+			}
+		case "leaf-list":
+			keys[xml.Name{Space: ns, Local: s.arg}] = []string{}
+		case "grouping":
+			collectYangKeys(s.subs, "", keys)
+			continue
+		}
+		collectYangKeys(s.subs, ns, keys)
+	}
+}
+
+type yangStatement struct {
+	keyword string
+	arg     string
+	subs    []*yangStatement
+}
+
+// sub delivers the argument of the first substatement with the keyword.
+func (s *yangStatement) sub(keyword string) string {
+	for _, sub := range s.subs {
+		if sub.keyword == keyword {
+			return sub.arg
+		}
+	}
+	return ""
+}
+
+type yangToken struct {
+	val    string
+	quoted bool
+	line   int
+}
+
+func (t yangToken) is(punct string) bool {
+	return !t.quoted && t.val == punct
+}
+
+// parseYang parses the statements of a YANG module, without interpreting them.
+func parseYang(text string) ([]*yangStatement, error) {
+	tokens, err := tokenizeYang(text)
+	if err != nil {
+		return nil, err
+	}
+	p := &yangParser{tokens: tokens}
+	stmts, err := p.parseStatements()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("yang: unexpected %q at line %d", p.tokens[p.pos].val, p.tokens[p.pos].line)
+	}
+	return stmts, nil
+}
+
+type yangParser struct {
+	tokens []yangToken
+	pos    int
+}
+
+func (p *yangParser) parseStatements() ([]*yangStatement, error) {
+	var stmts []*yangStatement
+	for p.pos < len(p.tokens) && !p.tokens[p.pos].is("}") {
+		kw := p.tokens[p.pos]
+		if kw.quoted || kw.is("{") || kw.is(";") || kw.is("+") {
+			return nil, fmt.Errorf("yang: expected keyword at line %d, found %q", kw.line, kw.val)
+		}
+		p.pos++
+		s := &yangStatement{keyword: kw.val}
+		// The argument is an unquoted string, or quoted strings concatenated with '+'.
+		if p.pos < len(p.tokens) && !p.tokens[p.pos].is("{") && !p.tokens[p.pos].is(";") {
+			s.arg = p.tokens[p.pos].val
+			p.pos++
+			for p.pos+1 < len(p.tokens) && p.tokens[p.pos].is("+") && p.tokens[p.pos+1].quoted {
+				s.arg += p.tokens[p.pos+1].val
+				p.pos += 2
+			}
+		}
+		switch {
+		case p.pos >= len(p.tokens):
+			return nil, fmt.Errorf("yang: unterminated statement %s at line %d", kw.val, kw.line)
+		case p.tokens[p.pos].is(";"):
+			p.pos++
+		case p.tokens[p.pos].is("{"):
+			p.pos++
+			subs, err := p.parseStatements()
+			if err != nil {
+				return nil, err
+			}
+			if p.pos >= len(p.tokens) {
+				return nil, fmt.Errorf("yang: unterminated block %s at line %d", kw.val, kw.line)
+			}
+			p.pos++
+			s.subs = subs
+		default:
+			return nil, fmt.Errorf("yang: expected ';' or '{' at line %d, found %q", p.tokens[p.pos].line, p.tokens[p.pos].val)
+		}
+		stmts = append(stmts, s)
+	}
+	return stmts, nil
+}
+
+func tokenizeYang(text string) ([]yangToken, error) {
+	var tokens []yangToken
+	rs := []rune(text)
+	line := 1
+	for i := 0; i < len(rs); {
+		r := rs[i]
+		switch {
+		case r == '\n':
+			line++
+			i++
+		case unicode.IsSpace(r):
+			i++
+		case r == '/' && i+1 < len(rs) && rs[i+1] == '/':
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+		case r == '/' && i+1 < len(rs) && rs[i+1] == '*':
+			start := line
+			for i += 2; i+1 < len(rs) && (rs[i] != '*' || rs[i+1] != '/'); i++ {
+				if rs[i] == '\n' {
+					line++
+				}
+			}
+			if i+1 >= len(rs) {
+				return nil, fmt.Errorf("yang: unterminated comment at line %d", start)
+			}
+			i += 2
+		case r == '{' || r == '}' || r == ';':
+			tokens = append(tokens, yangToken{val: string(r), line: line})
+			i++
+		case r == '"' || r == '\'':
+			start := line
+			var b strings.Builder
+			i++
+			for ; i < len(rs) && rs[i] != r; i++ {
+				if rs[i] == '\n' {
+					line++
+				}
+				if r == '"' && rs[i] == '\\' && i+1 < len(rs) {
+					i++
+					switch rs[i] {
+					case 'n':
+						b.WriteRune('\n')
+					case 't':
+						b.WriteRune('\t')
+					default:
+						b.WriteRune(rs[i])
+					}
+					continue
+				}
+				b.WriteRune(rs[i])
+			}
+			if i >= len(rs) {
+				return nil, fmt.Errorf("yang: unterminated string at line %d", start)
+			}
+			i++
+			tokens = append(tokens, yangToken{val: b.String(), quoted: true, line: start})
+		default:
+			start := i
+			for i < len(rs) && !unicode.IsSpace(rs[i]) && rs[i] != '{' && rs[i] != '}' && rs[i] != ';' {
+				i++
+			}
+			tokens = append(tokens, yangToken{val: string(rs[start:i]), line: line})
+		}
+	}
+	return tokens, nil
+}
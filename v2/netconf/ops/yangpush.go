@@ -0,0 +1,374 @@
+package ops
+
+import (
+	"context"
+	"encoding/xml"
+	"errors"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/damianoneill/net/v2/netconf/client"
+	"github.com/damianoneill/net/v2/netconf/common"
+)
+
+// Defines the dynamic datastore subscriptions of Subscription to YANG Notifications (RFC 8639) and YANG-Push
+// (RFC 8641).
+//
+// A subscription delivers datastore updates either periodically, as push-update notifications holding the selected
+// datastore contents, or on-change, as push-change-update notifications holding yang-patch edits. Notifications are
+// routed to the channel of the subscription using the subscription id that they carry, and can be decoded with
+// DecodePushUpdate and DecodePushChangeUpdate.
+
+// YangPatchNS is the namespace of the yang-patch edits delivered by push-change-update notifications.
+const YangPatchNS = "urn:ietf:params:xml:ns:yang:ietf-yang-patch"
+
+// Define the kinds of change that can be excluded from on-change subscriptions.
+const (
+	ChangeCreate  = "create"
+	ChangeDelete  = "delete"
+	ChangeInsert  = "insert"
+	ChangeMove    = "move"
+	ChangeReplace = "replace"
+)
+
+// PushOption configures an establish-subscription or modify-subscription request.
+type PushOption func(*PushParams)
+
+// PushDatastore selects the datastore to which the subscription applies, which is the name of an ietf-datastores
+// identity (e.g. "operational"), or a prefixed identity.
+func PushDatastore(datastore string) PushOption {
+	return func(p *PushParams) {
+		p.Datastore = &DatastoreSelector{DatastoresNS: DatastoresNS, Name: datastoreIdentity(datastore)}
+	}
+}
+
+// PushSubtreeFilter selects the datastore nodes of interest using a subtree filter, which may be an xml string or
+// a struct with xml tags.
+func PushSubtreeFilter(filter interface{}) PushOption {
+	return func(p *PushParams) {
+		p.SubtreeFilter = &SubtreeSelector{Union: common.GetUnion(filter)}
+		p.XpathFilter = nil
+	}
+}
+
+// PushXpathFilter selects the datastore nodes of interest using an xpath expression, with the namespaces used by its
+// prefixes.
+func PushXpathFilter(xpath string, nslist []Namespace) PushOption {
+	return func(p *PushParams) {
+		p.XpathFilter = &XpathSelector{Select: xpath}
+		for _, ns := range nslist {
+			p.XpathFilter.Namespaces = append(p.XpathFilter.Namespaces, xml.Attr{Name: xml.Name{Local: "xmlns:" + ns.Id}, Value: ns.Path})
+		}
+		p.SubtreeFilter = nil
+	}
+}
+
+// PushPeriodic requests that the selected datastore contents are pushed at the period, which is rounded down to
+// hundredths of a second and must not be zero.
+func PushPeriodic(period time.Duration) PushOption {
+	return func(p *PushParams) {
+		if p.Periodic == nil {
+			p.Periodic = &PeriodicTrigger{}
+		}
+		p.Periodic.Period = centiseconds(period)
+		p.OnChange = nil
+	}
+}
+
+// PushAnchorTime defines the time from which periodic updates are scheduled, which must be used with PushPeriodic.
+func PushAnchorTime(t time.Time) PushOption {
+	return func(p *PushParams) {
+		if p.Periodic == nil {
+			p.Periodic = &PeriodicTrigger{}
+		}
+		p.Periodic.AnchorTime = formatTime(t)
+	}
+}
+
+// PushOnChange requests that changes to the selected datastore nodes are pushed as they occur, no more often than
+// the dampening period (which is rounded down to hundredths of a second, and may be zero).
+func PushOnChange(dampening time.Duration) PushOption {
+	return func(p *PushParams) {
+		if p.OnChange == nil {
+			p.OnChange = &OnChangeTrigger{}
+		}
+		p.OnChange.DampeningPeriod = strconv.FormatUint(uint64(centiseconds(dampening)), 10)
+		p.Periodic = nil
+	}
+}
+
+// PushSyncOnStart defines whether an on-change subscription starts with a push-update holding the selected
+// datastore contents.
+func PushSyncOnStart(sync bool) PushOption {
+	return func(p *PushParams) {
+		if p.OnChange == nil {
+			p.OnChange = &OnChangeTrigger{}
+		}
+		p.OnChange.SyncOnStart = strconv.FormatBool(sync)
+	}
+}
+
+// PushExcludedChanges excludes kinds of change (e.g. ChangeCreate) from an on-change subscription.
+func PushExcludedChanges(changes ...string) PushOption {
+	return func(p *PushParams) {
+		if p.OnChange == nil {
+			p.OnChange = &OnChangeTrigger{}
+		}
+		p.OnChange.ExcludedChanges = changes
+	}
+}
+
+// PushStopTime requests that the subscription ends at the time.
+func PushStopTime(t time.Time) PushOption {
+	return func(p *PushParams) {
+		p.StopTime = formatTime(t)
+	}
+}
+
+// PushDelivery defines how notifications are delivered to the subscription channel, for example the back-pressure
+// policy. The subscription id is always taken from the reply. It is ignored by ModifySubscription.
+func PushDelivery(opts *client.SubscriptionOptions) PushOption {
+	return func(p *PushParams) {
+		p.delivery = opts
+	}
+}
+
+func (s *sImpl) EstablishSubscription(nchan chan *common.Notification, options ...PushOption) (string, error) {
+	return s.EstablishSubscriptionContext(context.Background(), nchan, options...)
+}
+
+func (s *sImpl) EstablishSubscriptionContext(ctx context.Context, nchan chan *common.Notification, options ...PushOption) (string, error) {
+	req, err := createEstablishSubscriptionRequest(options...)
+	if err != nil {
+		return "", err
+	}
+
+	opts := client.SubscriptionOptions{}
+	if req.delivery != nil {
+		opts = *req.delivery
+	}
+	// The subscription id is not known until the reply is received.
+	opts.ID = ""
+
+	reply, err := s.Session.SubscribeWithOptions(ctx, req, &opts, nchan)
+	if err != nil {
+		return "", err
+	}
+	sr := &establishSubscriptionReply{}
+	if err = xml.Unmarshal([]byte("<reply>"+reply.Data+"</reply>"), sr); err != nil {
+		return "", err
+	}
+	return sr.ID, nil
+}
+
+func (s *sImpl) ModifySubscription(id string, options ...PushOption) error {
+	return s.ModifySubscriptionContext(context.Background(), id, options...)
+}
+
+func (s *sImpl) ModifySubscriptionContext(ctx context.Context, id string, options ...PushOption) error {
+	req, err := createModifySubscriptionRequest(id, options...)
+	if err != nil {
+		return err
+	}
+	_, err = s.Session.ExecuteContext(ctx, req)
+	return err
+}
+
+func (s *sImpl) DeleteSubscription(id string) error {
+	return s.DeleteSubscriptionContext(context.Background(), id)
+}
+
+func (s *sImpl) DeleteSubscriptionContext(ctx context.Context, id string) error {
+	_, err := s.Session.ExecuteContext(ctx, &DeleteSubscriptionReq{ID: id})
+	return err
+}
+
+func (s *sImpl) KillSubscription(id string) error {
+	return s.KillSubscriptionContext(context.Background(), id)
+}
+
+func (s *sImpl) KillSubscriptionContext(ctx context.Context, id string) error {
+	_, err := s.Session.ExecuteContext(ctx, &KillSubscriptionReq{ID: id})
+	return err
+}
+
+// DecodePushUpdate decodes a push-update notification.
+func DecodePushUpdate(n *common.Notification) (*PushUpdate, error) {
+	u := &PushUpdate{}
+	if err := decodePushNotification(n, "push-update", u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+// DecodePushChangeUpdate decodes a push-change-update notification.
+func DecodePushChangeUpdate(n *common.Notification) (*PushChangeUpdate, error) {
+	u := &PushChangeUpdate{}
+	if err := decodePushNotification(n, "push-change-update", u); err != nil {
+		return nil, err
+	}
+	return u, nil
+}
+
+func decodePushNotification(n *common.Notification, name string, v interface{}) error {
+	if n.XMLName.Space != common.YangPushNS || n.XMLName.Local != name {
+		return fmt.Errorf("notification %s is not a %s", n.XMLName.Local, name)
+	}
+	return xml.Unmarshal([]byte(n.Event), v)
+}
+
+func createEstablishSubscriptionRequest(options ...PushOption) (*EstablishSubscriptionReq, error) {
+	req := &EstablishSubscriptionReq{}
+	for _, opt := range options {
+		opt(&req.PushParams)
+	}
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+func createModifySubscriptionRequest(id string, options ...PushOption) (*ModifySubscriptionReq, error) {
+	req := &ModifySubscriptionReq{ID: id}
+	for _, opt := range options {
+		opt(&req.PushParams)
+	}
+	req.delivery = nil
+	if err := req.validate(); err != nil {
+		return nil, err
+	}
+	return req, nil
+}
+
+// validate checks the options that the server would otherwise reject.
+func (p *PushParams) validate() error {
+	// The period is mandatory for periodic updates, so an anchor time cannot be used on its own.
+	if p.Periodic != nil && p.Periodic.Period == 0 {
+		return errors.New("push subscription: periodic updates require a period")
+	}
+	return nil
+}
+
+func centiseconds(d time.Duration) uint32 {
+	return uint32(d / (10 * time.Millisecond))
+}
+
+// PushUpdate holds the datastore contents delivered by a push-update notification.
+type PushUpdate struct {
+	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:yang:ietf-yang-push push-update"`
+	ID      string   `xml:"id"`
+	// Contents holds the selected datastore contents.
+	Contents AnyData `xml:"datastore-contents"`
+	// IncompleteUpdate is defined if the contents are incomplete.
+	IncompleteUpdate *struct{} `xml:"incomplete-update"`
+}
+
+// PushChangeUpdate holds the changes delivered by a push-change-update notification.
+type PushChangeUpdate struct {
+	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:yang:ietf-yang-push push-change-update"`
+	ID      string   `xml:"id"`
+	// Changes holds the changes to the selected datastore nodes, as yang-patch edits.
+	Changes YangPatch `xml:"datastore-changes>yang-patch"`
+	// IncompleteUpdate is defined if the changes are incomplete.
+	IncompleteUpdate *struct{} `xml:"incomplete-update"`
+}
+
+// YangPatch defines an ordered list of edits (RFC 8072).
+type YangPatch struct {
+	PatchID string          `xml:"patch-id"`
+	Comment string          `xml:"comment"`
+	Edits   []YangPatchEdit `xml:"edit"`
+}
+
+// YangPatchEdit defines an edit applied to a target datastore node.
+type YangPatchEdit struct {
+	EditID string `xml:"edit-id"`
+	// Operation is one of create, delete, insert, merge, move, replace or remove.
+	Operation string `xml:"operation"`
+	// Target identifies the target node, relative to the subscription's datastore root.
+	Target string `xml:"target"`
+	Point  string `xml:"point"`
+	Where  string `xml:"where"`
+	// Value holds the new value of the target node, if any.
+	Value AnyData `xml:"value"`
+}
+
+// AnyData holds arbitrary xml content.
+type AnyData struct {
+	Content string `xml:",innerxml"`
+}
+
+// Decode unmarshals the first element of the content into v, which should be a struct with xml tags.
+func (a *AnyData) Decode(v interface{}) error {
+	return xml.Unmarshal([]byte(a.Content), v)
+}
+
+type establishSubscriptionReply struct {
+	ID string `xml:"urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications id"`
+}
+
+// Request structs.
+
+// PushParams holds the parameters of establish-subscription and modify-subscription requests.
+type PushParams struct {
+	Datastore     *DatastoreSelector
+	SubtreeFilter *SubtreeSelector
+	XpathFilter   *XpathSelector
+	StopTime      string `xml:"stop-time,omitempty"`
+	Periodic      *PeriodicTrigger
+	OnChange      *OnChangeTrigger
+
+	delivery *client.SubscriptionOptions
+}
+
+type DatastoreSelector struct {
+	XMLName      xml.Name `xml:"urn:ietf:params:xml:ns:yang:ietf-yang-push datastore"`
+	DatastoresNS string   `xml:"xmlns:ds,attr"`
+	Name         string   `xml:",chardata"`
+}
+
+type SubtreeSelector struct {
+	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:yang:ietf-yang-push datastore-subtree-filter"`
+	*common.Union
+}
+
+type XpathSelector struct {
+	XMLName    xml.Name   `xml:"urn:ietf:params:xml:ns:yang:ietf-yang-push datastore-xpath-filter"`
+	Namespaces []xml.Attr `xml:",any,attr"`
+	Select     string     `xml:",chardata"`
+}
+
+type PeriodicTrigger struct {
+	XMLName    xml.Name `xml:"urn:ietf:params:xml:ns:yang:ietf-yang-push periodic"`
+	Period     uint32   `xml:"period,omitempty"`
+	AnchorTime string   `xml:"anchor-time,omitempty"`
+}
+
+type OnChangeTrigger struct {
+	XMLName         xml.Name `xml:"urn:ietf:params:xml:ns:yang:ietf-yang-push on-change"`
+	DampeningPeriod string   `xml:"dampening-period,omitempty"`
+	SyncOnStart     string   `xml:"sync-on-start,omitempty"`
+	ExcludedChanges []string `xml:"excluded-change"`
+}
+
+type EstablishSubscriptionReq struct {
+	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications establish-subscription"`
+	PushParams
+}
+
+type ModifySubscriptionReq struct {
+	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications modify-subscription"`
+	ID      string   `xml:"id"`
+	PushParams
+}
+
+type DeleteSubscriptionReq struct {
+	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications delete-subscription"`
+	ID      string   `xml:"id"`
+}
+
+type KillSubscriptionReq struct {
+	XMLName xml.Name `xml:"urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications kill-subscription"`
+	ID      string   `xml:"id"`
+}
@@ -0,0 +1,129 @@
+package ops
+
+import (
+	"encoding/xml"
+	"testing"
+	"time"
+
+	"github.com/damianoneill/net/v2/netconf/client"
+	"github.com/damianoneill/net/v2/netconf/common"
+	"github.com/damianoneill/net/v2/netconf/testserver"
+
+	assert "github.com/stretchr/testify/require"
+)
+
+func TestPushRequests(t *testing.T) {
+
+	anchor := time.Date(2020, time.March, 1, 10, 30, 0, 0, time.UTC)
+	er, err := createEstablishSubscriptionRequest(PushDatastore(OperationalCfg),
+		PushXpathFilter(`/if:interfaces`, []Namespace{{"if", "urn:ietf:params:xml:ns:yang:ietf-interfaces"}}),
+		PushPeriodic(5*time.Second), PushAnchorTime(anchor))
+	assert.NoError(t, err, "Not expecting request to fail")
+	req, _ := xml.Marshal(er)
+	assert.Equal(t, `<establish-subscription xmlns="urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications">`+
+		`<datastore xmlns="urn:ietf:params:xml:ns:yang:ietf-yang-push" xmlns:ds="urn:ietf:params:xml:ns:yang:ietf-datastores">ds:operational</datastore>`+
+		`<datastore-xpath-filter xmlns="urn:ietf:params:xml:ns:yang:ietf-yang-push" xmlns:if="urn:ietf:params:xml:ns:yang:ietf-interfaces">/if:interfaces</datastore-xpath-filter>`+
+		`<periodic xmlns="urn:ietf:params:xml:ns:yang:ietf-yang-push"><period>500</period><anchor-time>2020-03-01T10:30:00Z</anchor-time></periodic>`+
+		`</establish-subscription>`, string(req), "Expected establish-subscription request")
+
+	er, err = createEstablishSubscriptionRequest(PushDatastore(RunningCfg), PushSubtreeFilter(`<top xmlns="urn:tns"/>`),
+		PushOnChange(100*time.Millisecond), PushSyncOnStart(false), PushExcludedChanges(ChangeCreate, ChangeDelete), PushStopTime(anchor))
+	assert.NoError(t, err, "Not expecting request to fail")
+	req, _ = xml.Marshal(er)
+	assert.Equal(t, `<establish-subscription xmlns="urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications">`+
+		`<datastore xmlns="urn:ietf:params:xml:ns:yang:ietf-yang-push" xmlns:ds="urn:ietf:params:xml:ns:yang:ietf-datastores">ds:running</datastore>`+
+		`<datastore-subtree-filter xmlns="urn:ietf:params:xml:ns:yang:ietf-yang-push"><top xmlns="urn:tns"/></datastore-subtree-filter>`+
+		`<stop-time>2020-03-01T10:30:00Z</stop-time>`+
+		`<on-change xmlns="urn:ietf:params:xml:ns:yang:ietf-yang-push"><dampening-period>10</dampening-period><sync-on-start>false</sync-on-start>`+
+		`<excluded-change>create</excluded-change><excluded-change>delete</excluded-change></on-change>`+
+		`</establish-subscription>`, string(req), "Expected establish-subscription request")
+
+	mr, err := createModifySubscriptionRequest("22", PushPeriodic(time.Second), PushDelivery(&client.SubscriptionOptions{}))
+	assert.NoError(t, err, "Not expecting request to fail")
+	req, _ = xml.Marshal(mr)
+	assert.Equal(t, `<modify-subscription xmlns="urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications"><id>22</id>`+
+		`<periodic xmlns="urn:ietf:params:xml:ns:yang:ietf-yang-push"><period>100</period></periodic></modify-subscription>`,
+		string(req), "Expected modify-subscription request")
+
+	req, _ = xml.Marshal(&DeleteSubscriptionReq{ID: "22"})
+	assert.Equal(t, `<delete-subscription xmlns="urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications"><id>22</id></delete-subscription>`,
+		string(req), "Expected delete-subscription request")
+
+	req, _ = xml.Marshal(&KillSubscriptionReq{ID: "22"})
+	assert.Equal(t, `<kill-subscription xmlns="urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications"><id>22</id></kill-subscription>`,
+		string(req), "Expected kill-subscription request")
+}
+
+func TestPushAnchorTimeWithoutPeriod(t *testing.T) {
+
+	ncs, mcli := newOpsSessionWithMockClient(t)
+
+	anchor := time.Date(2020, time.March, 1, 10, 30, 0, 0, time.UTC)
+	_, err := ncs.EstablishSubscription(make(chan *common.Notification), PushDatastore(OperationalCfg), PushAnchorTime(anchor))
+	assert.EqualError(t, err, "push subscription: periodic updates require a period", "Expecting anchor time to require a period")
+	_, err = ncs.EstablishSubscription(make(chan *common.Notification), PushDatastore(OperationalCfg), PushPeriodic(time.Millisecond))
+	assert.EqualError(t, err, "push subscription: periodic updates require a period", "Expecting period to be defined")
+	err = ncs.ModifySubscription("22", PushAnchorTime(anchor))
+	assert.EqualError(t, err, "push subscription: periodic updates require a period", "Expecting anchor time to require a period")
+
+	mcli.AssertNotCalled(t, "SubscribeWithOptions")
+	mcli.AssertNotCalled(t, "ExecuteContext")
+}
+
+func TestPushSubscriptions(t *testing.T) {
+
+	ts := testserver.NewTestNetconfServer(t).
+		WithRequestHandler(testserver.RawReplyRequestHandler(`<id xmlns="urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications">101</id>`)).
+		WithRequestHandler(testserver.RawReplyRequestHandler(`<id xmlns="urn:ietf:params:xml:ns:yang:ietf-subscribed-notifications">102</id>`))
+	defer ts.Close()
+	ncs := newTransactionSession(t, ts)
+	defer ncs.Close()
+
+	periodic := make(chan *common.Notification, 1)
+	id, err := ncs.EstablishSubscription(periodic, PushDatastore(OperationalCfg), PushSubtreeFilter(`<top xmlns="urn:tns"/>`), PushPeriodic(time.Second))
+	assert.NoError(t, err, "Not expecting subscription to fail")
+	assert.Equal(t, "101", id, "Expected subscription id")
+
+	onChange := make(chan *common.Notification, 1)
+	id, err = ncs.EstablishSubscription(onChange, PushDatastore(OperationalCfg), PushSubtreeFilter(`<top xmlns="urn:tns"/>`), PushOnChange(0))
+	assert.NoError(t, err, "Not expecting subscription to fail")
+	assert.Equal(t, "102", id, "Expected subscription id")
+
+	sh := ts.SessionHandler(ncs.ID())
+	sh.SendNotification(`<push-change-update xmlns="urn:ietf:params:xml:ns:yang:ietf-yang-push"><id>102</id><datastore-changes>` +
+		`<yang-patch xmlns="urn:ietf:params:xml:ns:yang:ietf-yang-patch"><patch-id>1</patch-id>` +
+		`<edit><edit-id>e1</edit-id><operation>replace</operation><target>/tns:top/tns:sub</target><value><sub xmlns="urn:tns">2</sub></value></edit>` +
+		`<edit><edit-id>e2</edit-id><operation>delete</operation><target>/tns:top/tns:old</target></edit>` +
+		`</yang-patch></datastore-changes></push-change-update>`)
+	sh.SendNotification(`<push-update xmlns="urn:ietf:params:xml:ns:yang:ietf-yang-push"><id>101</id>` +
+		`<datastore-contents><top xmlns="urn:tns"><sub>1</sub></top></datastore-contents><incomplete-update/></push-update>`)
+
+	update, err := DecodePushUpdate(<-periodic)
+	assert.NoError(t, err, "Not expecting decode to fail")
+	assert.Equal(t, "101", update.ID, "Expected subscription id")
+	assert.NotNil(t, update.IncompleteUpdate, "Expected incomplete update")
+	top := &struct {
+		XMLName xml.Name `xml:"urn:tns top"`
+		Sub     string   `xml:"sub"`
+	}{}
+	assert.NoError(t, update.Contents.Decode(top), "Not expecting decode to fail")
+	assert.Equal(t, "1", top.Sub, "Expected datastore contents")
+
+	n := <-onChange
+	_, err = DecodePushUpdate(n)
+	assert.EqualError(t, err, "notification push-change-update is not a push-update", "Expecting decode to fail")
+	change, err := DecodePushChangeUpdate(n)
+	assert.NoError(t, err, "Not expecting decode to fail")
+	assert.Equal(t, "102", change.ID, "Expected subscription id")
+	assert.Nil(t, change.IncompleteUpdate, "Not expecting incomplete update")
+	assert.Equal(t, "1", change.Changes.PatchID, "Expected patch id")
+	assert.Len(t, change.Changes.Edits, 2, "Expected edits")
+	assert.Equal(t, YangPatchEdit{EditID: "e1", Operation: "replace", Target: "/tns:top/tns:sub", Value: AnyData{Content: `<sub xmlns="urn:tns">2</sub>`}},
+		change.Changes.Edits[0], "Expected replace edit")
+	assert.Equal(t, "delete", change.Changes.Edits[1].Operation, "Expected delete edit")
+
+	err = ncs.DeleteSubscription("102")
+	assert.NoError(t, err, "Not expecting delete to fail")
+	assert.Equal(t, `<id>102</id>`, sh.LastReq().Body, "Expected delete-subscription request")
+	ncs.Unsubscribe(onChange)
+}
@@ -58,6 +58,7 @@ type SessionHandler struct {
 
 	// The capabilities advertised to the client.
 	capabilities []string
+	caps         *common.Capabilities
 	// The session id to be reported to the client.
 	sid uint64
 
@@ -66,6 +67,8 @@ type SessionHandler struct {
 
 	// The HelloMessage sent by the connecting client.
 	ClientHello *common.HelloMessage
+	// The parsed capabilities of the connecting client.
+	clientCaps *common.Capabilities
 
 	// Caller supplied callbacks
 	cb SessionCallback
@@ -160,6 +163,7 @@ func (ncs *Server) newSessionHandler(svrcon *xssh.ServerConn, sid uint64) *Sessi
 	if caps != nil {
 		sh.capabilities = caps
 	}
+	sh.caps = common.ParseCapabilities(sh.capabilities)
 	return sh
 }
 
@@ -186,6 +190,17 @@ func (h *SessionHandler) Handle(ch xssh.Channel) {
 	h.server.trace.EndSession(h, err)
 }
 
+// Capabilities delivers the parsed set of capabilities advertised to the client.
+func (h *SessionHandler) Capabilities() *common.Capabilities {
+	return h.caps
+}
+
+// ClientCapabilities delivers the parsed set of capabilities advertised by the client, or nil if the client
+// hello has not been received.
+func (h *SessionHandler) ClientCapabilities() *common.Capabilities {
+	return h.clientCaps
+}
+
 // Close initiates session tear-down by closing the underlying transport channel.
 func (h *SessionHandler) Close() {
 	_ = h.ch.Close() // nolint: errcheck, gosec
@@ -235,7 +250,8 @@ func (h *SessionHandler) handleHello(token xml.StartElement) {
 
 	err := h.decodeElement(&h.ClientHello, &token)
 	if err == nil {
-		if common.PeerSupportsChunkedFraming(h.ClientHello.Capabilities) && common.PeerSupportsChunkedFraming(h.capabilities) {
+		h.clientCaps = common.ParseCapabilities(h.ClientHello.Capabilities)
+		if h.clientCaps.Supports(common.CapBase11) && h.caps.Supports(common.CapBase11) {
 
 			// Update the codec to use chunked framing from now.
 			codec.EnableChunkedFraming(h.dec, h.enc)
@@ -87,3 +87,64 @@ func TestServer(t *testing.T) {
 	assert.NotEmpty(t, result, "Reply should be non-nil")
 	assert.Equal(t, `<top><sub attr="cfgval1"><child1>cfgval2</child1></sub></top>`, result)
 }
+
+type capabilitiesCallback struct {
+	callback
+	sh         *SessionHandler
+	clientCaps chan *common.Capabilities
+}
+
+func (cb *capabilitiesCallback) HandleRequest(req *RpcRequestMessage) *RpcReplyMessage {
+	cb.clientCaps <- cb.sh.ClientCapabilities()
+	return cb.callback.HandleRequest(req)
+}
+
+func (cb *capabilitiesCallback) Capabilities() []string {
+	return append([]string{common.CapCandidate, interfacesModule.URI()}, common.DefaultCapabilities...)
+}
+
+var interfacesModule = &common.Module{
+	Namespace: "urn:ietf:params:xml:ns:yang:ietf-interfaces",
+	Name:      "ietf-interfaces",
+	Revision:  "2018-02-20",
+	Features:  []string{"arbitrary-names"},
+}
+
+func TestServerCapabilities(t *testing.T) {
+
+	sshcfg, err := ssh.PasswordConfig(TestUserName, TestPassword)
+	assert.NoError(t, err)
+
+	handlers := make(chan *SessionHandler, 1)
+	clientCaps := make(chan *common.Capabilities, 1)
+	factory := func(sh *SessionHandler) SessionCallback {
+		handlers <- sh
+		return &capabilitiesCallback{sh: sh, clientCaps: clientCaps}
+	}
+	server, err := NewServer(context.Background(), "localhost", 0, sshcfg, factory)
+	assert.NoError(t, err)
+	defer server.Close()
+
+	sshConfig := &xssh.ClientConfig{
+		User:            TestUserName,
+		Auth:            []xssh.AuthMethod{xssh.Password(TestPassword)},
+		HostKeyCallback: xssh.InsecureIgnoreHostKey(),
+	}
+
+	ncs, err := ops.NewSession(context.Background(), sshConfig, fmt.Sprintf("%s:%d", "localhost", server.Port()))
+	assert.NoError(t, err, "Not expecting new session to fail")
+	defer ncs.Close()
+
+	caps := ncs.Capabilities()
+	assert.True(t, caps.Supports(common.CapCandidate), "Expected candidate capability")
+	assert.False(t, caps.Supports(common.CapStartup), "Not expecting startup capability")
+	assert.Equal(t, interfacesModule, caps.Module("ietf-interfaces"), "Expected module")
+
+	sh := <-handlers
+	assert.True(t, sh.Capabilities().Supports(common.CapCandidate), "Expected candidate capability")
+
+	var result string
+	err = ncs.GetSubtree("/", &result)
+	assert.NoError(t, err, "Not expecting get to fail")
+	assert.Equal(t, []string{"1.0", "1.1"}, (<-clientCaps).BaseVersions(), "Expected client base versions")
+}
@@ -82,6 +82,13 @@ type replyData struct {
 	Data    string   `xml:",innerxml"`
 }
 
+// rawReplyMessage represents an rpc-reply message whose content is not wrapped in a data element.
+type rawReplyMessage struct {
+	XMLName   xml.Name `xml:"urn:ietf:params:xml:ns:netconf:base:1.0 rpc-reply"`
+	MessageID string   `xml:"message-id,attr"`
+	Body      string   `xml:",innerxml"`
+}
+
 // NotifyMessage defines the contents of a notification message that will be sent to a client session, where the
 // element type of the notification event is unknown.
 type NotifyMessage struct {
@@ -103,6 +110,51 @@ var EchoRequestHandler = func(h *SessionHandler, req *rpcRequestMessage) {
 	assert.NoError(h.t, err, "Failed to encode response")
 }
 
+// DelayedEchoRequestHandler delivers a request handler that responds as EchoRequestHandler, after the delay.
+// Subsequent requests will not be processed until the reply has been sent.
+func DelayedEchoRequestHandler(delay time.Duration) RequestHandler {
+	return func(h *SessionHandler, req *rpcRequestMessage) {
+		time.Sleep(delay)
+		EchoRequestHandler(h, req)
+	}
+}
+
+// DuplicateReplyRequestHandler responds as EchoRequestHandler, but sends the reply twice.
+var DuplicateReplyRequestHandler = func(h *SessionHandler, req *rpcRequestMessage) {
+	EchoRequestHandler(h, req)
+	EchoRequestHandler(h, req)
+}
+
+// UnknownMessageIDRequestHandler responds as EchoRequestHandler, but with a message-id that does not
+// match the request.
+var UnknownMessageIDRequestHandler = func(h *SessionHandler, req *rpcRequestMessage) {
+	EchoRequestHandler(h, &rpcRequestMessage{XMLName: req.XMLName, MessageID: "unknown", Request: req.Request})
+}
+
+// SwappingRequestHandler delivers a request handler that withholds the reply to a request until the next
+// request is received, then replies to both in reverse order. It should be registered for a pair of requests.
+func SwappingRequestHandler() RequestHandler {
+	var held *rpcRequestMessage
+	return func(h *SessionHandler, req *rpcRequestMessage) {
+		if held == nil {
+			held = req
+			return
+		}
+		EchoRequestHandler(h, req)
+		EchoRequestHandler(h, held)
+		held = nil
+	}
+}
+
+// RawReplyRequestHandler delivers a request handler that responds with a reply containing the body, without
+// a data element.
+func RawReplyRequestHandler(body string) RequestHandler {
+	return func(h *SessionHandler, req *rpcRequestMessage) {
+		err := h.encode(&rawReplyMessage{MessageID: req.MessageID, Body: body})
+		assert.NoError(h.t, err, "Failed to encode response")
+	}
+}
+
 // FailingRequestHandler replies to a request with an error.
 var FailingRequestHandler = func(h *SessionHandler, req *rpcRequestMessage) {
 	reply := &RPCReplyMessage{
@@ -310,3 +362,13 @@ func (h *SessionHandler) LastReq() *RPCRequest {
 	}
 	return nil
 }
+
+// ReqNames delivers the element names of the requests received by the handler, in order.
+func (h *SessionHandler) ReqNames() (names []string) {
+	h.reqMutex.Lock()
+	defer h.reqMutex.Unlock()
+	for _, r := range h.Reqs {
+		names = append(names, r.XMLName.Local)
+	}
+	return
+}
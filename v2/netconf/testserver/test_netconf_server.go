@@ -1,6 +1,7 @@
 package testserver
 
 import (
+	"crypto/tls"
 	"fmt"
 	"runtime"
 	"sync/atomic"
@@ -48,6 +49,24 @@ func NewTestNetconfServer(tctx assert.TestingT) *TestNCServer {
 	return ncs
 }
 
+// NewTestNetconfTLSServer creates a new TestNCServer that will accept Netconf over TLS localhost connections on an
+// ephemeral port (available via Port()), authenticating clients according to the supplied TLS configuration.
+// tctx is handled as for NewTestNetconfServer.
+func NewTestNetconfTLSServer(tctx assert.TestingT, cfg *tls.Config) *TestNCServer {
+
+	ncs := &TestNCServer{sessionHandlers: make(map[uint64]*SessionHandler), caps: common.DefaultCapabilities}
+
+	if tctx == nil {
+		// Default test context to built-in implementation.
+		tctx = ncs
+	}
+	ncs.tctx = tctx
+
+	ncs.SSHServer = NewTLSServerHandler(tctx, cfg, ncs.newFactory())
+
+	return ncs
+}
+
 func (ncs *TestNCServer) newFactory() HandlerFactory {
 	return func(t assert.TestingT) SSHHandler {
 		sid := atomic.AddUint64(&ncs.nextSid, 1)
@@ -17,6 +17,10 @@ import (
 // SSHServer represents a test SSH Server
 type SSHServer struct {
 	listener net.Listener
+	config   *ssh.ServerConfig
+	hostKey  ssh.Signer
+	factory  HandlerFactory
+	t        assert.TestingT
 }
 
 // SSHHandler is the interface that is implemented to handle an SSH channel.
@@ -42,9 +46,12 @@ func NewSSHServerHandler(t assert.TestingT, uname, password string, factory Hand
 	listener, err := net.Listen("tcp", "localhost:0")
 	assert.NoError(t, err, "Listen failed")
 
-	go acceptConnections(t, listener, newSSHServerConfig(t, uname, password), factory)
+	hostKey := generateHostKey(t)
+	config := newSSHServerConfig(uname, password, hostKey)
 
-	return &SSHServer{listener: listener}
+	go acceptConnections(t, listener, config, factory)
+
+	return &SSHServer{listener: listener, config: config, hostKey: hostKey, factory: factory, t: t}
 }
 
 // Port delivers the tcp port number on which the server is listening.
@@ -52,6 +59,22 @@ func (ts *SSHServer) Port() int {
 	return ts.listener.Addr().(*net.TCPAddr).Port
 }
 
+// HostKey delivers the public host key presented by the server.
+func (ts *SSHServer) HostKey() ssh.PublicKey {
+	return ts.hostKey.PublicKey()
+}
+
+// CallHome connects to a client listening at the address, as described by RFC 8071, and serves
+// an SSH session over the connection.
+func (ts *SSHServer) CallHome(address string) error {
+	nConn, err := net.Dial("tcp", address)
+	if err != nil {
+		return err
+	}
+	go serveConnection(ts.t, nConn, ts.config, ts.factory) // nolint: errcheck
+	return nil
+}
+
 // Close closes any resources used by the server.
 func (ts *SSHServer) Close() {
 	// nolint: gosec, errcheck
@@ -66,34 +89,42 @@ func acceptConnections(t assert.TestingT, listener net.Listener, config *ssh.Ser
 			return
 		}
 
-		_, chch, reqch, err := ssh.NewServerConn(nConn, config)
-		if err != nil {
+		if serveConnection(t, nConn, config, factory) != nil {
 			return
 		}
+	}
+}
 
-		go ssh.DiscardRequests(reqch)
+func serveConnection(t assert.TestingT, nConn net.Conn, config *ssh.ServerConfig, factory HandlerFactory) error {
+	// nolint: gosec, errcheck
+	_, chch, reqch, err := ssh.NewServerConn(nConn, config)
+	if err != nil {
+		return err
+	}
 
-		// Service the incoming Channel channel.
-		for newChannel := range chch {
-			dataChan, requests, err := newChannel.Accept()
-			assert.NoError(t, err, "Failed to accept new channel")
+	go ssh.DiscardRequests(reqch)
 
-			// Handle the "subsystem" request.
-			go func(in <-chan *ssh.Request) {
-				for req := range in {
-					assert.NoError(t, req.Reply(req.Type == "subsystem", nil), "Request reply failed")
-				}
-			}(requests)
+	// Service the incoming Channel channel.
+	for newChannel := range chch {
+		dataChan, requests, err := newChannel.Accept()
+		assert.NoError(t, err, "Failed to accept new channel")
 
-			go func() {
-				defer dataChan.Close()
-				factory(t).Handle(t, dataChan)
-			}()
-		}
+		// Handle the "subsystem" request.
+		go func(in <-chan *ssh.Request) {
+			for req := range in {
+				assert.NoError(t, req.Reply(req.Type == "subsystem", nil), "Request reply failed")
+			}
+		}(requests)
+
+		go func() {
+			defer dataChan.Close()
+			factory(t).Handle(t, dataChan)
+		}()
 	}
+	return nil
 }
 
-func newSSHServerConfig(t assert.TestingT, uname, password string) *ssh.ServerConfig {
+func newSSHServerConfig(uname, password string, hostKey ssh.Signer) *ssh.ServerConfig {
 	config := &ssh.ServerConfig{
 		PasswordCallback: func(c ssh.ConnMetadata, pass []byte) (*ssh.Permissions, error) {
 			if c.User() == uname && string(pass) == password {
@@ -103,7 +134,7 @@ func newSSHServerConfig(t assert.TestingT, uname, password string) *ssh.ServerCo
 		},
 	}
 
-	config.AddHostKey(generateHostKey(t))
+	config.AddHostKey(hostKey)
 	return config
 }
 
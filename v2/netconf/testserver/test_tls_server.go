@@ -0,0 +1,138 @@
+package testserver
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"io"
+	"math/big"
+	"net"
+	"time"
+
+	assert "github.com/stretchr/testify/require"
+	"golang.org/x/crypto/ssh"
+)
+
+// TLSCredentials defines a matching pair of server and client TLS configurations, with certificates
+// issued by a common, self-signed certificate authority. The server requires clients to present a certificate.
+type TLSCredentials struct {
+	ServerConfig *tls.Config
+	ClientConfig *tls.Config
+}
+
+// NewTLSCredentials delivers a new set of TLS credentials suitable for localhost testing.
+func NewTLSCredentials(t assert.TestingT) *TLSCredentials {
+
+	caKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err, "Failed to generate CA key")
+
+	caTemplate := newCertTemplate(1, "Test CA")
+	caTemplate.IsCA = true
+	caTemplate.BasicConstraintsValid = true
+	caTemplate.KeyUsage = x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature
+
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	assert.NoError(t, err, "Failed to create CA certificate")
+	caCert, err := x509.ParseCertificate(caDER)
+	assert.NoError(t, err, "Failed to parse CA certificate")
+
+	pool := x509.NewCertPool()
+	pool.AddCert(caCert)
+
+	serverTemplate := newCertTemplate(2, "localhost")
+	serverTemplate.DNSNames = []string{"localhost"}
+	serverTemplate.IPAddresses = []net.IP{net.IPv4(127, 0, 0, 1), net.IPv6loopback}
+	serverTemplate.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth}
+
+	clientTemplate := newCertTemplate(3, TestUserName)
+	clientTemplate.ExtKeyUsage = []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth}
+
+	return &TLSCredentials{
+		ServerConfig: &tls.Config{
+			Certificates: []tls.Certificate{issueCertificate(t, serverTemplate, caCert, caKey)},
+			ClientAuth:   tls.RequireAndVerifyClientCert,
+			ClientCAs:    pool,
+		},
+		ClientConfig: &tls.Config{
+			Certificates: []tls.Certificate{issueCertificate(t, clientTemplate, caCert, caKey)},
+			RootCAs:      pool,
+			ServerName:   "localhost",
+		},
+	}
+}
+
+func newCertTemplate(serial int64, cn string) *x509.Certificate {
+	return &x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: cn},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		KeyUsage:     x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment,
+	}
+}
+
+func issueCertificate(t assert.TestingT, template, ca *x509.Certificate, caKey *rsa.PrivateKey) tls.Certificate {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	assert.NoError(t, err, "Failed to generate key")
+
+	der, err := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	assert.NoError(t, err, "Failed to create certificate")
+
+	return tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+}
+
+// NewTLSServer delivers a new test TLS Server, with a Handler that simply echoes lines received.
+func NewTLSServer(t assert.TestingT, cfg *tls.Config) *SSHServer {
+
+	return NewTLSServerHandler(t, cfg, func(t assert.TestingT) SSHHandler { return &echoer{} })
+}
+
+// NewTLSServerHandler delivers a new test TLS Server, with a custom handler.
+// The handler is presented with the TLS connection in place of an SSH channel.
+func NewTLSServerHandler(t assert.TestingT, cfg *tls.Config, factory HandlerFactory) *SSHServer {
+
+	listener, err := tls.Listen("tcp", "localhost:0", cfg)
+	assert.NoError(t, err, "Listen failed")
+
+	go acceptTLSConnections(t, listener, factory)
+
+	return &SSHServer{listener: listener}
+}
+
+func acceptTLSConnections(t assert.TestingT, listener net.Listener, factory HandlerFactory) {
+	for {
+		nConn, err := listener.Accept()
+		if err != nil {
+			return
+		}
+
+		go func(conn *tls.Conn) {
+			defer conn.Close() // nolint: gosec, errcheck
+
+			// Complete the handshake before handing over, so that client authentication failures
+			// are not reported to the handler.
+			if conn.Handshake() != nil {
+				return
+			}
+			factory(t).Handle(t, &connChannel{Conn: conn})
+		}(nConn.(*tls.Conn))
+	}
+}
+
+// connChannel adapts a TLS connection to the ssh.Channel interface expected by handlers.
+type connChannel struct {
+	*tls.Conn
+}
+
+func (c *connChannel) SendRequest(name string, wantReply bool, payload []byte) (bool, error) {
+	return false, errors.New("requests not supported on TLS connection")
+}
+
+func (c *connChannel) Stderr() io.ReadWriter {
+	return nil
+}
+
+var _ ssh.Channel = &connChannel{}